@@ -6,7 +6,6 @@ import (
 	"log"
 	"net/http"
 	"os"
-	"os/exec"
 	"os/signal"
 	"path/filepath"
 	"strings"
@@ -16,6 +15,8 @@ import (
 	"github.com/thenasky/go-framework/internal/core"
 	"github.com/thenasky/go-framework/internal/database"
 	"github.com/thenasky/go-framework/internal/logger"
+	"github.com/thenasky/go-framework/internal/redis"
+	"github.com/thenasky/go-framework/internal/swaggergen"
 
 	// Import modules for auto-registration (init functions)
 	_ "github.com/thenasky/go-framework/modules/demo"
@@ -24,6 +25,8 @@ import (
 	"github.com/joho/godotenv"
 )
 
+//go:generate go run ../../scripts/pure_router_swagger.go -modules ../../modules -out ../../docs/swagger.json
+
 func main() {
 	// Load .env file
 	err := godotenv.Load()
@@ -31,6 +34,10 @@ func main() {
 		log.Println("No .env file found, using default settings")
 	}
 
+	// Banner and console clear (only on interactive terminals by default)
+	logger.SetVersion(core.GetBuildInfo().Version)
+	logger.Init()
+
 	// Auto-generate swagger documentation
 	generateSwaggerDocs()
 
@@ -38,6 +45,15 @@ func main() {
 	logger.LogInfo("Connecting to MongoDB...")
 	database.ConnectMongoDB()
 
+	// Keep watching the connection so a restarted MongoDB is picked up again
+	database.StartHealthMonitor(10 * time.Second)
+
+	// Redis is optional: nothing happens without REDIS_URL
+	redis.ConnectRedis()
+	if redis.Client != nil {
+		core.RegisterHealthCheck("redis", redis.HealthCheck)
+	}
+
 	// Wait a moment for MongoDB connection to establish
 	time.Sleep(2 * time.Second)
 
@@ -84,9 +100,19 @@ func main() {
 		logger.LogError(fmt.Sprintf("Server forced to shutdown: %s", err))
 	}
 
+	// Let modules stop their background work once no more requests are coming in
+	if err := core.Shutdown(ctx); err != nil {
+		logger.LogError(fmt.Sprintf("Module shutdown failed: %s", err))
+	}
+	redis.DisconnectRedis()
+
 	logger.LogInfo("Server exited")
 }
 
+// swaggerDocsFile is where the generated spec is written and served from,
+// relative to the application root (see core.ResolvePath)
+const swaggerDocsFile = "docs/swagger.json"
+
 // generateSwaggerDocs generates swagger purely from router definitions
 func generateSwaggerDocs() {
 	// Check if swagger docs need regeneration
@@ -94,39 +120,38 @@ func generateSwaggerDocs() {
 		return
 	}
 
-	// Run pure router swagger generator (silently)
-	cmd := exec.Command("go", "run", "../scripts/pure_router_swagger.go")
-	cmd.Dir = "."
-
-	// Generate silently, only log errors
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		logger.LogError("Failed to generate swagger: " + string(output))
-		return
+	// Generate in-process (silently), only log errors
+	if err := swaggergen.Generate(core.ResolvePath("modules"), core.ResolvePath(swaggerDocsFile)); err != nil {
+		logger.LogError("Failed to generate swagger: " + err.Error())
 	}
 }
 
-// shouldRegenerateSwagger checks if router files are newer than generated docs
+// shouldRegenerateSwagger checks if module sources are newer than generated docs
 func shouldRegenerateSwagger() bool {
-	docsFile := "docs/swagger.json"
+	// Compiled deployments ship without module sources; serve the bundled docs as-is
+	modulesDir := core.ResolvePath("modules")
+	if _, err := os.Stat(modulesDir); err != nil {
+		return false
+	}
 
 	// If docs don't exist, generate them
-	docsInfo, err := os.Stat(docsFile)
+	docsInfo, err := os.Stat(core.ResolvePath(swaggerDocsFile))
 	if err != nil {
 		return true
 	}
 
 	docsModTime := docsInfo.ModTime()
 
-	// Only check router files (not controllers - they don't matter anymore)
+	// Check all module sources - routes come from router files and summaries
+	// from the handler doc comments in controllers
 	var needsRegeneration bool
-	filepath.Walk("modules", func(path string, info os.FileInfo, err error) error {
+	filepath.Walk(modulesDir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return nil // ignore errors, continue walking
 		}
 
-		// Only check router.go files
-		if strings.HasSuffix(path, "router.go") {
+		// Only check Go source files
+		if strings.HasSuffix(path, ".go") {
 			if info.ModTime().After(docsModTime) {
 				needsRegeneration = true
 				return filepath.SkipAll // we can stop walking once we find one
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/thenasky/go-framework/internal/core"
+	"github.com/thenasky/go-framework/internal/database"
+	"github.com/thenasky/go-framework/internal/logger"
+	"github.com/thenasky/go-framework/internal/redis"
+	"github.com/thenasky/go-framework/modules/email"
+
+	"github.com/joho/godotenv"
+)
+
+// startRetryInterval is how long to wait before retrying when the queue is not reachable yet
+const startRetryInterval = 5 * time.Second
+
+func main() {
+	// Load .env file
+	err := godotenv.Load()
+	if err != nil {
+		log.Println("No .env file found, using default settings")
+	}
+
+	logger.SetVersion(core.GetBuildInfo().Version)
+	logger.Init()
+
+	logger.LogInfo("Connecting to MongoDB...")
+	database.ConnectMongoDB()
+
+	// Keep watching the connection so a restarted MongoDB is picked up again
+	database.StartHealthMonitor(10 * time.Second)
+
+	// Redis is optional: nothing happens without REDIS_URL
+	redis.ConnectRedis()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+
+	// Start the workers eagerly, retrying until the queue backend is reachable
+	service := email.GetDefaultService()
+	for {
+		err := service.Start()
+		if err == nil {
+			break
+		}
+		logger.LogWarn(fmt.Sprintf("Email workers not started, retrying in %s: %s", startRetryInterval, err))
+
+		select {
+		case <-quit:
+			logger.LogInfo("Worker exited")
+			return
+		case <-time.After(startRetryInterval):
+		}
+	}
+
+	logger.LogInfo("Email workers running")
+
+	<-quit
+
+	logger.LogInfo("Shutting down workers...")
+
+	// Give in-flight sends a deadline to finish
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := service.Stop(ctx); err != nil {
+		logger.LogError(fmt.Sprintf("Workers forced to shutdown: %s", err))
+	}
+
+	database.DisconnectMongoDB()
+	redis.DisconnectRedis()
+
+	logger.LogInfo("Worker exited")
+}
@@ -0,0 +1,278 @@
+// Package cache provides an in-memory key-value cache whose entries expire
+// after a time to live. Expired entries are never returned and are removed by a
+// background sweep, so a cache of short-lived keys does not grow without bound.
+//
+//	mx := cache.New[string, []string]("mx", time.Hour)
+//	mx.Set("example.com", hosts)
+//	hosts, ok := mx.Get("example.com")
+//
+// Hits, misses and evictions are exported on /metrics per cache name, and with
+// LOG_CACHE=true every lookup is logged at the CACHE level.
+package cache
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/thenasky/go-framework/internal/logger"
+	"github.com/thenasky/go-framework/internal/metrics"
+)
+
+// Sweep interval bounds: expired entries are removed at most a minute after
+// they expire, without sweeping more than once a second
+const (
+	minSweepInterval = 1 * time.Second
+	maxSweepInterval = 1 * time.Minute
+)
+
+// Prometheus metrics, labelled by cache name
+var (
+	cacheHitsTotal = metrics.NewCounter(
+		"cache_hits_total",
+		"Total number of cache lookups that found a live entry.",
+		"cache",
+	)
+	cacheMissesTotal = metrics.NewCounter(
+		"cache_misses_total",
+		"Total number of cache lookups that found no live entry.",
+		"cache",
+	)
+	cacheEvictionsTotal = metrics.NewCounter(
+		"cache_evictions_total",
+		"Total number of expired entries removed from caches.",
+		"cache",
+	)
+	_ = metrics.NewGaugeVecFunc(
+		"cache_entries",
+		"Number of entries held by each cache, including expired ones not yet swept.",
+		"cache",
+		entriesByCache,
+	)
+)
+
+var (
+	// caches holds the open caches reported by entriesByCache
+	caches   = make(map[statsSource]bool)
+	cachesMu sync.Mutex
+)
+
+// statsSource is implemented by every Cache regardless of its type parameters
+type statsSource interface {
+	Stats() Stats
+}
+
+// Stats describes a cache's activity since it was created
+type Stats struct {
+	Name      string `json:"name"`
+	Entries   int    `json:"entries"`
+	Hits      uint64 `json:"hits"`
+	Misses    uint64 `json:"misses"`
+	Evictions uint64 `json:"evictions"`
+}
+
+// entry is a cached value and the time it expires; a zero time never expires
+type entry[V any] struct {
+	value     V
+	expiresAt time.Time
+}
+
+// expired reports whether the entry has expired at now
+func (e entry[V]) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && now.After(e.expiresAt)
+}
+
+// Cache is a TTL cache safe for concurrent use
+type Cache[K comparable, V any] struct {
+	name      string
+	ttl       time.Duration
+	mu        sync.RWMutex
+	entries   map[K]entry[V]
+	hits      atomic.Uint64
+	misses    atomic.Uint64
+	evictions atomic.Uint64
+	stop      chan struct{}
+	closeOnce sync.Once
+}
+
+// New creates a cache whose entries live for ttl unless set with SetWithTTL; a
+// ttl of zero keeps them until they are deleted. name identifies the cache in
+// metrics and logs. Call Close when the cache is no longer needed.
+func New[K comparable, V any](name string, ttl time.Duration) *Cache[K, V] {
+	c := &Cache[K, V]{
+		name:    name,
+		ttl:     ttl,
+		entries: make(map[K]entry[V]),
+		stop:    make(chan struct{}),
+	}
+
+	go c.sweepLoop(sweepInterval(ttl))
+
+	cachesMu.Lock()
+	caches[c] = true
+	cachesMu.Unlock()
+
+	return c
+}
+
+// Get returns the live value stored for key
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	c.mu.RLock()
+	e, ok := c.entries[key]
+	c.mu.RUnlock()
+
+	if !ok || e.expired(time.Now()) {
+		c.misses.Add(1)
+		cacheMissesTotal.Inc(c.name)
+		c.log("miss", key)
+		var zero V
+		return zero, false
+	}
+
+	c.hits.Add(1)
+	cacheHitsTotal.Inc(c.name)
+	c.log("hit", key)
+	return e.value, true
+}
+
+// Set stores value for key with the cache's default ttl
+func (c *Cache[K, V]) Set(key K, value V) {
+	c.SetWithTTL(key, value, c.ttl)
+}
+
+// SetWithTTL stores value for key for ttl; a ttl of zero never expires
+func (c *Cache[K, V]) SetWithTTL(key K, value V, ttl time.Duration) {
+	e := entry[V]{value: value}
+	if ttl > 0 {
+		e.expiresAt = time.Now().Add(ttl)
+	}
+
+	c.mu.Lock()
+	c.entries[key] = e
+	c.mu.Unlock()
+}
+
+// Delete removes key from the cache
+func (c *Cache[K, V]) Delete(key K) {
+	c.mu.Lock()
+	delete(c.entries, key)
+	c.mu.Unlock()
+}
+
+// Len returns the number of entries, including expired ones not yet swept
+func (c *Cache[K, V]) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.entries)
+}
+
+// Stats returns the cache's size and activity
+func (c *Cache[K, V]) Stats() Stats {
+	return Stats{
+		Name:      c.name,
+		Entries:   c.Len(),
+		Hits:      c.hits.Load(),
+		Misses:    c.misses.Load(),
+		Evictions: c.evictions.Load(),
+	}
+}
+
+// Close stops the background sweep and removes the cache from the metrics.
+// The cache can still be used, but expired entries are no longer swept.
+func (c *Cache[K, V]) Close() {
+	c.closeOnce.Do(func() {
+		close(c.stop)
+
+		cachesMu.Lock()
+		delete(caches, c)
+		cachesMu.Unlock()
+	})
+}
+
+// sweepLoop removes expired entries every interval until the cache is closed
+func (c *Cache[K, V]) sweepLoop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			c.sweep()
+		case <-c.stop:
+			return
+		}
+	}
+}
+
+// sweep removes the expired entries
+func (c *Cache[K, V]) sweep() {
+	now := time.Now()
+
+	c.mu.Lock()
+	removed := 0
+	for key, e := range c.entries {
+		if e.expired(now) {
+			delete(c.entries, key)
+			removed++
+		}
+	}
+	c.mu.Unlock()
+
+	if removed > 0 {
+		c.evictions.Add(uint64(removed))
+		cacheEvictionsTotal.Add(float64(removed), c.name)
+		if logEnabled() {
+			logger.Log(logger.Cache, fmt.Sprintf("%s: evicted %d expired entries", c.name, removed))
+		}
+	}
+}
+
+// log records a lookup when LOG_CACHE is enabled
+func (c *Cache[K, V]) log(result string, key K) {
+	if logEnabled() {
+		logger.Log(logger.Cache, fmt.Sprintf("%s: %s %s", c.name, result, formatKey(key)))
+	}
+}
+
+// formatKey formats a key for the log, quoting strings so keys spanning several
+// lines stay on one
+func formatKey(key any) string {
+	if s, ok := key.(string); ok {
+		return strconv.Quote(s)
+	}
+	return fmt.Sprint(key)
+}
+
+// logEnabled reports whether cache activity is logged (LOG_CACHE=true). It is
+// read on use since caches are created before .env is loaded.
+func logEnabled() bool {
+	return os.Getenv("LOG_CACHE") == "true"
+}
+
+// sweepInterval derives how often expired entries are swept from the default ttl
+func sweepInterval(ttl time.Duration) time.Duration {
+	switch {
+	case ttl <= 0 || ttl > maxSweepInterval:
+		return maxSweepInterval
+	case ttl < minSweepInterval:
+		return minSweepInterval
+	default:
+		return ttl
+	}
+}
+
+// entriesByCache reports the number of entries of every open cache by name
+func entriesByCache() (map[string]float64, error) {
+	cachesMu.Lock()
+	defer cachesMu.Unlock()
+
+	entries := make(map[string]float64, len(caches))
+	for c := range caches {
+		stats := c.Stats()
+		entries[stats.Name] += float64(stats.Entries)
+	}
+	return entries, nil
+}
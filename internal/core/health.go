@@ -0,0 +1,130 @@
+package core
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/thenasky/go-framework/internal/database"
+	"github.com/thenasky/go-framework/internal/router"
+)
+
+// HealthCheck reports the health of a single dependency, returning nil when healthy
+type HealthCheck func(ctx context.Context) error
+
+// DependencyStatus is the health report of a single dependency
+type DependencyStatus struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+var (
+	healthChecks   = make(map[string]HealthCheck)
+	healthChecksMu sync.RWMutex
+)
+
+// RegisterHealthCheck adds a named dependency check to the /health endpoint
+func RegisterHealthCheck(name string, check HealthCheck) {
+	healthChecksMu.Lock()
+	defer healthChecksMu.Unlock()
+	healthChecks[name] = check
+}
+
+func init() {
+	RegisterHealthCheck("database", database.HealthCheck)
+}
+
+// runHealthChecks runs every registered check and reports whether all passed
+func runHealthChecks(ctx context.Context) (map[string]DependencyStatus, bool) {
+	healthChecksMu.RLock()
+	names := make([]string, 0, len(healthChecks))
+	for name := range healthChecks {
+		names = append(names, name)
+	}
+	healthChecksMu.RUnlock()
+	sort.Strings(names)
+
+	results := make(map[string]DependencyStatus, len(names))
+	healthy := true
+	for _, name := range names {
+		healthChecksMu.RLock()
+		check := healthChecks[name]
+		healthChecksMu.RUnlock()
+
+		if err := check(ctx); err != nil {
+			results[name] = DependencyStatus{Status: "unhealthy", Error: err.Error()}
+			healthy = false
+			continue
+		}
+		results[name] = DependencyStatus{Status: "healthy"}
+	}
+
+	return results, healthy
+}
+
+// healthHandler reports overall service health including every registered dependency
+func healthHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	dependencies, healthy := runHealthChecks(ctx)
+
+	payload := map[string]interface{}{
+		"timestamp":    time.Now().Format(time.RFC3339),
+		"dependencies": dependencies,
+	}
+
+	res := router.NewResponse(w).WithRequest(r)
+	if !healthy {
+		payload["status"] = "unhealthy"
+		res.Custom(http.StatusServiceUnavailable, "error", "Service is unhealthy", payload)
+		return
+	}
+
+	payload["status"] = "healthy"
+	res.Success("Service is healthy", payload)
+}
+
+// livezHandler is the liveness probe: it answers 200 whenever the process can
+// serve requests, so dependency outages never get the pod restarted
+func livezHandler(w http.ResponseWriter, r *http.Request) {
+	res := router.NewResponse(w).WithRequest(r)
+	res.Success("Service is alive", map[string]interface{}{
+		"status":    "alive",
+		"timestamp": time.Now().Format(time.RFC3339),
+	})
+}
+
+// readyzHandler is the readiness probe: it answers 200 only when every dependency
+// is healthy and maintenance mode is off, so load balancers stop routing traffic
+// during outages
+func readyzHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	dependencies, ready := runHealthChecks(ctx)
+
+	maintenance := DependencyStatus{Status: "healthy"}
+	if maintenanceMode.Load() {
+		maintenance = DependencyStatus{Status: "unhealthy", Error: "maintenance mode is enabled"}
+		ready = false
+	}
+	dependencies["maintenance"] = maintenance
+
+	payload := map[string]interface{}{
+		"timestamp":    time.Now().Format(time.RFC3339),
+		"dependencies": dependencies,
+	}
+
+	res := router.NewResponse(w).WithRequest(r)
+	if !ready {
+		payload["status"] = "not_ready"
+		res.Custom(http.StatusServiceUnavailable, "error", "Service is not ready", payload)
+		return
+	}
+
+	payload["status"] = "ready"
+	res.Success("Service is ready", payload)
+}
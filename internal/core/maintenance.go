@@ -0,0 +1,57 @@
+package core
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync/atomic"
+
+	"github.com/thenasky/go-framework/internal/logger"
+	"github.com/thenasky/go-framework/internal/middleware"
+	"github.com/thenasky/go-framework/internal/router"
+)
+
+// maintenanceMode is read by the maintenance middleware on every request
+var maintenanceMode atomic.Bool
+
+// maintenanceAllowlist holds the paths still served while maintenance mode is on
+var maintenanceAllowlist = []string{"/health", "/livez", "/readyz", "/metrics", "/version", "/_maintenance"}
+
+// adminAPIKeys returns the keys accepted by admin-only endpoints, from ADMIN_API_KEYS
+func adminAPIKeys() []string {
+	var keys []string
+	for _, key := range strings.Split(os.Getenv("ADMIN_API_KEYS"), ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// AdminMiddleware guards admin-only endpoints with the keys from ADMIN_API_KEYS
+func AdminMiddleware() func(http.HandlerFunc) http.HandlerFunc {
+	return middleware.APIKeyMiddleware(adminAPIKeys())
+}
+
+// maintenanceHandler reports maintenance mode on GET and toggles it on POST
+func maintenanceHandler(w http.ResponseWriter, r *http.Request) {
+	res := router.NewResponse(w).WithRequest(r)
+
+	if r.Method == http.MethodPost {
+		var body struct {
+			Enabled *bool `json:"enabled"`
+		}
+		if err := router.NewRequest(r).JSON(&body); err != nil || body.Enabled == nil {
+			res.BadRequest("Request body must be {\"enabled\": true|false}", nil)
+			return
+		}
+
+		maintenanceMode.Store(*body.Enabled)
+		logger.FromContext(r.Context()).Warn(fmt.Sprintf("Maintenance mode set to %t", *body.Enabled))
+	}
+
+	res.Success("Maintenance mode retrieved successfully", map[string]interface{}{
+		"enabled": maintenanceMode.Load(),
+	})
+}
@@ -0,0 +1,23 @@
+package core
+
+import "github.com/thenasky/go-framework/internal/router"
+
+// Spanish translations of the core and middleware response messages
+func init() {
+	router.RegisterMessages("es", map[string]string{
+		"Service is healthy":                             "El servicio funciona correctamente",
+		"Service is unhealthy":                           "El servicio no funciona correctamente",
+		"Service is alive":                               "El servicio está activo",
+		"Service is ready":                               "El servicio está listo",
+		"Service is not ready":                           "El servicio no está listo",
+		"Routes retrieved successfully":                  "Rutas obtenidas correctamente",
+		"Method %s not allowed for %s":                   "Método %s no permitido para %s",
+		"Maintenance mode retrieved successfully":        "Modo de mantenimiento obtenido correctamente",
+		"Request body must be {\"enabled\": true|false}": "El cuerpo de la solicitud debe ser {\"enabled\": true|false}",
+		"Service under maintenance":                      "Servicio en mantenimiento",
+		"A valid API key is required":                    "Se requiere una clave de API válida",
+		"Invalid JSON body":                              "Cuerpo JSON inválido",
+		"Validation failed":                              "La validación falló",
+		"An unexpected error occurred":                   "Ocurrió un error inesperado",
+	})
+}
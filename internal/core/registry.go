@@ -0,0 +1,135 @@
+package core
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/thenasky/go-framework/internal/router"
+
+	"github.com/gorilla/mux"
+)
+
+// RouteEntry describes a single registered route
+type RouteEntry struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	Module string `json:"module"`
+}
+
+var (
+	routeRegistry   []RouteEntry
+	routeRegistryMu sync.RWMutex
+
+	// currentModule is the module whose routes are being registered
+	currentModule string
+)
+
+func init() {
+	router.SetRouteHook(recordRoute)
+	router.SetAllowedMethodsLookup(allowHeaderMethods)
+}
+
+// recordRoute adds a route to the registry, attributed to the module being registered
+func recordRoute(method, path string) {
+	routeRegistryMu.Lock()
+	defer routeRegistryMu.Unlock()
+
+	routeRegistry = append(routeRegistry, RouteEntry{
+		Method: method,
+		Path:   path,
+		Module: currentModule,
+	})
+}
+
+// Routes returns every registered route sorted by path and method
+func Routes() []RouteEntry {
+	routeRegistryMu.RLock()
+	routes := make([]RouteEntry, len(routeRegistry))
+	copy(routes, routeRegistry)
+	routeRegistryMu.RUnlock()
+
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+
+	return routes
+}
+
+// handleCore registers a framework-level route on the main router and records it
+func handleCore(r *mux.Router, method, path string, handler http.HandlerFunc) {
+	r.HandleFunc(path, handler).Methods(method)
+
+	currentModule = "core"
+	recordRoute(method, path)
+	currentModule = ""
+}
+
+// routesHandler lists every registered route
+func routesHandler(w http.ResponseWriter, r *http.Request) {
+	routes := Routes()
+
+	res := router.NewResponse(w).WithRequest(r)
+	res.Success("Routes retrieved successfully", map[string]interface{}{
+		"routes": routes,
+		"total":  len(routes),
+	})
+}
+
+// allowedMethods returns the methods registered for the concrete request path
+func allowedMethods(path string) []string {
+	routeRegistryMu.RLock()
+	defer routeRegistryMu.RUnlock()
+
+	seen := make(map[string]bool)
+	var methods []string
+	for _, route := range routeRegistry {
+		if seen[route.Method] || !templateMatches(route.Path, path) {
+			continue
+		}
+		seen[route.Method] = true
+		methods = append(methods, route.Method)
+	}
+
+	sort.Strings(methods)
+	return methods
+}
+
+// allowHeaderMethods returns the methods to list in the Allow header for path:
+// the registered ones plus OPTIONS, which the router answers for every route.
+// It returns nil when no route matches path.
+func allowHeaderMethods(path string) []string {
+	methods := allowedMethods(path)
+	if len(methods) > 0 && !contains(methods, "OPTIONS") {
+		methods = append(methods, "OPTIONS")
+	}
+	return methods
+}
+
+// templateMatches reports whether path matches a route template such as /api/v1/emails/{id}/status
+func templateMatches(template, path string) bool {
+	templateSegments := strings.Split(strings.Trim(template, "/"), "/")
+	pathSegments := strings.Split(strings.Trim(path, "/"), "/")
+	if len(templateSegments) != len(pathSegments) {
+		return false
+	}
+
+	for i, segment := range templateSegments {
+		isVariable := strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}")
+		if isVariable {
+			if pathSegments[i] == "" {
+				return false
+			}
+			continue
+		}
+		if segment != pathSegments[i] {
+			return false
+		}
+	}
+
+	return true
+}
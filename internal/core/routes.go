@@ -1,10 +1,20 @@
 package core
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 
 	"github.com/thenasky/go-framework/internal/logger"
+	"github.com/thenasky/go-framework/internal/metrics"
+	"github.com/thenasky/go-framework/internal/middleware"
+	"github.com/thenasky/go-framework/internal/router"
+	"github.com/thenasky/go-framework/internal/swaggergen"
 
 	"github.com/gorilla/mux"
 )
@@ -14,6 +24,24 @@ type ModuleRegistrar interface {
 	RegisterRoutes(r *mux.Router)
 }
 
+// ModuleMiddleware can optionally be implemented by modules to wrap all of their routes
+// (e.g. auth or rate limiting for the whole module prefix)
+type ModuleMiddleware interface {
+	Middlewares() []func(http.HandlerFunc) http.HandlerFunc
+}
+
+// ModuleShutdowner can optionally be implemented by modules that need to release
+// resources (background workers, connections) when the server shuts down
+type ModuleShutdowner interface {
+	Shutdown(ctx context.Context) error
+}
+
+// ModuleSetup can optionally be implemented by modules with setup that must only
+// happen when the module is enabled (health checks, metrics, background work)
+type ModuleSetup interface {
+	Setup()
+}
+
 // ModuleInfo holds information about a discovered module
 type ModuleInfo struct {
 	Name   string
@@ -31,27 +59,122 @@ func NewRouter() http.Handler {
 
 	// Register all discovered modules
 	for _, moduleInfo := range discoveredModules {
-		moduleInfo.Module.RegisterRoutes(router)
+		registerModule(router, moduleInfo)
 	}
 
+	// Service health - used by load balancers as a readiness probe
+	handleCore(router, "GET", "/health", healthHandler)
+
+	// Kubernetes probes - liveness never checks dependencies, readiness does
+	handleCore(router, "GET", "/livez", livezHandler)
+	handleCore(router, "GET", "/readyz", readyzHandler)
+
+	// Build information
+	handleCore(router, "GET", "/version", versionHandler)
+
+	// Prometheus metrics
+	handleCore(router, "GET", "/metrics", metrics.Handler)
+
+	// Route introspection
+	handleCore(router, "GET", "/_routes", routesHandler)
+
+	// Maintenance mode toggle - guarded by ADMIN_API_KEYS
+	adminMaintenance := AdminMiddleware()(maintenanceHandler)
+	handleCore(router, "GET", "/_maintenance", adminMaintenance)
+	handleCore(router, "POST", "/_maintenance", adminMaintenance)
+
 	// Swagger documentation - serve our custom swagger.json
-	router.HandleFunc("/swagger", swaggerUIHandler).Methods("GET")
-	router.HandleFunc("/swagger/", swaggerUIHandler).Methods("GET")
-	router.HandleFunc("/swagger/swagger.json", swaggerJSONHandler).Methods("GET")
+	handleCore(router, "GET", "/swagger", swaggerUIHandler)
+	handleCore(router, "GET", "/swagger/", swaggerUIHandler)
+	handleCore(router, "GET", "/swagger/swagger.json", swaggerJSONHandler)
 
-	// Custom 404 handler
+	// Let LOG_QUERIES log the matched route's path variables
+	logger.SetRouteResolver(func(r *http.Request) (string, map[string]string, bool) {
+		var match mux.RouteMatch
+		if !router.Match(r, &match) || match.Route == nil || match.MatchErr != nil {
+			return "", nil, false
+		}
+		template, err := match.Route.GetPathTemplate()
+		if err != nil {
+			return "", nil, false
+		}
+		return template, match.Vars, true
+	})
+
+	// Custom 404 and 405 handlers
 	router.NotFoundHandler = http.HandlerFunc(notFoundHandler)
+	router.MethodNotAllowedHandler = http.HandlerFunc(methodNotAllowedHandler)
 
-	// Apply middleware
-	return logger.RequestLogger(router)
+	// Apply middleware - recovery runs inside the request logger so panics are
+	// logged with the request's correlation ID and the 500 response is recorded.
+	// Maintenance mode short-circuits everything but the allow-listed paths.
+	maintenanceMode.Store(os.Getenv("MAINTENANCE_MODE") == "true")
+	handler := middleware.MaintenanceMiddleware(&maintenanceMode, maintenanceAllowlist)(router.ServeHTTP)
+	return logger.RequestLogger(http.HandlerFunc(middleware.RecoveryMiddleware(handler)))
+}
+
+// registerModule registers a module's routes, applying its middleware if it declares any
+func registerModule(r *mux.Router, moduleInfo ModuleInfo) {
+	currentModule = moduleInfo.Name
+	defer func() { currentModule = "" }()
+
+	withMiddleware, ok := moduleInfo.Module.(ModuleMiddleware)
+	if !ok {
+		moduleInfo.Module.RegisterRoutes(r)
+		return
+	}
+
+	// A matcher-less subrouter scopes the middleware to this module's routes only
+	moduleRouter := r.NewRoute().Subrouter()
+	for _, mw := range withMiddleware.Middlewares() {
+		moduleRouter.Use(adaptMiddleware(mw))
+	}
+
+	moduleInfo.Module.RegisterRoutes(moduleRouter)
+}
+
+// adaptMiddleware converts a HandlerFunc middleware into a mux middleware
+func adaptMiddleware(mw func(http.HandlerFunc) http.HandlerFunc) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return mw(next.ServeHTTP)
+	}
 }
 
 func notFoundHandler(w http.ResponseWriter, r *http.Request) {
+	// mux loses the method mismatch when a later module subrouter fails to match,
+	// so paths registered for other methods are answered with 405 here as well
+	if len(allowedMethods(r.URL.Path)) > 0 {
+		methodNotAllowedHandler(w, r)
+		return
+	}
+
 	w.WriteHeader(http.StatusNotFound)
 	// Log the 404 error with the custom tag
 	logger.LogNotFound(fmt.Sprintf("Route not found: %s %s", r.Method, r.URL.Path))
 }
 
+// methodNotAllowedHandler responds 405 with the methods registered for the path
+func methodNotAllowedHandler(w http.ResponseWriter, r *http.Request) {
+	methods := allowHeaderMethods(r.URL.Path)
+	if len(methods) == 0 {
+		notFoundHandler(w, r)
+		return
+	}
+
+	res := router.NewResponse(w).WithRequest(r)
+	res.MethodNotAllowed(res.T("Method %s not allowed for %s", r.Method, r.URL.Path), methods)
+}
+
+// contains reports whether values contains value
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
 // swaggerUIHandler serves a simple Swagger UI HTML page
 func swaggerUIHandler(w http.ResponseWriter, r *http.Request) {
 	html := `<!DOCTYPE html>
@@ -94,10 +217,85 @@ func swaggerUIHandler(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte(html))
 }
 
-// swaggerJSONHandler serves the swagger.json file
+// swaggerDocsFile and swaggerModulesDir are where the spec is served from and
+// where it is generated from, relative to the application root
+const (
+	swaggerDocsFile   = "docs/swagger.json"
+	swaggerModulesDir = "modules"
+)
+
+// swaggerGenerateMu keeps concurrent requests from generating the spec twice
+var swaggerGenerateMu sync.Mutex
+
+// swaggerJSONHandler serves the swagger.json file, generating it when it is
+// missing and the module sources are available
 func swaggerJSONHandler(w http.ResponseWriter, r *http.Request) {
+	docsFile := ResolvePath(swaggerDocsFile)
+
+	if _, err := os.Stat(docsFile); err != nil {
+		if err := generateMissingSwagger(docsFile); err != nil {
+			logger.LogWarn("Swagger docs unavailable: " + err.Error())
+			router.NewResponse(w).WithRequest(r).Error("API documentation is not available", map[string]string{
+				"reason": err.Error(),
+			})
+			return
+		}
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	http.ServeFile(w, r, "docs/swagger.json")
+	http.ServeFile(w, r, docsFile)
+}
+
+// generateMissingSwagger writes the spec to docsFile from the module sources
+func generateMissingSwagger(docsFile string) error {
+	swaggerGenerateMu.Lock()
+	defer swaggerGenerateMu.Unlock()
+
+	// Another request may have generated it while this one waited
+	if _, err := os.Stat(docsFile); err == nil {
+		return nil
+	}
+
+	modulesDir := ResolvePath(swaggerModulesDir)
+	if _, err := os.Stat(modulesDir); err != nil {
+		return fmt.Errorf("%s was not generated and module sources are not available to generate it", swaggerDocsFile)
+	}
+
+	if err := swaggergen.Generate(modulesDir, docsFile); err != nil {
+		return fmt.Errorf("failed to generate %s: %w", swaggerDocsFile, err)
+	}
+	logger.LogInfo("Generated missing " + swaggerDocsFile)
+	return nil
+}
+
+// ResolvePath resolves a path relative to the application root, so bundled
+// files are found regardless of the directory the binary is started from
+func ResolvePath(path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(appRoot(), path)
+}
+
+// appRoot returns the directory of the executable when it holds the docs or
+// module sources, otherwise the working directory (as with go run, whose
+// binary lives in a temporary directory)
+func appRoot() string {
+	executable, err := os.Executable()
+	if err != nil {
+		return "."
+	}
+	if resolved, err := filepath.EvalSymlinks(executable); err == nil {
+		executable = resolved
+	}
+
+	dir := filepath.Dir(executable)
+	for _, marker := range []string{filepath.Dir(swaggerDocsFile), swaggerModulesDir} {
+		if _, err := os.Stat(filepath.Join(dir, marker)); err == nil {
+			return dir
+		}
+	}
+	return "."
 }
 
 // discoverModules automatically finds and loads all modules in the modules/ directory
@@ -106,14 +304,67 @@ func discoverModules() {
 		return // Already discovered
 	}
 
-	// Load all registered modules from the registry
+	// Load all enabled modules from the registry
 	for moduleName, module := range moduleRegistry {
+		if !ModuleEnabled(moduleName) {
+			logger.LogInfo(fmt.Sprintf("Module %s is disabled", moduleName))
+			continue
+		}
+
+		if setup, ok := module.(ModuleSetup); ok {
+			setup.Setup()
+		}
+
 		discoveredModules = append(discoveredModules, ModuleInfo{
 			Name:   moduleName,
 			Module: module,
 		})
 	}
 
+	for _, name := range append(moduleList("ENABLED_MODULES"), moduleList("DISABLED_MODULES")...) {
+		if _, ok := moduleRegistry[name]; !ok {
+			logger.LogWarn(fmt.Sprintf("Unknown module %q in ENABLED_MODULES/DISABLED_MODULES", name))
+		}
+	}
+}
+
+// ModuleEnabled reports whether the named module should be registered. When
+// ENABLED_MODULES is set only the modules it lists are enabled; modules listed
+// in DISABLED_MODULES are always disabled.
+func ModuleEnabled(name string) bool {
+	if enabled := moduleList("ENABLED_MODULES"); len(enabled) > 0 && !contains(enabled, name) {
+		return false
+	}
+	return !contains(moduleList("DISABLED_MODULES"), name)
+}
+
+// moduleList reads a comma-separated list of module names from the environment
+func moduleList(env string) []string {
+	var names []string
+	for _, name := range strings.Split(os.Getenv(env), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// Shutdown invokes the shutdown hook of every registered module that implements one
+func Shutdown(ctx context.Context) error {
+	var errs []error
+	for _, moduleInfo := range discoveredModules {
+		shutdowner, ok := moduleInfo.Module.(ModuleShutdowner)
+		if !ok {
+			continue
+		}
+
+		if err := shutdowner.Shutdown(ctx); err != nil {
+			logger.LogError(fmt.Sprintf("Module %s failed to shut down: %s", moduleInfo.Name, err))
+			errs = append(errs, fmt.Errorf("%s: %w", moduleInfo.Name, err))
+		}
+	}
+
+	return errors.Join(errs...)
 }
 
 // moduleRegistry holds all available modules
@@ -0,0 +1,58 @@
+package core
+
+import (
+	"net/http"
+	"runtime"
+	"runtime/debug"
+
+	"github.com/thenasky/go-framework/internal/router"
+)
+
+// Build details, set at link time:
+//
+//	go build -ldflags "-X github.com/thenasky/go-framework/internal/core.Version=1.4.0 \
+//	  -X github.com/thenasky/go-framework/internal/core.Commit=$(git rev-parse HEAD) \
+//	  -X github.com/thenasky/go-framework/internal/core.BuildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+var (
+	Version   = "dev"
+	Commit    = ""
+	BuildTime = ""
+)
+
+// BuildInfo describes the running build
+type BuildInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildTime string `json:"build_time"`
+	GoVersion string `json:"go_version"`
+}
+
+// GetBuildInfo returns the running build's details. Commit and build time fall
+// back to the VCS stamp the Go toolchain embeds when they were not set at link time.
+func GetBuildInfo() BuildInfo {
+	info := BuildInfo{
+		Version:   Version,
+		Commit:    Commit,
+		BuildTime: BuildTime,
+		GoVersion: runtime.Version(),
+	}
+
+	if build, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range build.Settings {
+			switch {
+			case setting.Key == "vcs.revision" && info.Commit == "":
+				info.Commit = setting.Value
+			case setting.Key == "vcs.time" && info.BuildTime == "":
+				info.BuildTime = setting.Value
+			}
+		}
+	}
+
+	return info
+}
+
+// versionHandler reports which build is serving requests
+func versionHandler(w http.ResponseWriter, r *http.Request) {
+	res := router.NewResponse(w).WithRequest(r)
+	res.Success("Build information", GetBuildInfo())
+}
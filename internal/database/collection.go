@@ -0,0 +1,88 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/thenasky/go-framework/internal/logger"
+)
+
+// ErrDisconnected is returned by Collection.Get while MongoDB is unreachable
+var ErrDisconnected = errors.New("MongoDB is disconnected")
+
+// Collection is a handle to a collection that outlives reconnects. After the
+// health monitor establishes a new connection, the next Get re-acquires the
+// collection from the new client and runs its setup (e.g. index creation) again.
+type Collection struct {
+	dbName     string // Empty for the default database
+	name       string
+	setup      func(*mongo.Collection) error
+	mu         sync.RWMutex
+	collection *mongo.Collection
+	generation uint64
+}
+
+// NewCollection returns a handle to collection name of database dbName, the
+// default database when empty. setup, which may be nil, runs on the collection
+// now and after every reconnect; an error from the first run is returned.
+func NewCollection(dbName, name string, setup func(*mongo.Collection) error) (*Collection, error) {
+	gen := Generation()
+	db := GetDatabase(dbName)
+	if db == nil {
+		return nil, fmt.Errorf("MongoDB not connected")
+	}
+
+	collection := db.Collection(name)
+	if setup != nil {
+		if err := setup(collection); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Collection{
+		dbName:     dbName,
+		name:       name,
+		setup:      setup,
+		collection: collection,
+		generation: gen,
+	}, nil
+}
+
+// Get returns the collection, re-acquiring it after a reconnect. It returns
+// ErrDisconnected while MongoDB is unreachable.
+func (c *Collection) Get() (*mongo.Collection, error) {
+	if !IsConnected() {
+		return nil, ErrDisconnected
+	}
+
+	gen := Generation()
+
+	c.mu.RLock()
+	collection, current := c.collection, c.generation == gen
+	c.mu.RUnlock()
+	if current {
+		return collection, nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.generation != gen {
+		db := GetDatabase(c.dbName)
+		if db == nil {
+			return nil, ErrDisconnected
+		}
+		c.collection = db.Collection(c.name)
+		c.generation = gen
+		if c.setup != nil {
+			if err := c.setup(c.collection); err != nil {
+				logger.LogMongoError(fmt.Sprintf("Failed to set up %s after reconnect: %v", c.name, err))
+			}
+		}
+	}
+
+	return c.collection, nil
+}
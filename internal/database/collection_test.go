@@ -0,0 +1,60 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// TestCollectionReacquiresAfterReconnect swaps in a new client as the health
+// monitor does and checks the handle follows it and reruns its setup
+func TestCollectionReacquiresAfterReconnect(t *testing.T) {
+	connect := func() {
+		client, err := mongo.Connect(context.Background(), options.Client().ApplyURI(unreachableURI))
+		if err != nil {
+			t.Fatalf("Connect: %v", err)
+		}
+		if previous := mongoClient.Swap(client); previous != nil {
+			previous.Disconnect(context.Background())
+		}
+		resetDatabases()
+		mongoDB.Store(client.Database("test"))
+		generation.Add(1)
+		connected.Store(true)
+	}
+	t.Cleanup(DisconnectMongoDB)
+
+	connect()
+	setups := 0
+	handle, err := NewCollection("other", "things", func(*mongo.Collection) error {
+		setups++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("NewCollection: %v", err)
+	}
+	first, err := handle.Get()
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if setups != 1 || first.Database().Name() != "other" || first.Name() != "things" {
+		t.Fatalf("got %s.%s after %d setups, want other.things after 1", first.Database().Name(), first.Name(), setups)
+	}
+
+	connect()
+	second, err := handle.Get()
+	if err != nil {
+		t.Fatalf("Get after reconnect: %v", err)
+	}
+	if second.Database().Client() == first.Database().Client() || setups != 2 {
+		t.Errorf("collection not re-acquired after reconnect (%d setups)", setups)
+	}
+
+	connected.Store(false)
+	if _, err := handle.Get(); !errors.Is(err, ErrDisconnected) {
+		t.Errorf("Get while disconnected = %v, want ErrDisconnected", err)
+	}
+}
@@ -0,0 +1,87 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/thenasky/go-framework/internal/logger"
+)
+
+// EnsureIndexes creates the indexes a module declares for a collection of the
+// default database. See EnsureCollectionIndexes.
+func EnsureIndexes(collection string, models []mongo.IndexModel) error {
+	db := MongoDB()
+	if db == nil {
+		return fmt.Errorf("MongoDB not connected")
+	}
+	return EnsureCollectionIndexes(db.Collection(collection), models)
+}
+
+// EnsureCollectionIndexes creates the given indexes on collection. It is safe to
+// call on every startup: an index that already exists with the same definition
+// is left as is, while one whose definition changed fails with the server's
+// conflict error. Every index must be named so it can be matched against the
+// existing ones. Which indexes were created and which already existed is
+// logged at the DATABASE level.
+func EnsureCollectionIndexes(collection *mongo.Collection, models []mongo.IndexModel) error {
+	names := make([]string, len(models))
+	for i, model := range models {
+		if model.Options == nil || model.Options.Name == nil || *model.Options.Name == "" {
+			return fmt.Errorf("index %d on %s has no name", i, collection.Name())
+		}
+		names[i] = *model.Options.Name
+	}
+
+	existing, err := indexNames(collection)
+	if err != nil {
+		return err
+	}
+
+	var created, found []string
+	for i, model := range models {
+		ctx, cancel := OpContext(context.Background())
+		_, err := collection.Indexes().CreateOne(ctx, model)
+		cancel()
+		if err != nil {
+			return fmt.Errorf("failed to create index %s on %s: %w", names[i], collection.Name(), err)
+		}
+
+		if existing[names[i]] {
+			found = append(found, names[i])
+		} else {
+			created = append(created, names[i])
+		}
+	}
+
+	logger.Log(logger.Database, fmt.Sprintf("Indexes on %s.%s: created [%s], existing [%s]",
+		collection.Database().Name(), collection.Name(), strings.Join(created, ", "), strings.Join(found, ", ")))
+	return nil
+}
+
+// indexNames returns the names of the indexes that exist on collection
+func indexNames(collection *mongo.Collection) (map[string]bool, error) {
+	ctx, cancel := OpContext(context.Background())
+	defer cancel()
+
+	cursor, err := collection.Indexes().List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list indexes on %s: %w", collection.Name(), err)
+	}
+
+	var indexes []bson.M
+	if err := cursor.All(ctx, &indexes); err != nil {
+		return nil, fmt.Errorf("failed to list indexes on %s: %w", collection.Name(), err)
+	}
+
+	names := make(map[string]bool, len(indexes))
+	for _, index := range indexes {
+		if name, ok := index["name"].(string); ok {
+			names[name] = true
+		}
+	}
+	return names, nil
+}
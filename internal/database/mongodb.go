@@ -2,7 +2,12 @@ package database
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/thenasky/go-framework/internal/logger"
@@ -11,8 +16,37 @@ import (
 )
 
 var (
-	MongoClient *mongo.Client
-	MongoDB     *mongo.Database
+	// mongoClient and mongoDB hold the current connection. The health monitor
+	// replaces them from its goroutine while requests and workers read them.
+	mongoClient atomic.Pointer[mongo.Client]
+	mongoDB     atomic.Pointer[mongo.Database]
+)
+
+// MongoClient returns the shared MongoDB client, or nil while not connected
+func MongoClient() *mongo.Client {
+	return mongoClient.Load()
+}
+
+// MongoDB returns the default database (MONGODB_DATABASE), or nil while not connected
+func MongoDB() *mongo.Database {
+	return mongoDB.Load()
+}
+
+var (
+	// connected reflects the result of the most recent connectivity check
+	connected atomic.Bool
+
+	// generation is bumped every time a (re)connection is established so that
+	// holders of collection handles know when to re-acquire them
+	generation atomic.Uint64
+
+	// monitorStop stops the health monitor goroutine
+	monitorStop chan struct{}
+	monitorMu   sync.Mutex
+
+	// databases caches the handles returned by GetDatabase for the current client
+	databasesMu sync.Mutex
+	databases   = map[string]*mongo.Database{}
 )
 
 // ConnectMongoDB attempts to connect to MongoDB if MONGODB_URI is present
@@ -23,10 +57,20 @@ func ConnectMongoDB() {
 		return
 	}
 
+	poolConfig, err := loadPoolConfig()
+	if err != nil {
+		logger.LogMongoError("Invalid MongoDB configuration: " + err.Error())
+		return
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	clientOptions := options.Client().ApplyURI(uri)
+	clientOptions := options.Client().
+		ApplyURI(uri).
+		SetMaxPoolSize(poolConfig.MaxPoolSize).
+		SetMinPoolSize(poolConfig.MinPoolSize).
+		SetServerSelectionTimeout(poolConfig.ServerSelectionTimeout)
 	client, err := mongo.Connect(ctx, clientOptions)
 	if err != nil {
 		logger.LogMongoError("Failed to connect to MongoDB: " + err.Error())
@@ -37,10 +81,13 @@ func ConnectMongoDB() {
 	err = client.Ping(ctx, nil)
 	if err != nil {
 		logger.LogMongoError("Failed to connect to MongoDB")
+		client.Disconnect(context.Background())
 		return
 	}
 
-	MongoClient = client
+	mongoClient.Store(client)
+	operationTimeout.Store(int64(poolConfig.OperationTimeout))
+	resetDatabases()
 
 	// Get database name from environment variable or use default
 	dbName := os.Getenv("MONGODB_DATABASE")
@@ -48,21 +95,286 @@ func ConnectMongoDB() {
 		dbName = "go_db" // fallback default
 	}
 
-	MongoDB = client.Database(dbName)
+	mongoDB.Store(client.Database(dbName))
+	connected.Store(true)
+	generation.Add(1)
 
 	logger.LogMongo("Successfully connected to MongoDB database: " + dbName)
+	logPoolConfig(poolConfig)
+}
+
+// PoolConfig holds the connection pool settings applied to the MongoDB client
+type PoolConfig struct {
+	MaxPoolSize            uint64
+	MinPoolSize            uint64
+	ServerSelectionTimeout time.Duration
+	OperationTimeout       time.Duration // Deadline applied by OpContext
+}
+
+// Pool defaults, matching the driver defaults
+const (
+	defaultMaxPoolSize            = 100
+	defaultMinPoolSize            = 0
+	defaultServerSelectionTimeout = 30 * time.Second
+	defaultOperationTimeout       = 30 * time.Second
+)
+
+// operationTimeout is the deadline OpContext applies, from MONGODB_OP_TIMEOUT
+var operationTimeout atomic.Int64
+
+// poolConfigLogged ensures the effective settings are only logged once
+var poolConfigLogged atomic.Bool
+
+// loadPoolConfig reads the pool settings from the environment and validates them
+func loadPoolConfig() (*PoolConfig, error) {
+	config := &PoolConfig{
+		MaxPoolSize:            defaultMaxPoolSize,
+		MinPoolSize:            defaultMinPoolSize,
+		ServerSelectionTimeout: defaultServerSelectionTimeout,
+		OperationTimeout:       defaultOperationTimeout,
+	}
+
+	if value := os.Getenv("MONGODB_MAX_POOL_SIZE"); value != "" {
+		size, err := strconv.ParseUint(value, 10, 64)
+		if err != nil || size == 0 {
+			return nil, fmt.Errorf("MONGODB_MAX_POOL_SIZE must be a positive integer, got %q", value)
+		}
+		config.MaxPoolSize = size
+	}
+
+	if value := os.Getenv("MONGODB_MIN_POOL_SIZE"); value != "" {
+		size, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("MONGODB_MIN_POOL_SIZE must be a non-negative integer, got %q", value)
+		}
+		config.MinPoolSize = size
+	}
+
+	if config.MinPoolSize > config.MaxPoolSize {
+		return nil, fmt.Errorf("MONGODB_MIN_POOL_SIZE (%d) cannot exceed MONGODB_MAX_POOL_SIZE (%d)", config.MinPoolSize, config.MaxPoolSize)
+	}
+
+	// Accepts Go durations ("5s", "500ms") or a plain number of milliseconds
+	if value := os.Getenv("MONGODB_SERVER_SELECTION_TIMEOUT"); value != "" {
+		timeout, err := parseDuration(value)
+		if err != nil || timeout <= 0 {
+			return nil, fmt.Errorf("MONGODB_SERVER_SELECTION_TIMEOUT must be a positive duration, got %q", value)
+		}
+		config.ServerSelectionTimeout = timeout
+	}
+
+	if value := os.Getenv("MONGODB_OP_TIMEOUT"); value != "" {
+		timeout, err := parseDuration(value)
+		if err != nil || timeout <= 0 {
+			return nil, fmt.Errorf("MONGODB_OP_TIMEOUT must be a positive duration, got %q", value)
+		}
+		config.OperationTimeout = timeout
+	}
+
+	return config, nil
+}
+
+// parseDuration parses a Go duration string or a number of milliseconds
+func parseDuration(value string) (time.Duration, error) {
+	if ms, err := strconv.Atoi(value); err == nil {
+		return time.Duration(ms) * time.Millisecond, nil
+	}
+	return time.ParseDuration(value)
+}
+
+// logPoolConfig logs the effective pool settings the first time a connection is made
+func logPoolConfig(config *PoolConfig) {
+	if !poolConfigLogged.CompareAndSwap(false, true) {
+		return
+	}
+	logger.LogMongo(fmt.Sprintf("Connection pool: max=%d, min=%d, server selection timeout=%v, operation timeout=%v",
+		config.MaxPoolSize, config.MinPoolSize, config.ServerSelectionTimeout, config.OperationTimeout))
+}
+
+// OpContext derives the context for a single database operation from parent,
+// bounded by MONGODB_OP_TIMEOUT (default 30s) so a stalled server cannot block
+// callers indefinitely. A shorter deadline or a cancellation of parent still
+// applies.
+func OpContext(parent context.Context) (context.Context, context.CancelFunc) {
+	timeout := time.Duration(operationTimeout.Load())
+	if timeout <= 0 {
+		timeout = defaultOperationTimeout
+	}
+	return context.WithTimeout(parent, timeout)
 }
 
 // DisconnectMongoDB disconnects from MongoDB if connected
 func DisconnectMongoDB() {
-	if MongoClient != nil {
+	StopHealthMonitor()
+
+	// Readers see the client gone before it is disconnected
+	mongoDB.Store(nil)
+	if client := mongoClient.Swap(nil); client != nil {
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
 
-		if err := MongoClient.Disconnect(ctx); err != nil {
+		if err := client.Disconnect(ctx); err != nil {
 			logger.LogMongoError("Error disconnecting from MongoDB: " + err.Error())
 		} else {
 			logger.LogMongo("Disconnected from MongoDB")
 		}
+		resetDatabases()
+	}
+	connected.Store(false)
+}
+
+// GetDatabase returns the named database on the shared client, so a module can
+// keep its data apart from the default MONGODB_DATABASE. An empty name returns
+// the default database. It returns nil while MongoDB is not connected.
+func GetDatabase(name string) *mongo.Database {
+	if name == "" {
+		return MongoDB()
+	}
+
+	databasesMu.Lock()
+	defer databasesMu.Unlock()
+
+	client := MongoClient()
+	if client == nil {
+		return nil
+	}
+	db, ok := databases[name]
+	if !ok {
+		db = client.Database(name)
+		databases[name] = db
+	}
+	return db
+}
+
+// resetDatabases drops the cached database handles of a previous client
+func resetDatabases() {
+	databasesMu.Lock()
+	defer databasesMu.Unlock()
+	databases = map[string]*mongo.Database{}
+}
+
+// illegalOperationCode is returned by standalone servers for transaction commands
+const illegalOperationCode = 20
+
+// ErrTransactionsUnsupported is returned by WithTransaction when the deployment is
+// a standalone server; transactions need a replica set or a sharded cluster
+var ErrTransactionsUnsupported = errors.New("MongoDB transactions require a replica set or sharded cluster")
+
+// WithTransaction runs fn in a transaction and commits it when fn returns nil.
+// Every operation in fn must use sessCtx to be part of the transaction. The
+// whole transaction is retried on transient errors (e.g. write conflicts) and
+// the commit on unknown commit results, so fn may run more than once.
+func WithTransaction(ctx context.Context, fn func(sessCtx mongo.SessionContext) error) error {
+	client := MongoClient()
+	if client == nil {
+		return fmt.Errorf("MongoDB not connected")
+	}
+
+	session, err := client.StartSession()
+	if err != nil {
+		return fmt.Errorf("failed to start MongoDB session: %w", err)
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sessCtx)
+	})
+
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) && cmdErr.Code == illegalOperationCode {
+		return fmt.Errorf("%w: %s", ErrTransactionsUnsupported, cmdErr.Message)
+	}
+	return err
+}
+
+// IsConnected reports whether MongoDB answered the most recent health check
+func IsConnected() bool {
+	return connected.Load()
+}
+
+// HealthCheck pings MongoDB with a short timeout and returns an error when it is unreachable
+func HealthCheck(ctx context.Context) error {
+	client := MongoClient()
+	if client == nil {
+		return fmt.Errorf("MongoDB not connected")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	if err := client.Ping(ctx, nil); err != nil {
+		return fmt.Errorf("MongoDB ping failed: %w", err)
+	}
+	return nil
+}
+
+// Generation returns a counter that changes every time a new connection is established
+func Generation() uint64 {
+	return generation.Load()
+}
+
+// StartHealthMonitor periodically pings MongoDB, tracks connectivity and
+// reconnects when the connection was never established or has been lost
+func StartHealthMonitor(interval time.Duration) {
+	monitorMu.Lock()
+	defer monitorMu.Unlock()
+
+	if os.Getenv("MONGODB_URI") == "" || monitorStop != nil {
+		return
+	}
+
+	monitorStop = make(chan struct{})
+	go monitorConnection(interval, monitorStop)
+}
+
+// StopHealthMonitor stops the health monitor if it is running
+func StopHealthMonitor() {
+	monitorMu.Lock()
+	defer monitorMu.Unlock()
+
+	if monitorStop != nil {
+		close(monitorStop)
+		monitorStop = nil
+	}
+}
+
+// monitorConnection is the health monitor loop
+func monitorConnection(interval time.Duration, stop chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			checkConnection()
+		}
+	}
+}
+
+// checkConnection pings MongoDB and updates the connectivity state
+func checkConnection() {
+	// Never connected - try again from scratch
+	client := MongoClient()
+	if client == nil {
+		ConnectMongoDB()
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err := client.Ping(ctx, nil)
+	wasConnected := connected.Load()
+
+	switch {
+	case err != nil && wasConnected:
+		connected.Store(false)
+		logger.LogMongoError("Lost connection to MongoDB: " + err.Error())
+	case err == nil && !wasConnected:
+		connected.Store(true)
+		generation.Add(1)
+		logger.LogMongo("Reconnected to MongoDB")
 	}
 }
@@ -0,0 +1,81 @@
+package database
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// unreachableURI is never dialled: clients connect lazily and the tests only
+// swap them in and out
+const unreachableURI = "mongodb://127.0.0.1:1"
+
+// TestConnectionSwapIsRaceFree replaces the connection, as the health monitor
+// does on reconnect, while other goroutines read it. Run with -race.
+func TestConnectionSwapIsRaceFree(t *testing.T) {
+	stop := make(chan struct{})
+	var readers sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				if db := MongoDB(); db != nil {
+					_ = db.Name()
+				}
+				if db := GetDatabase("other"); db != nil {
+					_ = db.Name()
+				}
+				_ = MongoClient()
+			}
+		}()
+	}
+
+	for i := 0; i < 10; i++ {
+		client, err := mongo.Connect(context.Background(), options.Client().ApplyURI(unreachableURI))
+		if err != nil {
+			t.Fatalf("Connect: %v", err)
+		}
+		mongoClient.Store(client)
+		resetDatabases()
+		mongoDB.Store(client.Database("test"))
+
+		DisconnectMongoDB()
+		if MongoClient() != nil || MongoDB() != nil {
+			t.Fatal("connection still set after DisconnectMongoDB")
+		}
+	}
+
+	close(stop)
+	readers.Wait()
+}
+
+// TestHealthMonitorStartStopIsRaceFree starts and stops the monitor from
+// several goroutines at once
+func TestHealthMonitorStartStopIsRaceFree(t *testing.T) {
+	t.Setenv("MONGODB_URI", unreachableURI)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				StartHealthMonitor(time.Hour)
+				StopHealthMonitor()
+			}
+		}()
+	}
+	wg.Wait()
+
+	StopHealthMonitor()
+}
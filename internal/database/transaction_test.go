@@ -0,0 +1,117 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// connectTestMongo connects to MONGODB_URI for the duration of the test and
+// reports whether the server supports transactions. The test is skipped
+// without MONGODB_URI.
+func connectTestMongo(t *testing.T) (transactions bool) {
+	t.Helper()
+	if os.Getenv("MONGODB_URI") == "" {
+		t.Skip("MONGODB_URI not set")
+	}
+
+	ConnectMongoDB()
+	if MongoDB() == nil {
+		t.Fatal("could not connect to MONGODB_URI")
+	}
+	t.Cleanup(DisconnectMongoDB)
+
+	var hello bson.M
+	if err := MongoDB().RunCommand(context.Background(), bson.D{{Key: "hello", Value: 1}}).Decode(&hello); err != nil {
+		t.Fatalf("hello: %v", err)
+	}
+	_, replicaSet := hello["setName"]
+	return replicaSet || hello["msg"] == "isdbgrid"
+}
+
+// testCollection returns a fresh collection that is dropped after the test
+func testCollection(t *testing.T) *mongo.Collection {
+	t.Helper()
+	collection := MongoDB().Collection("transaction_test_" + primitive.NewObjectID().Hex())
+	// Transactions can't create collections on older servers
+	if err := MongoDB().CreateCollection(context.Background(), collection.Name()); err != nil {
+		t.Fatalf("creating collection: %v", err)
+	}
+	t.Cleanup(func() { collection.Drop(context.Background()) })
+	return collection
+}
+
+func TestWithTransactionCommitsAndRollsBack(t *testing.T) {
+	if !connectTestMongo(t) {
+		t.Skip("MONGODB_URI is not a replica set or sharded cluster")
+	}
+	collection := testCollection(t)
+	ctx := context.Background()
+
+	err := WithTransaction(ctx, func(sessCtx mongo.SessionContext) error {
+		if _, err := collection.InsertOne(sessCtx, bson.M{"name": "committed-1"}); err != nil {
+			return err
+		}
+		_, err := collection.InsertOne(sessCtx, bson.M{"name": "committed-2"})
+		return err
+	})
+	if err != nil {
+		t.Fatalf("committing transaction: %v", err)
+	}
+
+	errAbort := errors.New("abort")
+	err = WithTransaction(ctx, func(sessCtx mongo.SessionContext) error {
+		if _, err := collection.InsertOne(sessCtx, bson.M{"name": "rolled-back"}); err != nil {
+			return err
+		}
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Fatalf("WithTransaction = %v, want the error returned by fn", err)
+	}
+
+	committed, err := collection.CountDocuments(ctx, bson.M{"name": bson.M{"$in": []string{"committed-1", "committed-2"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rolledBack, err := collection.CountDocuments(ctx, bson.M{"name": "rolled-back"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if committed != 2 || rolledBack != 0 {
+		t.Errorf("found %d committed and %d rolled back documents, want 2 and 0", committed, rolledBack)
+	}
+}
+
+func TestWithTransactionUnsupportedOnStandalone(t *testing.T) {
+	if connectTestMongo(t) {
+		t.Skip("MONGODB_URI supports transactions")
+	}
+	collection := testCollection(t)
+
+	err := WithTransaction(context.Background(), func(sessCtx mongo.SessionContext) error {
+		_, err := collection.InsertOne(sessCtx, bson.M{"name": "standalone"})
+		return err
+	})
+	if !errors.Is(err, ErrTransactionsUnsupported) {
+		t.Fatalf("WithTransaction = %v, want ErrTransactionsUnsupported", err)
+	}
+}
+
+func TestWithTransactionRequiresConnection(t *testing.T) {
+	if MongoClient() != nil {
+		t.Skip("already connected")
+	}
+	err := WithTransaction(context.Background(), func(sessCtx mongo.SessionContext) error {
+		t.Fatal("fn called without a connection")
+		return nil
+	})
+	if err == nil {
+		t.Fatal("WithTransaction succeeded without a connection")
+	}
+}
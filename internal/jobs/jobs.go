@@ -0,0 +1,98 @@
+// Package jobs runs background work outside the request path. A Pool provides
+// the worker goroutines, error backoff and draining shutdown; Queue and Worker
+// add a MongoDB-backed queue of typed jobs with retries and cleanup on top.
+//
+// A module defines a job type, registers a handler and enqueues jobs:
+//
+//	type ReportJob struct {
+//		AccountID string `bson:"account_id"`
+//	}
+//
+//	func (ReportJob) JobType() string { return "generate_report" }
+//
+//	queue, err := jobs.NewQueue()
+//	worker := jobs.NewWorker(queue, nil)
+//	jobs.Handle(worker, func(ctx context.Context, job ReportJob) error {
+//		return generateReport(ctx, job.AccountID)
+//	})
+//	worker.Start()
+//
+//	queue.Enqueue(ctx, ReportJob{AccountID: "42"})
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Job is a unit of background work. Its fields are stored with the job, and
+// JobType selects the handler that processes it.
+type Job interface {
+	JobType() string
+}
+
+// Record is a job as stored in the queue
+type Record struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Type        string             `json:"type" bson:"type"`
+	Payload     bson.Raw           `json:"-" bson:"payload"`
+	Status      string             `json:"status" bson:"status"` // pending, processing, done, failed
+	Attempts    int                `json:"attempts" bson:"attempts"`
+	MaxAttempts int                `json:"max_attempts" bson:"max_attempts"`
+	RunAt       time.Time          `json:"run_at" bson:"run_at"` // Not processed before this time
+	CreatedAt   time.Time          `json:"created_at" bson:"created_at"`
+	ProcessedAt *time.Time         `json:"processed_at,omitempty" bson:"processed_at,omitempty"`
+	Error       string             `json:"error,omitempty" bson:"error,omitempty"` // Last failure
+}
+
+// Job statuses
+const (
+	StatusPending    = "pending"
+	StatusProcessing = "processing"
+	StatusDone       = "done"
+	StatusFailed     = "failed" // No attempts left
+)
+
+// ErrNoHandler is recorded on jobs whose type has no registered handler
+var ErrNoHandler = errors.New("no handler registered for job type")
+
+// permanentError marks a handler error that retrying cannot fix
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps err so the job fails right away instead of being retried
+func Permanent(err error) error {
+	return &permanentError{err: err}
+}
+
+// IsPermanent reports whether err was marked with Permanent
+func IsPermanent(err error) bool {
+	var permanent *permanentError
+	return errors.As(err, &permanent)
+}
+
+// handlerFunc processes a stored job
+type handlerFunc func(ctx context.Context, record *Record) error
+
+// Handle registers handler for jobs of type T on worker. T is the job struct
+// passed to Queue.Enqueue; its JobType must work on the zero value. Handlers
+// must be registered before the worker is started.
+func Handle[T Job](worker *Worker, handler func(ctx context.Context, job T) error) {
+	var zero T
+	worker.handlers[zero.JobType()] = func(ctx context.Context, record *Record) error {
+		var job T
+		if err := bson.Unmarshal(record.Payload, &job); err != nil {
+			return Permanent(fmt.Errorf("failed to decode %s job: %w", record.Type, err))
+		}
+		return handler(ctx, job)
+	}
+}
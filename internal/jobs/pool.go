@@ -0,0 +1,253 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Bounds for the backoff applied while Process keeps returning errors
+const (
+	minErrorBackoff = 1 * time.Second
+	maxErrorBackoff = 30 * time.Second
+)
+
+// PoolConfig describes the work done by a Pool
+type PoolConfig struct {
+	Name    string // Used in log lines and errors, e.g. "email worker"
+	Workers int    // Number of goroutines calling Process
+
+	// Process handles the next unit of work and reports whether there was any.
+	// ctx is cancelled when the pool starts stopping; work already claimed should
+	// still be finished.
+	Process func(ctx context.Context, workerID int) (bool, error)
+	// PollInterval returns how long an idle goroutine waits before looking for work again
+	PollInterval func() time.Duration
+	// Wake optionally returns a channel that is closed when new work is available
+	Wake func() <-chan struct{}
+	// OnDrainTimeout is called when Stop's deadline passes while work is still in progress
+	OnDrainTimeout func()
+}
+
+// poolState is the lifecycle state of a Pool
+type poolState int
+
+const (
+	stateStopped poolState = iota
+	stateRunning
+	stateStopping
+)
+
+// Pool runs a fixed number of goroutines that process work in a loop, with an
+// exponential backoff on errors, idle waits between polls, optional periodic
+// tasks and a Stop that lets work in progress finish.
+type Pool struct {
+	config PoolConfig
+	tasks  []func(ctx context.Context)
+
+	mu      sync.Mutex
+	state   poolState
+	ctx     context.Context
+	cancel  context.CancelFunc
+	stopped chan struct{} // Closed once a stopping pool's goroutines have exited
+	wg      sync.WaitGroup
+}
+
+// NewPool creates a stopped pool
+func NewPool(config PoolConfig) *Pool {
+	if config.Workers < 1 {
+		config.Workers = 1
+	}
+	if config.PollInterval == nil {
+		config.PollInterval = func() time.Duration { return time.Second }
+	}
+	return &Pool{config: config}
+}
+
+// Go adds a routine that is started with the pool and runs until ctx is cancelled.
+// Routines must be added before Start.
+func (p *Pool) Go(routine func(ctx context.Context)) {
+	p.tasks = append(p.tasks, routine)
+}
+
+// Every adds a task that runs every interval while the pool is running
+func (p *Pool) Every(interval time.Duration, task func()) {
+	p.Go(func(ctx context.Context) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				task()
+			}
+		}
+	})
+}
+
+// Start starts the pool. Calling it on a pool that is already running is a no-op.
+func (p *Pool) Start() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.state != stateStopped {
+		log.Printf("%s already running", capitalize(p.config.Name))
+		return
+	}
+
+	log.Printf("Starting %s with %d workers", p.config.Name, p.config.Workers)
+
+	// A fresh context so a stopped pool can be started again
+	p.ctx, p.cancel = context.WithCancel(context.Background())
+	p.state = stateRunning
+
+	for i := 0; i < p.config.Workers; i++ {
+		p.wg.Add(1)
+		go p.workerRoutine(p.ctx, i)
+	}
+
+	for _, task := range p.tasks {
+		p.wg.Add(1)
+		go func(task func(ctx context.Context), ctx context.Context) {
+			defer p.wg.Done()
+			task(ctx)
+		}(task, p.ctx)
+	}
+
+	log.Printf("%s started successfully", capitalize(p.config.Name))
+}
+
+// Stop drains the pool: no new work is started, and work in progress is given
+// until ctx is done to finish, after which OnDrainTimeout is called and an error
+// is returned. Stopping a pool that is not running is a no-op.
+func (p *Pool) Stop(ctx context.Context) error {
+	p.mu.Lock()
+	switch p.state {
+	case stateStopped:
+		p.mu.Unlock()
+		return nil
+	case stateStopping:
+		// Another caller is already stopping the pool; just wait for it
+		stopped := p.stopped
+		p.mu.Unlock()
+		select {
+		case <-stopped:
+			return nil
+		case <-ctx.Done():
+			return fmt.Errorf("%s did not stop in time: %w", p.config.Name, ctx.Err())
+		}
+	}
+
+	log.Printf("Stopping %s...", p.config.Name)
+	p.state = stateStopping
+	p.cancel()
+
+	stopped := make(chan struct{})
+	p.stopped = stopped
+	p.mu.Unlock()
+
+	go func() {
+		p.wg.Wait()
+
+		p.mu.Lock()
+		p.state = stateStopped
+		p.mu.Unlock()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		log.Printf("%s stopped successfully", capitalize(p.config.Name))
+		return nil
+	case <-ctx.Done():
+		if p.config.OnDrainTimeout != nil {
+			p.config.OnDrainTimeout()
+		}
+		return fmt.Errorf("%s did not drain in time: %w", p.config.Name, ctx.Err())
+	}
+}
+
+// IsRunning reports whether the pool is running
+func (p *Pool) IsRunning() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.state == stateRunning
+}
+
+// workerRoutine calls Process until the pool stops
+func (p *Pool) workerRoutine(ctx context.Context, workerID int) {
+	defer p.wg.Done()
+
+	log.Printf("Worker %d started", workerID)
+
+	// Exponential backoff applied while Process keeps failing (e.g. MongoDB down)
+	errorBackoff := minErrorBackoff
+
+	for {
+		if ctx.Err() != nil {
+			log.Printf("Worker %d stopping", workerID)
+			return
+		}
+
+		processed, err := p.config.Process(ctx, workerID)
+		if err != nil {
+			log.Printf("Worker %d error: %v (retrying in %v)", workerID, err, errorBackoff)
+			if !Sleep(ctx, errorBackoff) {
+				return
+			}
+			errorBackoff *= 2
+			if errorBackoff > maxErrorBackoff {
+				errorBackoff = maxErrorBackoff
+			}
+			continue
+		}
+		errorBackoff = minErrorBackoff
+
+		// Only wait when there was no work; keep draining while work is waiting
+		if !processed && !p.waitForWork(ctx) {
+			return
+		}
+	}
+}
+
+// waitForWork blocks until new work is signalled or the poll interval elapses.
+// It returns false if the pool was stopped meanwhile.
+func (p *Pool) waitForWork(ctx context.Context) bool {
+	var wake <-chan struct{}
+	if p.config.Wake != nil {
+		wake = p.config.Wake()
+	}
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-wake:
+		return true
+	case <-time.After(p.config.PollInterval()):
+		return true
+	}
+}
+
+// Sleep waits for d and returns false if ctx was cancelled meanwhile
+func Sleep(ctx context.Context, d time.Duration) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(d):
+		return true
+	}
+}
+
+// capitalize upper-cases the first letter of a pool name for the start of a log line
+func capitalize(name string) string {
+	if name == "" {
+		return name
+	}
+	return strings.ToUpper(name[:1]) + name[1:]
+}
@@ -0,0 +1,43 @@
+package jobs
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestPoolDrainsQueuedWorkWithoutPolling queues more work than one poll could
+// handle: with an hour-long poll interval it only completes in time if busy
+// workers skip the idle wait
+func TestPoolDrainsQueuedWorkWithoutPolling(t *testing.T) {
+	const queued = 500
+
+	var remaining atomic.Int64
+	remaining.Store(queued)
+	drained := make(chan struct{})
+
+	pool := NewPool(PoolConfig{
+		Name:    "test pool",
+		Workers: 2,
+		Process: func(ctx context.Context, workerID int) (bool, error) {
+			left := remaining.Add(-1)
+			if left < 0 {
+				return false, nil
+			}
+			if left == 0 {
+				close(drained)
+			}
+			return true, nil
+		},
+		PollInterval: func() time.Duration { return time.Hour },
+	})
+	pool.Start()
+	defer pool.Stop(context.Background())
+
+	select {
+	case <-drained:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("%d of %d jobs still queued", remaining.Load(), queued)
+	}
+}
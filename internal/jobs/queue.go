@@ -0,0 +1,237 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/thenasky/go-framework/internal/database"
+)
+
+// collectionName is the MongoDB collection backing the queue
+const collectionName = "jobs"
+
+// DefaultMaxAttempts is how many times a job is tried before it is marked failed
+const DefaultMaxAttempts = 3
+
+// ErrDisconnected is returned by queue operations while MongoDB is unreachable
+var ErrDisconnected = database.ErrDisconnected
+
+// Queue stores jobs in MongoDB. Jobs are claimed atomically, so any number of
+// workers on any number of nodes can share a queue.
+type Queue struct {
+	collection *database.Collection
+	newJobs    *Notifier
+}
+
+// NewQueue creates a MongoDB-backed job queue
+func NewQueue() (*Queue, error) {
+	collection, err := database.NewCollection("", collectionName, createIndexes)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Queue{
+		collection: collection,
+		newJobs:    NewNotifier(),
+	}, nil
+}
+
+// getCollection returns the queue collection, re-acquiring it after a reconnect
+func (q *Queue) getCollection() (*mongo.Collection, error) {
+	return q.collection.Get()
+}
+
+// indexes are used to claim and clean up jobs
+var indexes = []mongo.IndexModel{
+	{
+		Keys:    bson.D{{Key: "status", Value: 1}, {Key: "type", Value: 1}, {Key: "run_at", Value: 1}},
+		Options: options.Index().SetName("status_type_run_at"),
+	},
+	{
+		Keys:    bson.D{{Key: "status", Value: 1}, {Key: "processed_at", Value: 1}},
+		Options: options.Index().SetName("status_processed_at"),
+	},
+}
+
+// createIndexes creates the indexes of the queue collection
+func createIndexes(collection *mongo.Collection) error {
+	if err := database.EnsureCollectionIndexes(collection, indexes); err != nil {
+		return fmt.Errorf("failed to create job queue indexes: %w", err)
+	}
+	return nil
+}
+
+// Enqueue adds a job to run as soon as a worker is free
+func (q *Queue) Enqueue(ctx context.Context, job Job) (primitive.ObjectID, error) {
+	return q.Schedule(ctx, job, time.Now())
+}
+
+// Schedule adds a job that is not run before runAt
+func (q *Queue) Schedule(ctx context.Context, job Job, runAt time.Time) (primitive.ObjectID, error) {
+	collection, err := q.getCollection()
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+
+	payload, err := bson.Marshal(job)
+	if err != nil {
+		return primitive.NilObjectID, fmt.Errorf("failed to encode %s job: %w", job.JobType(), err)
+	}
+
+	record := Record{
+		ID:          primitive.NewObjectID(),
+		Type:        job.JobType(),
+		Payload:     payload,
+		Status:      StatusPending,
+		MaxAttempts: DefaultMaxAttempts,
+		RunAt:       runAt,
+		CreatedAt:   time.Now(),
+	}
+	ctx, cancel := database.OpContext(ctx)
+	defer cancel()
+
+	if _, err := collection.InsertOne(ctx, record); err != nil {
+		return primitive.NilObjectID, fmt.Errorf("failed to enqueue job: %w", err)
+	}
+
+	// Wake up idle workers
+	q.newJobs.Broadcast()
+
+	return record.ID, nil
+}
+
+// Get returns a job by ID, or nil when it does not exist
+func (q *Queue) Get(ctx context.Context, id primitive.ObjectID) (*Record, error) {
+	collection, err := q.getCollection()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := database.OpContext(ctx)
+	defer cancel()
+
+	var record Record
+	if err := collection.FindOne(ctx, bson.M{"_id": id}).Decode(&record); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get job: %w", err)
+	}
+	return &record, nil
+}
+
+// Dequeue claims the oldest due job of one of types, or returns nil when none is available
+func (q *Queue) Dequeue(ctx context.Context, types []string) (*Record, error) {
+	collection, err := q.getCollection()
+	if err != nil {
+		return nil, err
+	}
+
+	filter := bson.M{
+		"status": StatusPending,
+		"type":   bson.M{"$in": types},
+		"run_at": bson.M{"$lte": time.Now()},
+	}
+	update := bson.M{
+		"$set": bson.M{"status": StatusProcessing},
+		"$inc": bson.M{"attempts": 1},
+	}
+	opts := options.FindOneAndUpdate().SetSort(bson.D{
+		{Key: "run_at", Value: 1},
+		{Key: "created_at", Value: 1},
+	}).SetReturnDocument(options.After)
+
+	ctx, cancel := database.OpContext(ctx)
+	defer cancel()
+
+	var record Record
+	if err := collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&record); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil // No jobs available
+		}
+		return nil, fmt.Errorf("failed to dequeue job: %w", err)
+	}
+	return &record, nil
+}
+
+// Complete marks a claimed job done
+func (q *Queue) Complete(ctx context.Context, id primitive.ObjectID) error {
+	return q.update(ctx, id, bson.M{"$set": bson.M{
+		"status":       StatusDone,
+		"processed_at": time.Now(),
+	}}, "complete")
+}
+
+// Retry puts a claimed job back in the queue to run again at runAt
+func (q *Queue) Retry(ctx context.Context, id primitive.ObjectID, errorMessage string, runAt time.Time) error {
+	return q.update(ctx, id, bson.M{"$set": bson.M{
+		"status":       StatusPending,
+		"error":        errorMessage,
+		"run_at":       runAt,
+		"processed_at": time.Now(),
+	}}, "retry")
+}
+
+// Fail marks a claimed job failed; it is not run again
+func (q *Queue) Fail(ctx context.Context, id primitive.ObjectID, errorMessage string) error {
+	return q.update(ctx, id, bson.M{"$set": bson.M{
+		"status":       StatusFailed,
+		"error":        errorMessage,
+		"processed_at": time.Now(),
+	}}, "fail")
+}
+
+// Requeue puts a claimed job back in the queue without using up an attempt
+func (q *Queue) Requeue(ctx context.Context, id primitive.ObjectID) error {
+	return q.update(ctx, id, bson.M{
+		"$set": bson.M{"status": StatusPending},
+		"$inc": bson.M{"attempts": -1},
+	}, "requeue")
+}
+
+// update applies update to a claimed job
+func (q *Queue) update(ctx context.Context, id primitive.ObjectID, update bson.M, operation string) error {
+	collection, err := q.getCollection()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := database.OpContext(ctx)
+	defer cancel()
+
+	if _, err := collection.UpdateOne(ctx, bson.M{"_id": id, "status": StatusProcessing}, update); err != nil {
+		return fmt.Errorf("failed to %s job: %w", operation, err)
+	}
+	return nil
+}
+
+// Cleanup removes done and failed jobs processed more than olderThan ago
+func (q *Queue) Cleanup(ctx context.Context, olderThan time.Duration) (int64, error) {
+	collection, err := q.getCollection()
+	if err != nil {
+		return 0, err
+	}
+
+	ctx, cancel := database.OpContext(ctx)
+	defer cancel()
+
+	result, err := collection.DeleteMany(ctx, bson.M{
+		"status":       bson.M{"$in": []string{StatusDone, StatusFailed}},
+		"processed_at": bson.M{"$lt": time.Now().Add(-olderThan)},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to clean up jobs: %w", err)
+	}
+	return result.DeletedCount, nil
+}
+
+// NewJobs returns a channel that is closed the next time a job is enqueued on this node
+func (q *Queue) NewJobs() <-chan struct{} {
+	return q.newJobs.Wait()
+}
@@ -0,0 +1,192 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Config holds configuration for a Worker
+type Config struct {
+	Workers       int           // Number of worker goroutines
+	PollInterval  time.Duration // Delay between queue checks while idle
+	RetryDelay    time.Duration // Delay before the first retry, doubled for each further attempt
+	MaxRetryDelay time.Duration // Upper bound for the retry delay
+	Retention     time.Duration // How long done and failed jobs are kept
+}
+
+// DefaultConfig returns sensible default configuration
+func DefaultConfig() *Config {
+	return &Config{
+		Workers:       2,
+		PollInterval:  1 * time.Second,
+		RetryDelay:    30 * time.Second,
+		MaxRetryDelay: 1 * time.Hour,
+		Retention:     24 * time.Hour,
+	}
+}
+
+// Worker processes jobs from a Queue with the handlers registered through Handle.
+// Failed jobs are retried with an exponential backoff until they run out of
+// attempts, and finished jobs are removed once the retention period has passed.
+type Worker struct {
+	queue    *Queue
+	config   *Config
+	handlers map[string]handlerFunc
+	pool     *Pool
+	mu       sync.Mutex
+	inFlight map[primitive.ObjectID]bool // Jobs claimed by a worker goroutine and not yet finished
+}
+
+// NewWorker creates a worker for queue; a nil config uses DefaultConfig
+func NewWorker(queue *Queue, config *Config) *Worker {
+	if config == nil {
+		config = DefaultConfig()
+	}
+
+	w := &Worker{
+		queue:    queue,
+		config:   config,
+		handlers: make(map[string]handlerFunc),
+		inFlight: make(map[primitive.ObjectID]bool),
+	}
+
+	w.pool = NewPool(PoolConfig{
+		Name:           "job worker",
+		Workers:        config.Workers,
+		Process:        w.processNextJob,
+		PollInterval:   func() time.Duration { return config.PollInterval },
+		Wake:           queue.NewJobs,
+		OnDrainTimeout: w.requeueInFlight,
+	})
+	w.pool.Every(1*time.Hour, w.cleanup)
+
+	return w
+}
+
+// Start starts processing jobs. Calling it on a worker that is already running is a no-op.
+func (w *Worker) Start() {
+	w.pool.Start()
+}
+
+// Stop stops taking new jobs and waits until ctx is done for running jobs to
+// finish; jobs still running then are put back in the queue.
+func (w *Worker) Stop(ctx context.Context) error {
+	return w.pool.Stop(ctx)
+}
+
+// IsRunning reports whether the worker is running
+func (w *Worker) IsRunning() bool {
+	return w.pool.IsRunning()
+}
+
+// processNextJob runs the next due job and reports whether one was found
+func (w *Worker) processNextJob(ctx context.Context, workerID int) (bool, error) {
+	types := make([]string, 0, len(w.handlers))
+	for jobType := range w.handlers {
+		types = append(types, jobType)
+	}
+
+	record, err := w.queue.Dequeue(ctx, types)
+	if err != nil {
+		return false, err
+	}
+	if record == nil {
+		return false, nil
+	}
+
+	w.trackJob(record.ID, true)
+	defer w.trackJob(record.ID, false)
+
+	// Let a claimed job finish even when the worker starts stopping
+	jobCtx := context.WithoutCancel(ctx)
+	err = w.run(jobCtx, record)
+	if err == nil {
+		if err := w.queue.Complete(jobCtx, record.ID); err != nil {
+			return true, err
+		}
+		return true, nil
+	}
+
+	log.Printf("Worker %d: %s job %s failed (attempt %d of %d): %v", workerID, record.Type, record.ID.Hex(), record.Attempts, record.MaxAttempts, err)
+
+	if IsPermanent(err) || record.Attempts >= record.MaxAttempts {
+		return true, w.queue.Fail(jobCtx, record.ID, err.Error())
+	}
+	return true, w.queue.Retry(jobCtx, record.ID, err.Error(), time.Now().Add(w.retryDelay(record.Attempts)))
+}
+
+// run calls the handler for record, turning a panic into an error
+func (w *Worker) run(ctx context.Context, record *Record) (err error) {
+	handler, ok := w.handlers[record.Type]
+	if !ok {
+		return Permanent(fmt.Errorf("%w: %s", ErrNoHandler, record.Type))
+	}
+
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("job panicked: %v", recovered)
+		}
+	}()
+	return handler(ctx, record)
+}
+
+// retryDelay is the backoff before the next attempt after attempts have failed
+func (w *Worker) retryDelay(attempts int) time.Duration {
+	delay := w.config.RetryDelay
+	for i := 1; i < attempts && delay < w.config.MaxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > w.config.MaxRetryDelay {
+		delay = w.config.MaxRetryDelay
+	}
+	return delay
+}
+
+// trackJob records whether a job is being processed by a worker goroutine
+func (w *Worker) trackJob(id primitive.ObjectID, active bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if active {
+		w.inFlight[id] = true
+	} else {
+		delete(w.inFlight, id)
+	}
+}
+
+// requeueInFlight puts every job still running back in the queue
+func (w *Worker) requeueInFlight() {
+	w.mu.Lock()
+	ids := make([]primitive.ObjectID, 0, len(w.inFlight))
+	for id := range w.inFlight {
+		ids = append(ids, id)
+	}
+	w.mu.Unlock()
+
+	requeued := 0
+	for _, id := range ids {
+		if err := w.queue.Requeue(context.Background(), id); err != nil {
+			log.Printf("Failed to requeue in-flight job %s: %v", id.Hex(), err)
+			continue
+		}
+		requeued++
+	}
+	log.Printf("Job worker stop deadline reached, requeued %d in-flight jobs", requeued)
+}
+
+// cleanup removes finished jobs past the retention period
+func (w *Worker) cleanup() {
+	removed, err := w.queue.Cleanup(context.Background(), w.config.Retention)
+	if err != nil {
+		log.Printf("Job cleanup error: %v", err)
+		return
+	}
+	if removed > 0 {
+		log.Printf("Removed %d finished jobs", removed)
+	}
+}
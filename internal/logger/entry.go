@@ -0,0 +1,88 @@
+package logger
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// RequestIDHeader carries the correlation ID of a request
+const RequestIDHeader = "X-Request-ID"
+
+type contextKey struct{}
+
+// field is a single key/value pair attached to an Entry
+type field struct {
+	key   string
+	value string
+}
+
+// Entry is a logger that prefixes every message with its fields
+type Entry struct {
+	fields []field
+}
+
+// FromContext returns the Entry stored in ctx, or an Entry without fields
+func FromContext(ctx context.Context) *Entry {
+	if entry, ok := ctx.Value(contextKey{}).(*Entry); ok {
+		return entry
+	}
+	return &Entry{}
+}
+
+// NewContext returns a copy of ctx carrying entry
+func NewContext(ctx context.Context, entry *Entry) context.Context {
+	return context.WithValue(ctx, contextKey{}, entry)
+}
+
+// With returns a new Entry with an extra field
+func (e *Entry) With(key, value string) *Entry {
+	fields := make([]field, len(e.fields), len(e.fields)+1)
+	copy(fields, e.fields)
+	return &Entry{fields: append(fields, field{key: key, value: value})}
+}
+
+// Field returns the value of the named field, or "" if it is not set
+func (e *Entry) Field(key string) string {
+	for _, f := range e.fields {
+		if f.key == key {
+			return f.value
+		}
+	}
+	return ""
+}
+
+func (e *Entry) Info(message string)  { logFields(Info, message, e.fields) }
+func (e *Entry) Error(message string) { logFields(Error, message, e.fields) }
+func (e *Entry) Warn(message string)  { logFields(Warn, message, e.fields) }
+func (e *Entry) Debug(message string) { logFields(Debug, message, e.fields) }
+func (e *Entry) Trace(message string) { logFields(Trace, message, e.fields) }
+
+// formatFields prepends fields to message for text output
+func formatFields(fields []field, message string) string {
+	if len(fields) == 0 {
+		return message
+	}
+
+	var b strings.Builder
+	for _, f := range fields {
+		value := f.value
+		if strings.ContainsAny(value, " \t") {
+			value = fmt.Sprintf("%q", value)
+		}
+		fmt.Fprintf(&b, "%s=%s ", f.key, value)
+	}
+	b.WriteString(message)
+	return b.String()
+}
+
+// newRequestID generates a random correlation ID
+func newRequestID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(b)
+}
@@ -4,13 +4,23 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"log"
+	"mime"
 	"net/http"
+	"net/url"
 	"os"
 	"os/exec"
+	"regexp"
 	"runtime"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
+
+	"github.com/thenasky/go-framework/internal/metrics"
 )
 
 type LogLevel int
@@ -98,19 +108,70 @@ func getFormattedTimestamp() string {
 type logMessage struct {
 	level   LogLevel
 	message string
+	fields  []field
 }
 
 var logChannel = make(chan logMessage, 1000)
 
 func init() {
-	ClearConsole()
-	PrintBanner()
 	go logWorker()
 }
 
+// Init clears the console and prints the banner, each only when enabled.
+// LOG_CLEAR, LOG_BANNER and LOG_PRETTY default to on for interactive terminals
+// and off otherwise (CI, Docker logs, piped output). LOG_FORMAT=json switches to
+// one JSON object per line. Call it after loading .env.
+func Init() {
+	interactive := isTerminal(os.Stdout)
+
+	if name := os.Getenv("SERVICE_NAME"); name != "" {
+		SetServiceName(name)
+	}
+	SetPretty(envFlag("LOG_PRETTY", interactive))
+	SetJSON(strings.EqualFold(os.Getenv("LOG_FORMAT"), "json"))
+
+	if envFlag("LOG_CLEAR", interactive) {
+		ClearConsole()
+	}
+	if envFlag("LOG_BANNER", interactive) {
+		PrintBanner()
+	}
+}
+
+// isTerminal reports whether f is attached to a terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// envFlag reads a boolean environment variable, falling back to defaultValue
+func envFlag(name string, defaultValue bool) bool {
+	value, err := strconv.ParseBool(os.Getenv(name))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+// slowRequestThreshold reads LOG_SLOW_MS, returning 0 (disabled) when unset or invalid
+func slowRequestThreshold() time.Duration {
+	ms, err := strconv.Atoi(os.Getenv("LOG_SLOW_MS"))
+	if err != nil || ms <= 0 {
+		return 0
+	}
+	return time.Duration(ms) * time.Millisecond
+}
+
 func PrintBanner() {
 	green := "\x1b[32m"
 	reset := "\x1b[0m"
+	name := ServiceName()
+	streamsMu.Lock()
+	buildVersion := version
+	streamsMu.Unlock()
 	fmt.Println()
 	fmt.Printf("%s  ooooooo                                      o8                           %s\n", green, reset)
 	fmt.Printf("%so888   888o oooo  oooo   ooooooo   oo oooooo o888oo oooo   oooo oooo   oooo %s\n", green, reset)
@@ -118,6 +179,9 @@ func PrintBanner() {
 	fmt.Printf("%s888o  8o888  888   888 888    888   888   888 888     888 888     o88 88o   %s\n", green, reset)
 	fmt.Printf("%s  88ooo88     888o88 8o 88ooo88 8o o888o o888o 888o     8888    o88o   o88o %s\n", green, reset)
 	fmt.Printf("%s       88o8                                          o8o888                 %s\n", green, reset)
+	if subtitle := strings.TrimSpace(name + " " + buildVersion); subtitle != "" {
+		fmt.Printf("%s  %s%s\n", green, subtitle, reset)
+	}
 	fmt.Println()
 }
 
@@ -134,15 +198,148 @@ func ClearConsole() {
 
 func logWorker() {
 	for msg := range logChannel {
-		writeLog(msg.level, msg.message)
+		writeEntry(msg.level, msg.message, msg.fields)
+	}
+}
+
+var (
+	streamsMu   sync.Mutex
+	outStream   io.Writer = os.Stdout
+	errStream   io.Writer = os.Stderr
+	serviceName string
+	version     string
+	pretty      = true
+	jsonFormat  bool
+)
+
+// SetServiceName sets the name every log line is prefixed with, e.g. [email-svc],
+// so services sharing a host can be told apart. It also prefixes lines written
+// with the standard log package. Init sets it from SERVICE_NAME.
+func SetServiceName(name string) {
+	streamsMu.Lock()
+	defer streamsMu.Unlock()
+	serviceName = name
+
+	if name == "" {
+		log.SetPrefix("")
+	} else {
+		log.SetPrefix("[" + name + "] ")
+	}
+}
+
+// SetVersion sets the build version shown under the banner
+func SetVersion(v string) {
+	streamsMu.Lock()
+	defer streamsMu.Unlock()
+	version = v
+}
+
+// ServiceName returns the name set with SetServiceName
+func ServiceName() string {
+	streamsMu.Lock()
+	defer streamsMu.Unlock()
+	return serviceName
+}
+
+// SetPretty switches between indented JSON bodies spread over several lines
+// (pretty) and compact output with exactly one line per log entry. Init sets it
+// from LOG_PRETTY.
+func SetPretty(enabled bool) {
+	streamsMu.Lock()
+	defer streamsMu.Unlock()
+	pretty = enabled
+}
+
+// SetJSON switches between the colored text format and one JSON object per
+// line with time, level, service, message and the Entry's fields as keys, for
+// log collectors. Init sets it from LOG_FORMAT.
+func SetJSON(enabled bool) {
+	streamsMu.Lock()
+	defer streamsMu.Unlock()
+	jsonFormat = enabled
+}
+
+// Pretty reports whether pretty output is enabled
+func Pretty() bool {
+	streamsMu.Lock()
+	defer streamsMu.Unlock()
+	return pretty
+}
+
+// SetOutStream sets the writer used for informational levels (default os.Stdout)
+func SetOutStream(w io.Writer) {
+	streamsMu.Lock()
+	defer streamsMu.Unlock()
+	outStream = w
+}
+
+// SetErrorStream sets the writer used for error levels (default os.Stderr)
+func SetErrorStream(w io.Writer) {
+	streamsMu.Lock()
+	defer streamsMu.Unlock()
+	errStream = w
+}
+
+// severity ranks levels for stream routing: 0 info, 1 warning, 2 error
+func (l LogLevel) severity() int {
+	switch l {
+	case Error, MongoError, NotFound:
+		return 2
+	case Warn:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// errorThreshold returns the minimum severity routed to the error stream.
+// LOG_STDERR_THRESHOLD can be 'error' (default), 'warn' or 'info'.
+func errorThreshold() int {
+	switch os.Getenv("LOG_STDERR_THRESHOLD") {
+	case "info":
+		return 0
+	case "warn":
+		return 1
+	default:
+		return 2
 	}
 }
 
 func writeLog(level LogLevel, message string) {
+	writeEntry(level, message, nil)
+}
+
+// writeEntry writes a message with the fields of the Entry that logged it
+func writeEntry(level LogLevel, message string, fields []field) {
 	timestamp := getFormattedTimestamp()
 	color := level.color()
 	tag := level.String()
 
+	// Keep multi-line messages together when logging synchronously and asynchronously
+	streamsMu.Lock()
+	defer streamsMu.Unlock()
+
+	out := outStream
+	if level.severity() >= errorThreshold() {
+		out = errStream
+	}
+
+	if jsonFormat {
+		writeJSON(out, level, message, fields)
+		return
+	}
+	message = formatFields(fields, message)
+
+	prefix := ""
+	if serviceName != "" {
+		prefix = "[" + serviceName + "] "
+	}
+
+	// Compact output keeps every entry on one line for log collectors
+	if !pretty {
+		message = strings.ReplaceAll(message, "\n", `\n`)
+	}
+
 	// Handle multi-line messages (like JSON responses) by putting diamond at the end
 	if strings.Contains(message, "\n") {
 		lines := strings.Split(message, "\n")
@@ -157,33 +354,67 @@ func writeLog(level LogLevel, message string) {
 		}
 
 		// Print first line without diamond
-		fmt.Fprintf(os.Stdout, "\x1b[90m%s\x1b[0m %s[%s]\x1b[0m %s\n", timestamp, color, tag, lines[0])
+		fmt.Fprintf(out, "%s\x1b[90m%s\x1b[0m %s[%s]\x1b[0m %s\n", prefix, timestamp, color, tag, lines[0])
 
 		// Print remaining lines
 		for i := 1; i < len(lines); i++ {
 			if i == lastNonEmptyIndex && strings.TrimSpace(lines[i]) != "" {
 				// Add diamond to the last non-empty line
-				fmt.Fprintf(os.Stdout, "%s %s◆\x1b[0m\n", lines[i], color)
+				fmt.Fprintf(out, "%s %s◆\x1b[0m\n", lines[i], color)
 			} else {
-				fmt.Fprintf(os.Stdout, "%s\n", lines[i])
+				fmt.Fprintf(out, "%s\n", lines[i])
 			}
 		}
 	} else {
 		// Single line message - use original format
-		fmt.Fprintf(os.Stdout, "\x1b[90m%s\x1b[0m %s[%s]\x1b[0m %s %s◆\x1b[0m\n", timestamp, color, tag, message, color)
+		fmt.Fprintf(out, "%s\x1b[90m%s\x1b[0m %s[%s]\x1b[0m %s %s◆\x1b[0m\n", prefix, timestamp, color, tag, message, color)
 	}
 }
 
 func Log(level LogLevel, message string) {
+	logFields(level, message, nil)
+}
+
+// logFields queues a message along with the fields of the Entry that logged it
+func logFields(level LogLevel, message string, fields []field) {
 	select {
-	case logChannel <- logMessage{level: level, message: message}:
+	case logChannel <- logMessage{level: level, message: message, fields: fields}:
 	default:
 		// Channel is full, fallback to synchronous logging
 		fmt.Fprintln(os.Stderr, "Async logging channel full. Falling back to sync logging.")
-		writeLog(level, message)
+		writeEntry(level, message, fields)
 	}
 }
 
+// ansiEscape matches the color codes used in text output
+var ansiEscape = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+// writeJSON writes a single JSON log line. Fields never override the standard
+// keys. The caller holds streamsMu.
+func writeJSON(out io.Writer, level LogLevel, message string, fields []field) {
+	line := make(map[string]string, len(fields)+4)
+	for _, f := range fields {
+		line[f.key] = f.value
+	}
+	line["time"] = time.Now().UTC().Format(time.RFC3339Nano)
+	line["level"] = level.String()
+	line["message"] = ansiEscape.ReplaceAllString(message, "")
+	if serviceName != "" {
+		line["service"] = serviceName
+	} else {
+		delete(line, "service")
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(line); err != nil {
+		fmt.Fprintf(out, "{\"level\":\"ERROR\",\"message\":%q}\n", "failed to encode log entry: "+err.Error())
+		return
+	}
+	out.Write(buf.Bytes())
+}
+
 func LogInfo(message string)     { Log(Info, message) }
 func LogError(message string)    { Log(Error, message) }
 func LogWarn(message string)     { Log(Warn, message) }
@@ -204,10 +435,103 @@ func LogWarnSync(message string)  { writeLog(Warn, message) }
 func LogMongo(message string)      { Log(Mongo, message) }
 func LogMongoError(message string) { Log(MongoError, message) }
 
+// Redis logging function
+func LogRedis(message string) { Log(Redis, message) }
+
 // MongoDB synchronous logging functions
 func LogMongoSync(message string)      { writeLog(Mongo, message) }
 func LogMongoErrorSync(message string) { writeLog(MongoError, message) }
 
+var (
+	skipPathsMu sync.RWMutex
+	// skipPaths holds path prefixes whose requests are never logged
+	skipPaths = []string{"/swagger"}
+)
+
+// SkipPath excludes requests whose path starts with prefix from request logging
+func SkipPath(prefix string) {
+	skipPathsMu.Lock()
+	defer skipPathsMu.Unlock()
+	skipPaths = append(skipPaths, prefix)
+}
+
+// isSkippedPath reports whether requests to path should not be logged
+func isSkippedPath(path string) bool {
+	skipPathsMu.RLock()
+	defer skipPathsMu.RUnlock()
+	for _, prefix := range skipPaths {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// RouteResolver returns the path template of the route a request matches and
+// the path variables extracted from it, or ok false when no route matches
+type RouteResolver func(r *http.Request) (template string, vars map[string]string, ok bool)
+
+var (
+	routeResolverMu sync.RWMutex
+	routeResolver   RouteResolver
+)
+
+// SetRouteResolver lets LOG_QUERIES log path variables. The request logger runs
+// before routing, so the router provides the match.
+func SetRouteResolver(resolver RouteResolver) {
+	routeResolverMu.Lock()
+	defer routeResolverMu.Unlock()
+	routeResolver = resolver
+}
+
+// logParameters logs the path variables and the decoded query parameters of a request
+func logParameters(r *http.Request) {
+	routeResolverMu.RLock()
+	resolver := routeResolver
+	routeResolverMu.RUnlock()
+
+	if resolver != nil {
+		if template, vars, ok := resolver(r); ok && len(vars) > 0 {
+			params := make(url.Values, len(vars))
+			for key, value := range vars {
+				params.Set(key, value)
+			}
+			LogQueries(fmt.Sprintf("Path %s: %s", template, formatParams(params)))
+		}
+	}
+
+	if r.URL.RawQuery == "" {
+		return
+	}
+	query, err := url.ParseQuery(r.URL.RawQuery)
+	if err != nil {
+		LogQueries(fmt.Sprintf("Query (malformed: %v): %s", err, r.URL.RawQuery))
+		return
+	}
+	LogQueries("Query: " + formatParams(query))
+}
+
+// formatParams formats parameters as key=value pairs sorted by key, quoting
+// values that are empty or contain separators
+func formatParams(params url.Values) string {
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var pairs []string
+	for _, key := range keys {
+		for _, value := range params[key] {
+			if value == "" || strings.ContainsAny(value, " ,=\"\n") {
+				value = strconv.Quote(value)
+			}
+			pairs = append(pairs, key+"="+value)
+		}
+	}
+	return strings.Join(pairs, ", ")
+}
+
 func RequestLogger(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Capture start time immediately
@@ -221,9 +545,20 @@ func RequestLogger(next http.Handler) http.Handler {
 		// Restore the body
 		r.Body = ioutil.NopCloser(bytes.NewBuffer(bodyBytes))
 
-		// Always skip logging for swagger requests
-		if strings.HasPrefix(r.URL.Path, "/swagger") {
-			next.ServeHTTP(w, r)
+		// Seed the request context with a correlated logger
+		requestID := r.Header.Get(RequestIDHeader)
+		if requestID == "" {
+			requestID = newRequestID()
+		}
+		w.Header().Set(RequestIDHeader, requestID)
+		entry := (&Entry{}).
+			With("request_id", requestID).
+			With("route", r.Method+" "+r.URL.Path)
+		r = r.WithContext(NewContext(r.Context(), entry))
+
+		// Always skip logging for swagger and other excluded requests
+		if isSkippedPath(r.URL.Path) {
+			next.ServeHTTP(&loggingResponseWriter{ResponseWriter: w, statusCode: http.StatusOK, start: requestStart}, r)
 			return
 		}
 
@@ -234,9 +569,7 @@ func RequestLogger(next http.Handler) http.Handler {
 		}
 
 		if os.Getenv("LOG_QUERIES") == "true" {
-			if query := r.URL.RawQuery; query != "" {
-				LogQueries(strings.ReplaceAll(query, "&", ", "))
-			}
+			logParameters(r)
 		}
 
 		if os.Getenv("LOG_HEADERS") == "true" {
@@ -253,21 +586,37 @@ func RequestLogger(next http.Handler) http.Handler {
 			LogBody(prettyPrintJSON(bodyBytes))
 		}
 
-		lrw := &loggingResponseWriter{w, http.StatusOK, make([]byte, 0)}
+		// The body is only kept when it is logged
+		lrw := &loggingResponseWriter{ResponseWriter: w, statusCode: http.StatusOK, start: requestStart}
+		if os.Getenv("LOG_RESPONSE") == "true" {
+			lrw.body = make([]byte, 0)
+		}
 		next.ServeHTTP(lrw, r)
 
+		// Calculate elapsed time using time.Since for better precision
+		elapsed := time.Since(requestStart)
+		metrics.ObserveHTTPRequest(lrw.statusCode, elapsed)
+
+		// Slow requests are flagged whether or not response logging is on
+		if threshold := slowRequestThreshold(); threshold > 0 && elapsed > threshold {
+			entry.Warn(fmt.Sprintf("Slow request: %s %s took %.2fms (threshold %dms)",
+				r.Method, r.URL.Path, float64(elapsed.Nanoseconds())/1000000.0, threshold.Milliseconds()))
+		}
+
 		if lrw.statusCode == http.StatusNotFound {
 			// The notFoundHandler will log this, so we don't need to do anything here.
 			return
 		}
 
-		// Calculate elapsed time using time.Since for better precision
-		elapsed := time.Since(requestStart)
-
 		responseBody := string(lrw.body)
-		if responseBody == "" {
+		switch {
+		case lrw.streaming:
+			responseBody = fmt.Sprintf("Status: %d (event stream)", lrw.statusCode)
+		case lrw.truncated:
+			responseBody += fmt.Sprintf("... (truncated at %d bytes)", maxLoggedResponseBytes)
+		case responseBody == "":
 			responseBody = fmt.Sprintf("Status: %d", lrw.statusCode)
-		} else {
+		default:
 			// Format JSON responses for better readability
 			responseBody = prettyPrintJSON(lrw.body)
 		}
@@ -320,27 +669,73 @@ func getColoredStatus(statusCode int) string {
 	return fmt.Sprintf("%s%s %d%s", color, statusText, statusCode, reset)
 }
 
+// prettyPrintJSON indents JSON bodies for logging, or compacts them onto a
+// single line when pretty output is off
 func prettyPrintJSON(b []byte) string {
 	var out bytes.Buffer
-	err := json.Indent(&out, b, "", "  ")
+	var err error
+	if Pretty() {
+		err = json.Indent(&out, b, "", "  ")
+	} else {
+		err = json.Compact(&out, b)
+	}
 	if err != nil {
 		return string(b)
 	}
 	return out.String()
 }
 
+// ResponseTimeHeader reports how long the server took to produce the response headers
+const ResponseTimeHeader = "X-Response-Time-Ms"
+
+// maxLoggedResponseBytes caps the part of a response body kept for the log
+const maxLoggedResponseBytes = 64 * 1024
+
 type loggingResponseWriter struct {
 	http.ResponseWriter
-	statusCode int
-	body       []byte
+	statusCode  int
+	body        []byte // Captured for LOG_RESPONSE; nil when not captured
+	truncated   bool   // The body exceeded maxLoggedResponseBytes
+	streaming   bool   // An event stream, whose body is never captured
+	start       time.Time
+	wroteHeader bool
 }
 
+// WriteHeader adds the X-Response-Time-Ms header, the last moment headers can still be set
 func (lrw *loggingResponseWriter) WriteHeader(code int) {
+	if lrw.wroteHeader {
+		return
+	}
+	lrw.wroteHeader = true
 	lrw.statusCode = code
+
+	// Event streams stay open for as long as the client listens
+	if mediaType, _, _ := mime.ParseMediaType(lrw.Header().Get("Content-Type")); mediaType == "text/event-stream" {
+		lrw.streaming = true
+		lrw.body = nil
+	}
+
+	elapsed := time.Since(lrw.start)
+	lrw.Header().Set(ResponseTimeHeader, strconv.FormatFloat(float64(elapsed.Microseconds())/1000, 'f', 2, 64))
 	lrw.ResponseWriter.WriteHeader(code)
 }
 
 func (lrw *loggingResponseWriter) Write(data []byte) (int, error) {
-	lrw.body = append(lrw.body, data...)
+	if !lrw.wroteHeader {
+		lrw.WriteHeader(http.StatusOK)
+	}
+	if lrw.body != nil && !lrw.truncated {
+		if room := maxLoggedResponseBytes - len(lrw.body); len(data) > room {
+			lrw.body = append(lrw.body, data[:room]...)
+			lrw.truncated = true
+		} else {
+			lrw.body = append(lrw.body, data...)
+		}
+	}
 	return lrw.ResponseWriter.Write(data)
 }
+
+// Unwrap exposes the underlying writer to http.ResponseController (flushing, deadlines)
+func (lrw *loggingResponseWriter) Unwrap() http.ResponseWriter {
+	return lrw.ResponseWriter
+}
@@ -0,0 +1,142 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestLoggingResponseWriterSkipsEventStreams(t *testing.T) {
+	lrw := &loggingResponseWriter{ResponseWriter: httptest.NewRecorder(), statusCode: http.StatusOK, body: make([]byte, 0)}
+	lrw.Header().Set("Content-Type", "text/event-stream")
+	lrw.WriteHeader(http.StatusOK)
+
+	frame := []byte("data: {\"status\":\"pending\"}\n\n")
+	for i := 0; i < 1000; i++ {
+		lrw.Write(frame)
+	}
+
+	if !lrw.streaming {
+		t.Error("event stream not detected")
+	}
+	if lrw.body != nil {
+		t.Errorf("event stream body captured: %d bytes", len(lrw.body))
+	}
+}
+
+func TestLoggingResponseWriterCapsCapturedBody(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	lrw := &loggingResponseWriter{ResponseWriter: recorder, statusCode: http.StatusOK, body: make([]byte, 0)}
+
+	chunk := bytes.Repeat([]byte("x"), 1000)
+	for i := 0; i < 100; i++ {
+		lrw.Write(chunk)
+	}
+
+	if len(lrw.body) != maxLoggedResponseBytes || !lrw.truncated {
+		t.Errorf("captured %d bytes (truncated %v), want %d truncated", len(lrw.body), lrw.truncated, maxLoggedResponseBytes)
+	}
+	if recorder.Body.Len() != 100*len(chunk) {
+		t.Errorf("client got %d bytes, want the full %d", recorder.Body.Len(), 100*len(chunk))
+	}
+}
+
+// captureStreams redirects the output and error streams to buffers until the test ends
+func captureStreams(t *testing.T) (out, errOut *bytes.Buffer) {
+	t.Helper()
+	out, errOut = new(bytes.Buffer), new(bytes.Buffer)
+	SetOutStream(out)
+	SetErrorStream(errOut)
+	t.Cleanup(func() {
+		SetOutStream(os.Stdout)
+		SetErrorStream(os.Stderr)
+	})
+	return out, errOut
+}
+
+func TestLevelsRouteToStreams(t *testing.T) {
+	tests := []struct {
+		threshold string
+		level     LogLevel
+		toErr     bool
+	}{
+		{"", Info, false},
+		{"", Warn, false},
+		{"", Error, true},
+		{"", MongoError, true},
+		{"warn", Warn, true},
+		{"warn", Database, false},
+		{"info", Info, true},
+	}
+
+	for _, tt := range tests {
+		t.Setenv("LOG_STDERR_THRESHOLD", tt.threshold)
+		out, errOut := captureStreams(t)
+
+		writeLog(tt.level, "routed")
+
+		got, other := out, errOut
+		if tt.toErr {
+			got, other = errOut, out
+		}
+		if !bytes.Contains(got.Bytes(), []byte("routed")) || other.Len() != 0 {
+			t.Errorf("threshold %q, level %s: stdout %q, stderr %q", tt.threshold, tt.level, out.String(), errOut.String())
+		}
+	}
+}
+
+func TestAsyncLogReachesStream(t *testing.T) {
+	_, errOut := captureStreams(t)
+
+	LogError("queued through the log channel")
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		streamsMu.Lock()
+		written := bytes.Contains(errOut.Bytes(), []byte("queued through the log channel"))
+		streamsMu.Unlock()
+		if written {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("asynchronous entry never written to the error stream")
+}
+
+func TestJSONOutputCarriesService(t *testing.T) {
+	out, _ := captureStreams(t)
+	SetServiceName("email-svc")
+	SetJSON(true)
+	defer func() {
+		SetJSON(false)
+		SetServiceName("")
+	}()
+
+	writeEntry(Info, "Email \x1b[32mqueued\x1b[0m\nsecond line", []field{{key: "request_id", value: "abc123"}})
+
+	if lines := bytes.Count(out.Bytes(), []byte("\n")); lines != 1 {
+		t.Fatalf("got %d lines, want 1: %q", lines, out.String())
+	}
+	var line map[string]string
+	if err := json.Unmarshal(out.Bytes(), &line); err != nil {
+		t.Fatalf("not JSON: %v: %q", err, out.String())
+	}
+	want := map[string]string{
+		"level":      "INFO",
+		"service":    "email-svc",
+		"message":    "Email queued\nsecond line",
+		"request_id": "abc123",
+	}
+	for key, value := range want {
+		if line[key] != value {
+			t.Errorf("%s = %q, want %q", key, line[key], value)
+		}
+	}
+	if line["time"] == "" {
+		t.Error("time missing")
+	}
+}
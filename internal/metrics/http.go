@@ -0,0 +1,26 @@
+package metrics
+
+import (
+	"fmt"
+	"time"
+)
+
+var (
+	httpRequestsTotal = NewCounter(
+		"http_requests_total",
+		"Total number of HTTP requests by status class.",
+		"status_class",
+	)
+
+	httpRequestDuration = NewHistogram(
+		"http_request_duration_seconds",
+		"HTTP request latency in seconds.",
+		nil,
+	)
+)
+
+// ObserveHTTPRequest records a completed HTTP request
+func ObserveHTTPRequest(statusCode int, elapsed time.Duration) {
+	httpRequestsTotal.Inc(fmt.Sprintf("%dxx", statusCode/100))
+	httpRequestDuration.Observe(elapsed.Seconds())
+}
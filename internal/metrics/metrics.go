@@ -0,0 +1,270 @@
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// collector is implemented by every metric type that can be exposed
+type collector interface {
+	write(sb *strings.Builder)
+}
+
+var (
+	collectors   []collector
+	collectorsMu sync.RWMutex
+)
+
+// register adds a collector to the exposition list
+func register(c collector) {
+	collectorsMu.Lock()
+	defer collectorsMu.Unlock()
+	collectors = append(collectors, c)
+}
+
+// ===== Counter =====
+
+// Counter is a monotonically increasing value, optionally split by labels
+type Counter struct {
+	name       string
+	help       string
+	labelNames []string
+	values     map[string]float64
+	mu         sync.Mutex
+}
+
+// NewCounter creates and registers a counter
+func NewCounter(name, help string, labelNames ...string) *Counter {
+	c := &Counter{
+		name:       name,
+		help:       help,
+		labelNames: labelNames,
+		values:     make(map[string]float64),
+	}
+
+	// Unlabelled counters are exposed from the start
+	if len(labelNames) == 0 {
+		c.values[""] = 0
+	}
+
+	register(c)
+	return c
+}
+
+// Inc increments the counter for the given label values by one
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add increments the counter for the given label values by delta
+func (c *Counter) Add(delta float64, labelValues ...string) {
+	key := formatLabels(c.labelNames, labelValues)
+
+	c.mu.Lock()
+	c.values[key] += delta
+	c.mu.Unlock()
+}
+
+func (c *Counter) write(sb *strings.Builder) {
+	writeHeader(sb, c.name, c.help, "counter")
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, key := range sortedKeys(c.values) {
+		fmt.Fprintf(sb, "%s%s %s\n", c.name, key, formatValue(c.values[key]))
+	}
+}
+
+// ===== Histogram =====
+
+// DefaultBuckets are latency buckets in seconds suited for HTTP requests
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Histogram samples observations into cumulative buckets
+type Histogram struct {
+	name    string
+	help    string
+	buckets []float64
+	counts  []uint64
+	sum     float64
+	count   uint64
+	mu      sync.Mutex
+}
+
+// NewHistogram creates and registers a histogram (DefaultBuckets when buckets is nil)
+func NewHistogram(name, help string, buckets []float64) *Histogram {
+	if buckets == nil {
+		buckets = DefaultBuckets
+	}
+
+	h := &Histogram{
+		name:    name,
+		help:    help,
+		buckets: buckets,
+		counts:  make([]uint64, len(buckets)),
+	}
+	register(h)
+	return h
+}
+
+// Observe records a single value
+func (h *Histogram) Observe(value float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for i, upperBound := range h.buckets {
+		if value <= upperBound {
+			h.counts[i]++
+		}
+	}
+	h.sum += value
+	h.count++
+}
+
+func (h *Histogram) write(sb *strings.Builder) {
+	writeHeader(sb, h.name, h.help, "histogram")
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for i, upperBound := range h.buckets {
+		fmt.Fprintf(sb, "%s_bucket{le=\"%s\"} %d\n", h.name, formatValue(upperBound), h.counts[i])
+	}
+	fmt.Fprintf(sb, "%s_bucket{le=\"+Inf\"} %d\n", h.name, h.count)
+	fmt.Fprintf(sb, "%s_sum %s\n", h.name, formatValue(h.sum))
+	fmt.Fprintf(sb, "%s_count %d\n", h.name, h.count)
+}
+
+// ===== Gauge =====
+
+// GaugeFunc is a gauge whose value is computed at scrape time
+type GaugeFunc struct {
+	name string
+	help string
+	fn   func() (float64, error)
+}
+
+// NewGaugeFunc creates and registers a gauge backed by fn. The gauge is omitted
+// from the output when fn returns an error.
+func NewGaugeFunc(name, help string, fn func() (float64, error)) *GaugeFunc {
+	g := &GaugeFunc{
+		name: name,
+		help: help,
+		fn:   fn,
+	}
+	register(g)
+	return g
+}
+
+func (g *GaugeFunc) write(sb *strings.Builder) {
+	value, err := g.fn()
+	if err != nil {
+		return
+	}
+
+	writeHeader(sb, g.name, g.help, "gauge")
+	fmt.Fprintf(sb, "%s %s\n", g.name, formatValue(value))
+}
+
+// GaugeVecFunc is a gauge with one label whose values are computed at scrape time
+type GaugeVecFunc struct {
+	name      string
+	help      string
+	labelName string
+	fn        func() (map[string]float64, error)
+}
+
+// NewGaugeVecFunc creates and registers a gauge backed by fn, which returns a
+// value per label value. The gauge is omitted from the output when fn returns an error.
+func NewGaugeVecFunc(name, help, labelName string, fn func() (map[string]float64, error)) *GaugeVecFunc {
+	g := &GaugeVecFunc{
+		name:      name,
+		help:      help,
+		labelName: labelName,
+		fn:        fn,
+	}
+	register(g)
+	return g
+}
+
+func (g *GaugeVecFunc) write(sb *strings.Builder) {
+	values, err := g.fn()
+	if err != nil {
+		return
+	}
+
+	writeHeader(sb, g.name, g.help, "gauge")
+	for _, label := range sortedKeys(values) {
+		labels := formatLabels([]string{g.labelName}, []string{label})
+		fmt.Fprintf(sb, "%s%s %s\n", g.name, labels, formatValue(values[label]))
+	}
+}
+
+// ===== Exposition =====
+
+// Handler serves all registered metrics in the Prometheus text format
+func Handler(w http.ResponseWriter, r *http.Request) {
+	var sb strings.Builder
+
+	collectorsMu.RLock()
+	for _, c := range collectors {
+		c.write(&sb)
+	}
+	collectorsMu.RUnlock()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(sb.String()))
+}
+
+// writeHeader writes the HELP and TYPE lines of a metric
+func writeHeader(sb *strings.Builder, name, help, metricType string) {
+	fmt.Fprintf(sb, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(sb, "# TYPE %s %s\n", name, metricType)
+}
+
+// formatLabels renders label pairs as {a="x",b="y"}
+func formatLabels(names, values []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		var value string
+		if i < len(values) {
+			value = values[i]
+		}
+		pairs[i] = fmt.Sprintf("%s=%q", name, value)
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// formatValue renders a sample value
+func formatValue(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+// sortedKeys returns the keys of m in a stable order
+func sortedKeys(m map[string]float64) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
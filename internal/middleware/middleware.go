@@ -1,22 +1,45 @@
 package middleware
 
 import (
+	"crypto/subtle"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/mail"
+	"os"
+	"regexp"
+	"runtime/debug"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/thenasky/go-framework/internal/cache"
+	"github.com/thenasky/go-framework/internal/logger"
 	"github.com/thenasky/go-framework/internal/router"
 )
 
+// ValueType is the expected type of a validated value
+type ValueType string
+
+const (
+	TypeAny     ValueType = ""        // Validate whatever type the value has
+	TypeString  ValueType = "string"  // Must be a string
+	TypeNumber  ValueType = "number"  // Must be a number (query strings are parsed)
+	TypeBoolean ValueType = "boolean" // Must be a boolean (query strings are parsed)
+)
+
 // ValidationRule represents a validation rule for a field
 type ValidationRule struct {
 	Field    string
 	Required bool
+	Type     ValueType
+	Array    bool // Validate every value of a repeated query param or JSON array
 	Min      int
 	Max      int
-	Pattern  string
+	Pattern  string // Regular expression string values must match
+	Email    bool   // String values must be email addresses
 	Custom   func(value interface{}) error
 }
 
@@ -51,7 +74,7 @@ func (vm *ValidationMiddleware) Validate(endpoint string) func(http.HandlerFunc)
 			var body map[string]interface{}
 			if r.Header.Get("Content-Type") == "application/json" {
 				if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
-					res := router.NewResponse(w)
+					res := router.NewResponse(w).WithRequest(r)
 					res.BadRequest("Invalid JSON body", map[string]string{"error": err.Error()})
 					return
 				}
@@ -73,7 +96,7 @@ func (vm *ValidationMiddleware) Validate(endpoint string) func(http.HandlerFunc)
 
 			// If validation failed, return error
 			if len(validationErrors) > 0 {
-				res := router.NewResponse(w)
+				res := router.NewResponse(w).WithRequest(r)
 				res.ValidationError("Validation failed", validationErrors)
 				return
 			}
@@ -88,7 +111,7 @@ func (vm *ValidationMiddleware) Validate(endpoint string) func(http.HandlerFunc)
 func (vm *ValidationMiddleware) validateField(rule ValidationRule, body map[string]interface{}, query map[string][]string) error {
 	// Check if field exists in body or query
 	var value interface{}
-	var exists bool
+	var exists, fromQuery bool
 
 	// Check body first, then query parameters
 	if body != nil {
@@ -96,8 +119,17 @@ func (vm *ValidationMiddleware) validateField(rule ValidationRule, body map[stri
 	}
 	if !exists && query != nil {
 		if queryValues, ok := query[rule.Field]; ok && len(queryValues) > 0 {
-			value = queryValues[0]
 			exists = true
+			fromQuery = true
+			if rule.Array {
+				values := make([]interface{}, len(queryValues))
+				for i, v := range queryValues {
+					values[i] = v
+				}
+				value = values
+			} else {
+				value = queryValues[0]
+			}
 		}
 	}
 
@@ -111,11 +143,52 @@ func (vm *ValidationMiddleware) validateField(rule ValidationRule, body map[stri
 		return nil
 	}
 
+	if !rule.Array {
+		return vm.checkValue(rule, value, fromQuery)
+	}
+
+	items, ok := value.([]interface{})
+	if !ok {
+		return fmt.Errorf("Field '%s' must be an array", rule.Field)
+	}
+	for _, item := range items {
+		if err := vm.checkValue(rule, item, fromQuery); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkValue coerces a single value to the rule's type and runs every check on it
+func (vm *ValidationMiddleware) checkValue(rule ValidationRule, value interface{}, fromQuery bool) error {
+	value, err := coerceValue(rule, value, fromQuery)
+	if err != nil {
+		return err
+	}
+
 	// Type-specific validation
 	if err := vm.validateValue(rule, value); err != nil {
 		return err
 	}
 
+	// Format validation
+	if str, ok := value.(string); ok {
+		if rule.Pattern != "" {
+			re, err := regexp.Compile(rule.Pattern)
+			if err != nil {
+				return fmt.Errorf("Field '%s' has an invalid pattern: %v", rule.Field, err)
+			}
+			if !re.MatchString(str) {
+				return fmt.Errorf("Field '%s' must match pattern %s", rule.Field, rule.Pattern)
+			}
+		}
+		if rule.Email {
+			if addr, err := mail.ParseAddress(str); err != nil || addr.Address != str {
+				return fmt.Errorf("Field '%s' must be a valid email address", rule.Field)
+			}
+		}
+	}
+
 	// Custom validation
 	if rule.Custom != nil {
 		if err := rule.Custom(value); err != nil {
@@ -126,6 +199,44 @@ func (vm *ValidationMiddleware) validateField(rule ValidationRule, body map[stri
 	return nil
 }
 
+// coerceValue converts query strings to the rule's type and checks body value types
+func coerceValue(rule ValidationRule, value interface{}, fromQuery bool) (interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	switch rule.Type {
+	case TypeNumber:
+		if str, ok := value.(string); ok && fromQuery {
+			num, err := strconv.ParseFloat(str, 64)
+			if err != nil {
+				return nil, fmt.Errorf("Field '%s' must be a number", rule.Field)
+			}
+			return num, nil
+		}
+		if _, ok := value.(float64); !ok {
+			return nil, fmt.Errorf("Field '%s' must be a number", rule.Field)
+		}
+	case TypeBoolean:
+		if str, ok := value.(string); ok && fromQuery {
+			b, err := strconv.ParseBool(str)
+			if err != nil {
+				return nil, fmt.Errorf("Field '%s' must be a boolean", rule.Field)
+			}
+			return b, nil
+		}
+		if _, ok := value.(bool); !ok {
+			return nil, fmt.Errorf("Field '%s' must be a boolean", rule.Field)
+		}
+	case TypeString:
+		if _, ok := value.(string); !ok {
+			return nil, fmt.Errorf("Field '%s' must be a string", rule.Field)
+		}
+	}
+
+	return value, nil
+}
+
 // validateValue performs type-specific validation
 func (vm *ValidationMiddleware) validateValue(rule ValidationRule, value interface{}) error {
 	if value == nil {
@@ -181,15 +292,48 @@ func MaxLength(field string, max int) ValidationRule {
 	}
 }
 
-// Range creates a numeric range rule
+// Range creates a numeric range rule (numeric query params are parsed)
 func Range(field string, min, max int) ValidationRule {
 	return ValidationRule{
 		Field: field,
+		Type:  TypeNumber,
 		Min:   min,
 		Max:   max,
 	}
 }
 
+// Number creates a rule requiring a numeric value
+func Number(field string) ValidationRule {
+	return ValidationRule{
+		Field: field,
+		Type:  TypeNumber,
+	}
+}
+
+// Boolean creates a rule requiring a boolean value
+func Boolean(field string) ValidationRule {
+	return ValidationRule{
+		Field: field,
+		Type:  TypeBoolean,
+	}
+}
+
+// Pattern creates a rule requiring string values to match a regular expression
+func Pattern(field, pattern string) ValidationRule {
+	return ValidationRule{
+		Field:   field,
+		Pattern: pattern,
+	}
+}
+
+// Email creates a rule requiring a valid email address
+func Email(field string) ValidationRule {
+	return ValidationRule{
+		Field: field,
+		Email: true,
+	}
+}
+
 // Custom creates a custom validation rule
 func Custom(field string, validator func(value interface{}) error) ValidationRule {
 	return ValidationRule{
@@ -205,21 +349,31 @@ func RecoveryMiddleware(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		defer func() {
 			if err := recover(); err != nil {
-				// Log the panic (you might want to use your logger here)
-				// logger.LogError(fmt.Sprintf("Panic recovered: %v", err))
+				// Let net/http handle deliberate aborts
+				if err == http.ErrAbortHandler {
+					panic(err)
+				}
 
 				// Generate a unique ID for tracking
 				internalID := generateInternalID()
 
-				// Return a proper error response
-				res := router.NewResponse(w)
-				res.InternalError(
-					"An unexpected error occurred",
-					internalID,
-					map[string]interface{}{
+				// Log the panic with the request's correlation ID and a stack trace
+				stack := debug.Stack()
+				logger.FromContext(r.Context()).Error(fmt.Sprintf("Panic recovered [%s]: %v\n%s", internalID, err, stack))
+
+				// Only debug builds expose the panic; production responses carry just
+				// the internal ID to look up the log entry
+				var details interface{}
+				if os.Getenv("DEBUG") == "true" {
+					details = map[string]interface{}{
 						"error": fmt.Sprintf("%v", err),
-					},
-				)
+						"stack": trimStack(stack),
+					}
+				}
+
+				// Return a proper error response
+				res := router.NewResponse(w).WithRequest(r)
+				res.InternalError("An unexpected error occurred", internalID, details)
 			}
 		}()
 
@@ -227,11 +381,227 @@ func RecoveryMiddleware(next http.HandlerFunc) http.HandlerFunc {
 	}
 }
 
+// maxStackFrames bounds the frames of a panic stack included in debug responses
+const maxStackFrames = 15
+
+// trimStack turns a debug.Stack trace into "function (file:line)" frames, starting
+// at the frame that panicked and dropping the recovery machinery above it
+func trimStack(stack []byte) []string {
+	lines := strings.Split(strings.TrimSpace(string(stack)), "\n")
+
+	// Frames come in pairs after the goroutine header: function, then "\tfile:line +0x.."
+	start := 1
+	for i := 1; i < len(lines); i += 2 {
+		if strings.HasPrefix(lines[i], "panic(") {
+			start = i + 2
+			break
+		}
+	}
+
+	var frames []string
+	for i := start; i+1 < len(lines) && len(frames) < maxStackFrames; i += 2 {
+		location := strings.TrimSpace(lines[i+1])
+		if offset := strings.LastIndex(location, " +0x"); offset != -1 {
+			location = location[:offset]
+		}
+		frames = append(frames, fmt.Sprintf("%s (%s)", lines[i], location))
+	}
+	return frames
+}
+
 // generateInternalID generates a simple internal ID for error tracking
 func generateInternalID() string {
 	return fmt.Sprintf("ERR_%d", time.Now().Unix())
 }
 
+// ===== API Key Middleware =====
+
+// APIKeyMiddleware only lets through requests carrying one of keys in the
+// X-API-Key header or as an Authorization bearer token. With no keys
+// configured every request is rejected, so guarded routes stay closed by default.
+func APIKeyMiddleware(keys []string) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get("X-API-Key")
+			if key == "" {
+				key = strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+			}
+
+			if key == "" || !validAPIKey(keys, key) {
+				res := router.NewResponse(w).WithRequest(r)
+				res.Unauthorized("A valid API key is required", nil)
+				return
+			}
+
+			next(w, r)
+		}
+	}
+}
+
+// validAPIKey compares key against every configured key in constant time
+func validAPIKey(keys []string, key string) bool {
+	valid := false
+	for _, candidate := range keys {
+		if candidate != "" && subtle.ConstantTimeCompare([]byte(candidate), []byte(key)) == 1 {
+			valid = true
+		}
+	}
+	return valid
+}
+
+// ===== Maintenance Middleware =====
+
+// MaintenanceRetryAfter is the Retry-After sent while maintenance mode is on, in seconds
+const MaintenanceRetryAfter = 120
+
+// MaintenanceMiddleware answers every request with 503 while enabled is set,
+// except for allow-listed paths. An entry ending in "/" allows every path under it.
+func MaintenanceMiddleware(enabled *atomic.Bool, allowlist []string) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if !enabled.Load() || pathAllowed(allowlist, r.URL.Path) {
+				next(w, r)
+				return
+			}
+
+			w.Header().Set("Retry-After", strconv.Itoa(MaintenanceRetryAfter))
+			res := router.NewResponse(w).WithRequest(r)
+			res.Custom(http.StatusServiceUnavailable, "error", "Service under maintenance", map[string]interface{}{
+				"retry_after": MaintenanceRetryAfter,
+			})
+		}
+	}
+}
+
+// pathAllowed reports whether path is in the allowlist
+func pathAllowed(allowlist []string, path string) bool {
+	for _, allowed := range allowlist {
+		if path == allowed || (strings.HasSuffix(allowed, "/") && strings.HasPrefix(path, allowed)) {
+			return true
+		}
+	}
+	return false
+}
+
+// ===== Coalescing Middleware =====
+
+// coalescedResponse is a handler response recorded for replay
+type coalescedResponse struct {
+	status int
+	header http.Header
+	body   []byte
+}
+
+// coalescedCall is a handler call shared by identical requests. done is closed
+// when the call finished; response stays nil when the handler panicked.
+type coalescedCall struct {
+	done     chan struct{}
+	response *coalescedResponse
+}
+
+// responseRecorder captures a handler response so it can be replayed
+type responseRecorder struct {
+	response coalescedResponse
+}
+
+func (rr *responseRecorder) Header() http.Header { return rr.response.header }
+
+func (rr *responseRecorder) WriteHeader(status int) {
+	if rr.response.status == 0 {
+		rr.response.status = status
+	}
+}
+
+func (rr *responseRecorder) Write(b []byte) (int, error) {
+	rr.WriteHeader(http.StatusOK)
+	rr.response.body = append(rr.response.body, b...)
+	return len(b), nil
+}
+
+// CoalesceMiddleware runs concurrent identical GET requests through the handler
+// once and replays the result to all of them. Responses below 500 are then served
+// from memory for ttl; a ttl of zero only coalesces requests in flight. Requests
+// are identical when method, URL and the headers that select the representation
+// match. Only apply it to routes where data up to ttl old is acceptable.
+func CoalesceMiddleware(ttl time.Duration) func(http.HandlerFunc) http.HandlerFunc {
+	var mu sync.Mutex
+	calls := map[string]*coalescedCall{} // Requests in flight
+
+	// Finished responses kept for ttl
+	var responses *cache.Cache[string, *coalescedResponse]
+	if ttl > 0 {
+		responses = cache.New[string, *coalescedResponse]("coalesce", ttl)
+	}
+
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet && r.Method != http.MethodHead {
+				next(w, r)
+				return
+			}
+
+			key := strings.Join([]string{
+				r.Method, r.URL.RequestURI(),
+				r.Header.Get("Accept"), r.Header.Get("Accept-Language"), r.Header.Get("If-None-Match"),
+			}, "\n")
+
+			if responses != nil {
+				if response, ok := responses.Get(key); ok {
+					replayResponse(w, response)
+					return
+				}
+			}
+
+			mu.Lock()
+			if call, ok := calls[key]; ok {
+				mu.Unlock()
+				<-call.done
+				if call.response == nil {
+					next(w, r) // The shared call panicked
+					return
+				}
+				replayResponse(w, call.response)
+				return
+			}
+			call := &coalescedCall{done: make(chan struct{})}
+			calls[key] = call
+			mu.Unlock()
+
+			defer func() {
+				close(call.done)
+				// Cache before forgetting the call so no request runs the handler in between
+				if responses != nil && call.response != nil && call.response.status < 500 {
+					responses.Set(key, call.response)
+				}
+
+				mu.Lock()
+				if calls[key] == call {
+					delete(calls, key)
+				}
+				mu.Unlock()
+			}()
+
+			recorder := &responseRecorder{response: coalescedResponse{header: http.Header{}}}
+			next(recorder, r)
+			if recorder.response.status == 0 {
+				recorder.response.status = http.StatusOK
+			}
+			call.response = &recorder.response
+
+			replayResponse(w, call.response)
+		}
+	}
+}
+
+// replayResponse writes a recorded response to w
+func replayResponse(w http.ResponseWriter, response *coalescedResponse) {
+	for name, values := range response.header {
+		w.Header()[name] = append([]string(nil), values...)
+	}
+	w.WriteHeader(response.status)
+	w.Write(response.body)
+}
+
 // ===== CORS Middleware =====
 
 // CORSConfig holds CORS configuration
@@ -263,28 +633,33 @@ func CORSMiddleware(config *CORSConfig) func(http.HandlerFunc) http.HandlerFunc
 	return func(next http.HandlerFunc) http.HandlerFunc {
 		return func(w http.ResponseWriter, r *http.Request) {
 			// Set CORS headers
-			if len(config.AllowedOrigins) > 0 {
-				origin := r.Header.Get("Origin")
-				if origin != "" {
-					allowed := false
-					for _, allowedOrigin := range config.AllowedOrigins {
-						if allowedOrigin == "*" || allowedOrigin == origin {
-							w.Header().Set("Access-Control-Allow-Origin", origin)
-							allowed = true
-							break
-						}
-					}
-					if !allowed {
-						w.Header().Set("Access-Control-Allow-Origin", config.AllowedOrigins[0])
-					}
+			wildcardOnly := len(config.AllowedOrigins) == 1 && config.AllowedOrigins[0] == "*"
+			if !wildcardOnly || config.AllowCredentials {
+				// The response depends on the request origin
+				w.Header().Add("Vary", "Origin")
+			}
+
+			if origin := r.Header.Get("Origin"); origin != "" {
+				switch {
+				case wildcardOnly && !config.AllowCredentials:
+					w.Header().Set("Access-Control-Allow-Origin", "*")
+				case originAllowed(config.AllowedOrigins, origin):
+					w.Header().Set("Access-Control-Allow-Origin", origin)
 				}
+				// Disallowed origins get no Access-Control-Allow-Origin header
 			}
 
 			if len(config.AllowedMethods) > 0 {
 				w.Header().Set("Access-Control-Allow-Methods", strings.Join(config.AllowedMethods, ", "))
 			}
 
-			if len(config.AllowedHeaders) > 0 {
+			if containsString(config.AllowedHeaders, "*") {
+				// Reflect whatever the preflight asks for
+				if requested := r.Header.Get("Access-Control-Request-Headers"); requested != "" {
+					w.Header().Set("Access-Control-Allow-Headers", requested)
+					w.Header().Add("Vary", "Access-Control-Request-Headers")
+				}
+			} else if len(config.AllowedHeaders) > 0 {
 				w.Header().Set("Access-Control-Allow-Headers", strings.Join(config.AllowedHeaders, ", "))
 			}
 
@@ -306,3 +681,50 @@ func CORSMiddleware(config *CORSConfig) func(http.HandlerFunc) http.HandlerFunc
 		}
 	}
 }
+
+// originAllowed reports whether origin matches one of the allowed origins.
+// Patterns may be "*", an exact origin, or a wildcard subdomain such as
+// "*.example.com" or "https://*.example.com".
+func originAllowed(allowedOrigins []string, origin string) bool {
+	for _, allowed := range allowedOrigins {
+		if allowed == "*" || allowed == origin {
+			return true
+		}
+
+		wildcard := strings.Index(allowed, "*.")
+		if wildcard == -1 {
+			continue
+		}
+
+		// Patterns without a scheme match any scheme
+		candidate := origin
+		prefix, suffix := allowed[:wildcard], allowed[wildcard+1:]
+		if !strings.Contains(prefix, "://") {
+			if idx := strings.Index(candidate, "://"); idx != -1 {
+				candidate = candidate[idx+3:]
+			}
+		}
+
+		if len(candidate) < len(prefix)+len(suffix) ||
+			!strings.HasPrefix(candidate, prefix) || !strings.HasSuffix(candidate, suffix) {
+			continue
+		}
+
+		// The wildcard must match a non-empty subdomain
+		subdomain := candidate[len(prefix) : len(candidate)-len(suffix)]
+		if subdomain != "" && !strings.ContainsAny(subdomain, "/:") {
+			return true
+		}
+	}
+	return false
+}
+
+// containsString reports whether values contains value
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
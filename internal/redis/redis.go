@@ -0,0 +1,77 @@
+// Package redis holds the process-wide Redis connection. It is optional: without
+// REDIS_URL nothing connects and Client stays nil, so callers check it before use.
+package redis
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	goredis "github.com/go-redis/redis/v8"
+
+	"github.com/thenasky/go-framework/internal/logger"
+)
+
+// Client is the shared, pooled Redis client, nil while not connected
+var Client *goredis.Client
+
+// ConnectRedis connects to Redis if REDIS_URL is present. Pool settings are
+// taken from the URL, e.g. redis://localhost:6379/0?pool_size=20.
+func ConnectRedis() {
+	url := os.Getenv("REDIS_URL")
+	if url == "" {
+		return
+	}
+
+	opts, err := goredis.ParseURL(url)
+	if err != nil {
+		logger.LogError("Invalid REDIS_URL: " + err.Error())
+		return
+	}
+
+	client := goredis.NewClient(opts)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := client.Ping(ctx).Err(); err != nil {
+		logger.LogError("Failed to connect to Redis: " + err.Error())
+		client.Close()
+		return
+	}
+
+	Client = client
+
+	// The client fills in the pool defaults for settings missing from the URL
+	logger.LogRedis(fmt.Sprintf("Successfully connected to Redis at %s (db %d, pool size %d)", opts.Addr, opts.DB, client.Options().PoolSize))
+}
+
+// DisconnectRedis closes the shared client if connected
+func DisconnectRedis() {
+	if Client == nil {
+		return
+	}
+
+	if err := Client.Close(); err != nil {
+		logger.LogError("Error disconnecting from Redis: " + err.Error())
+	} else {
+		logger.LogRedis("Disconnected from Redis")
+	}
+	Client = nil
+}
+
+// HealthCheck pings Redis with a short timeout and returns an error when it is unreachable
+func HealthCheck(ctx context.Context) error {
+	if Client == nil {
+		return fmt.Errorf("Redis not connected")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	if err := Client.Ping(ctx).Err(); err != nil {
+		return fmt.Errorf("Redis ping failed: %w", err)
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package router
 
 import (
 	"net/http"
+	"strings"
 
 	"github.com/gorilla/mux"
 )
@@ -9,6 +10,26 @@ import (
 // RouterBuilder provides a clean fluent API for building routes
 type RouterBuilder struct {
 	subrouter *mux.Router
+
+	// allowed tracks the methods registered per path, used to answer OPTIONS
+	allowed map[string][]string
+	// options holds handlers registered explicitly with Options
+	options map[string]HandlerFunc
+	// optionsRoutes holds the OPTIONS route registered for each path
+	optionsRoutes map[string]*mux.Route
+	// errorHandler answers errors returned by HandlerFuncE routes
+	errorHandler ErrorHandler
+}
+
+// RouteHook is notified of every route registered through a RouterBuilder
+type RouteHook func(method, pathTemplate string)
+
+// routeHook is the currently installed route hook (nil when none)
+var routeHook RouteHook
+
+// SetRouteHook installs a hook that is called for every registered route
+func SetRouteHook(hook RouteHook) {
+	routeHook = hook
 }
 
 // HandlerFunc represents the JavaScript-like handler signature
@@ -16,47 +37,175 @@ type HandlerFunc func(req *Request, res *Response)
 
 // Router creates a new router with the given prefix
 func Router(mainRouter *mux.Router, prefix string) *RouterBuilder {
-	subrouter := mainRouter.PathPrefix(prefix).Subrouter()
+	return newRouterBuilder(mainRouter.PathPrefix(prefix).Subrouter(), DefaultErrorHandler)
+}
+
+// Group creates a child router nested under prefix. Middleware registered on
+// the parent with Use also applies to the child's routes, and the child starts
+// with the parent's error handler.
+func (r *RouterBuilder) Group(prefix string) *RouterBuilder {
+	return newRouterBuilder(r.subrouter.PathPrefix(prefix).Subrouter(), r.errorHandler)
+}
+
+// OnError sets the handler that turns errors returned by HandlerFuncE routes
+// on this router into responses (default DefaultErrorHandler)
+func (r *RouterBuilder) OnError(handler ErrorHandler) *RouterBuilder {
+	r.errorHandler = handler
+	return r
+}
+
+// Use adds middleware that runs for every route on this router and its groups
+func (r *RouterBuilder) Use(middlewares ...func(http.HandlerFunc) http.HandlerFunc) *RouterBuilder {
+	for _, mw := range middlewares {
+		mw := mw
+		r.subrouter.Use(func(next http.Handler) http.Handler {
+			return mw(next.ServeHTTP)
+		})
+	}
+	return r
+}
+
+// newRouterBuilder wraps a mux subrouter in a RouterBuilder
+func newRouterBuilder(subrouter *mux.Router, errorHandler ErrorHandler) *RouterBuilder {
 	return &RouterBuilder{
-		subrouter: subrouter,
+		subrouter:     subrouter,
+		allowed:       make(map[string][]string),
+		options:       make(map[string]HandlerFunc),
+		optionsRoutes: make(map[string]*mux.Route),
+		errorHandler:  errorHandler,
 	}
 }
 
 // Get adds a GET route
 func (r *RouterBuilder) Get(path string, handler HandlerFunc) *RouterBuilder {
-	r.subrouter.HandleFunc(path, r.wrapHandler(handler)).Methods("GET")
-	return r
+	return r.handle("GET", path, handler)
 }
 
 // Post adds a POST route
 func (r *RouterBuilder) Post(path string, handler HandlerFunc) *RouterBuilder {
-	r.subrouter.HandleFunc(path, r.wrapHandler(handler)).Methods("POST")
-	return r
+	return r.handle("POST", path, handler)
 }
 
 // Put adds a PUT route
 func (r *RouterBuilder) Put(path string, handler HandlerFunc) *RouterBuilder {
-	r.subrouter.HandleFunc(path, r.wrapHandler(handler)).Methods("PUT")
-	return r
+	return r.handle("PUT", path, handler)
 }
 
 // Delete adds a DELETE route
 func (r *RouterBuilder) Delete(path string, handler HandlerFunc) *RouterBuilder {
-	r.subrouter.HandleFunc(path, r.wrapHandler(handler)).Methods("DELETE")
-	return r
+	return r.handle("DELETE", path, handler)
 }
 
 // Patch adds a PATCH route
 func (r *RouterBuilder) Patch(path string, handler HandlerFunc) *RouterBuilder {
-	r.subrouter.HandleFunc(path, r.wrapHandler(handler)).Methods("PATCH")
+	return r.handle("PATCH", path, handler)
+}
+
+// Head adds a HEAD route
+func (r *RouterBuilder) Head(path string, handler HandlerFunc) *RouterBuilder {
+	return r.handle("HEAD", path, handler)
+}
+
+// GetE adds a GET route whose handler returns its error
+func (r *RouterBuilder) GetE(path string, handler HandlerFuncE) *RouterBuilder {
+	return r.handle("GET", path, r.handleErrors(handler))
+}
+
+// PostE adds a POST route whose handler returns its error
+func (r *RouterBuilder) PostE(path string, handler HandlerFuncE) *RouterBuilder {
+	return r.handle("POST", path, r.handleErrors(handler))
+}
+
+// PutE adds a PUT route whose handler returns its error
+func (r *RouterBuilder) PutE(path string, handler HandlerFuncE) *RouterBuilder {
+	return r.handle("PUT", path, r.handleErrors(handler))
+}
+
+// DeleteE adds a DELETE route whose handler returns its error
+func (r *RouterBuilder) DeleteE(path string, handler HandlerFuncE) *RouterBuilder {
+	return r.handle("DELETE", path, r.handleErrors(handler))
+}
+
+// PatchE adds a PATCH route whose handler returns its error
+func (r *RouterBuilder) PatchE(path string, handler HandlerFuncE) *RouterBuilder {
+	return r.handle("PATCH", path, r.handleErrors(handler))
+}
+
+// Options adds an OPTIONS route, replacing the automatic allowed-methods response
+func (r *RouterBuilder) Options(path string, handler HandlerFunc) *RouterBuilder {
+	r.ensureOptionsRoute(path)
+	r.options[path] = handler
+	reportRoute("OPTIONS", r.optionsRoutes[path])
+	return r
+}
+
+// AllowedMethods returns the methods registered for path on this builder
+func (r *RouterBuilder) AllowedMethods(path string) []string {
+	methods := append([]string(nil), r.allowed[path]...)
+	return append(methods, "OPTIONS")
+}
+
+// handle registers a route for the given method and reports it to the route hook
+func (r *RouterBuilder) handle(method, path string, handler HandlerFunc) *RouterBuilder {
+	r.ensureOptionsRoute(path)
+	r.allowed[path] = append(r.allowed[path], method)
+
+	route := r.subrouter.HandleFunc(path, r.wrapHandler(handler)).Methods(method)
+	reportRoute(method, route)
+
 	return r
 }
 
+// ensureOptionsRoute registers the OPTIONS route for path the first time it is seen.
+// It answers with the methods registered for the path unless an explicit handler exists.
+func (r *RouterBuilder) ensureOptionsRoute(path string) {
+	if _, seen := r.allowed[path]; seen {
+		return
+	}
+	r.allowed[path] = nil
+
+	r.optionsRoutes[path] = r.subrouter.HandleFunc(path, func(w http.ResponseWriter, httpReq *http.Request) {
+		w.Header().Set("Allow", strings.Join(r.AllowedMethods(path), ", "))
+
+		if handler, ok := r.options[path]; ok {
+			handler(NewRequest(httpReq), NewResponse(w).WithRequest(httpReq))
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}).Methods("OPTIONS")
+}
+
+// reportRoute notifies the route hook of a registered route
+func reportRoute(method string, route *mux.Route) {
+	if routeHook == nil {
+		return
+	}
+
+	if template, err := route.GetPathTemplate(); err == nil {
+		routeHook(method, template)
+	}
+}
+
+// handleErrors converts a HandlerFuncE to a HandlerFunc that passes returned
+// errors to the router's error handler, looked up per request so OnError also
+// applies to routes registered before it
+func (r *RouterBuilder) handleErrors(handler HandlerFuncE) HandlerFunc {
+	return func(req *Request, res *Response) {
+		if err := handler(req, res); err != nil {
+			errorHandler := r.errorHandler
+			if errorHandler == nil {
+				errorHandler = DefaultErrorHandler
+			}
+			errorHandler(err, req, res)
+		}
+	}
+}
+
 // wrapHandler converts HandlerFunc to http.HandlerFunc
 func (r *RouterBuilder) wrapHandler(handler HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, httpReq *http.Request) {
 		req := NewRequest(httpReq)
-		res := NewResponse(w)
+		res := NewResponse(w).WithRequest(httpReq)
 		handler(req, res)
 	}
 }
@@ -0,0 +1,93 @@
+package router_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+
+	"github.com/thenasky/go-framework/internal/router"
+)
+
+// tag returns middleware that records that it ran in a response header
+func tag(name string) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("X-Middleware", name)
+			next(w, r)
+		}
+	}
+}
+
+func ok(req *router.Req, res *router.Res) {
+	res.Success("OK", nil)
+}
+
+func TestGroupNestsPrefixAndMiddleware(t *testing.T) {
+	var registered []string
+	router.SetRouteHook(func(method, pathTemplate string) {
+		registered = append(registered, method+" "+pathTemplate)
+	})
+	defer router.SetRouteHook(nil)
+
+	m := mux.NewRouter()
+	api := router.Router(m, "/api").Use(tag("api"))
+	api.Get("/ping", ok)
+	api.Group("/v1").Use(tag("v1")).Get("/items", ok)
+
+	tests := []struct {
+		path       string
+		status     int
+		middleware []string
+	}{
+		{"/api/v1/items", http.StatusOK, []string{"api", "v1"}},
+		{"/api/ping", http.StatusOK, []string{"api"}},
+		{"/v1/items", http.StatusNotFound, nil},
+		{"/api/items", http.StatusNotFound, nil},
+	}
+	for _, tt := range tests {
+		recorder := httptest.NewRecorder()
+		m.ServeHTTP(recorder, httptest.NewRequest("GET", tt.path, nil))
+
+		if recorder.Code != tt.status {
+			t.Errorf("GET %s: status %d, want %d", tt.path, recorder.Code, tt.status)
+		}
+		got := recorder.Header().Values("X-Middleware")
+		if len(got) != len(tt.middleware) {
+			t.Errorf("GET %s: middleware %v, want %v", tt.path, got, tt.middleware)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.middleware[i] {
+				t.Errorf("GET %s: middleware %v, want %v", tt.path, got, tt.middleware)
+				break
+			}
+		}
+	}
+
+	want := map[string]bool{"GET /api/ping": true, "GET /api/v1/items": true}
+	for _, route := range registered {
+		delete(want, route)
+	}
+	if len(want) > 0 {
+		t.Errorf("routes %v not reported with their full path; reported %v", want, registered)
+	}
+}
+
+func TestGroupInheritsErrorHandler(t *testing.T) {
+	m := mux.NewRouter()
+	api := router.Router(m, "/api").OnError(func(err error, req *router.Req, res *router.Res) {
+		res.Custom(http.StatusTeapot, "error", err.Error(), nil)
+	})
+	api.Group("/v1").GetE("/fail", func(req *router.Req, res *router.Res) error {
+		return errors.New("handled by the parent")
+	})
+
+	recorder := httptest.NewRecorder()
+	m.ServeHTTP(recorder, httptest.NewRequest("GET", "/api/v1/fail", nil))
+	if recorder.Code != http.StatusTeapot {
+		t.Errorf("status %d, want the parent's error handler (%d)", recorder.Code, http.StatusTeapot)
+	}
+}
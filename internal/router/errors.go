@@ -0,0 +1,66 @@
+package router
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/thenasky/go-framework/internal/logger"
+)
+
+// HandlerFuncE is a handler that returns its error instead of writing the
+// error response itself; the router's ErrorHandler turns it into a response
+type HandlerFuncE func(req *Request, res *Response) error
+
+// ErrorHandler writes the response for an error returned by a HandlerFuncE
+type ErrorHandler func(err error, req *Request, res *Response)
+
+// HTTPError is an error that carries the response it should produce
+type HTTPError struct {
+	StatusCode int
+	Type       ErrorType
+	Code       string
+	Message    string
+	Details    interface{}
+}
+
+func (e *HTTPError) Error() string { return e.Message }
+
+// NewHTTPError creates an error answered with statusCode
+func NewHTTPError(statusCode int, errorType ErrorType, code, message string) *HTTPError {
+	return &HTTPError{StatusCode: statusCode, Type: errorType, Code: code, Message: message}
+}
+
+// NewBadRequestError creates an error answered with 400 Bad Request
+func NewBadRequestError(message string) *HTTPError {
+	return NewHTTPError(http.StatusBadRequest, ErrorTypeValidation, "BAD_REQUEST", message)
+}
+
+// NewNotFoundError creates an error answered with 404 Not Found
+func NewNotFoundError(message string) *HTTPError {
+	return NewHTTPError(http.StatusNotFound, ErrorTypeNotFound, "NOT_FOUND", message)
+}
+
+// NewConflictError creates an error answered with 409 Conflict
+func NewConflictError(message string) *HTTPError {
+	return NewHTTPError(http.StatusConflict, ErrorTypeConflict, "CONFLICT", message)
+}
+
+// WithDetails sets details included in the error response
+func (e *HTTPError) WithDetails(details interface{}) *HTTPError {
+	e.Details = details
+	return e
+}
+
+// DefaultErrorHandler answers an HTTPError anywhere in err's chain with its own
+// status and code, and any other error with a generic 500 that hides the cause
+func DefaultErrorHandler(err error, req *Request, res *Response) {
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		res.ErrorWithCode(httpErr.StatusCode, httpErr.Type, httpErr.Code, httpErr.Message, httpErr.Details)
+		return
+	}
+
+	logger.FromContext(req.Context()).Error(fmt.Sprintf("Unhandled error in %s %s: %v", req.Method, req.URL.Path, err))
+	res.Error("Internal server error", nil)
+}
@@ -0,0 +1,151 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultLanguage is the language response messages are written in. It needs no
+// catalog: the English messages themselves are the catalog keys.
+const DefaultLanguage = "en"
+
+var (
+	catalogs   = make(map[string]map[string]string)
+	catalogsMu sync.RWMutex
+)
+
+// RegisterMessages adds translations for lang, keyed by the English message.
+// Messages may contain fmt verbs, filled in by Response.T.
+func RegisterMessages(lang string, messages map[string]string) {
+	catalogsMu.Lock()
+	defer catalogsMu.Unlock()
+
+	lang = strings.ToLower(lang)
+	if catalogs[lang] == nil {
+		catalogs[lang] = make(map[string]string, len(messages))
+	}
+	for key, message := range messages {
+		catalogs[lang][key] = message
+	}
+}
+
+// Translate returns message in lang, or message itself when it has no translation
+func Translate(lang, message string) string {
+	catalogsMu.RLock()
+	defer catalogsMu.RUnlock()
+
+	if translated, ok := catalogs[lang][message]; ok {
+		return translated
+	}
+	return message
+}
+
+// hasTranslations reports whether any catalog is registered
+func hasTranslations() bool {
+	catalogsMu.RLock()
+	defer catalogsMu.RUnlock()
+	return len(catalogs) > 0
+}
+
+// supportedLanguage maps a language tag to a registered language, trying the
+// primary subtag ("es-AR" -> "es") when the full tag has no catalog
+func supportedLanguage(tag string) string {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	primary, _, _ := strings.Cut(tag, "-")
+
+	catalogsMu.RLock()
+	defer catalogsMu.RUnlock()
+
+	for _, candidate := range []string{tag, primary} {
+		if candidate == DefaultLanguage || catalogs[candidate] != nil {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// preferredLanguage picks the supported language ranked highest in the
+// Accept-Language header, falling back to DefaultLanguage
+func preferredLanguage(r *http.Request) string {
+	best, bestQuality := DefaultLanguage, 0.0
+
+	for _, part := range strings.Split(r.Header.Get("Accept-Language"), ",") {
+		tag, params, _ := strings.Cut(part, ";")
+
+		quality := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			quality = parsed
+		}
+
+		if lang := supportedLanguage(tag); lang != "" && quality > bestQuality {
+			best, bestQuality = lang, quality
+		}
+	}
+
+	return best
+}
+
+// PreferredLanguage returns the supported language the client prefers, from Accept-Language
+func (req *Request) PreferredLanguage() string {
+	return preferredLanguage(req.Request)
+}
+
+// T translates message into the language the request prefers, formatting it with args if given
+func (res *Response) T(message string, args ...interface{}) string {
+	lang := DefaultLanguage
+	if res.request != nil {
+		lang = preferredLanguage(res.request)
+	}
+
+	translated := Translate(lang, message)
+	if len(args) > 0 {
+		return fmt.Sprintf(translated, args...)
+	}
+	return translated
+}
+
+// localize translates the messages of a response into the language the request prefers
+func (res *Response) localize(response *StandardResponse) {
+	if res.request == nil || !hasTranslations() {
+		return
+	}
+	res.writer.Header().Add("Vary", "Accept-Language")
+
+	lang := preferredLanguage(res.request)
+	if lang == DefaultLanguage {
+		return
+	}
+
+	response.Message = Translate(lang, response.Message)
+	if response.Error == nil {
+		return
+	}
+
+	// Copy before translating so the caller's error and validation slice are untouched
+	apiError := *response.Error
+	apiError.Message = Translate(lang, apiError.Message)
+	if len(apiError.Validation) > 0 {
+		validation := make([]ValidationError, len(apiError.Validation))
+		for i, validationErr := range apiError.Validation {
+			validationErr.Message = Translate(lang, validationErr.Message)
+			validation[i] = validationErr
+		}
+		apiError.Validation = validation
+	}
+	response.Error = &apiError
+}
+
+func init() {
+	RegisterMessages("es", map[string]string{
+		"Success":                   "Éxito",
+		"Invalid JSONP callback":    "Callback JSONP inválido",
+		"Failed to encode response": "No se pudo codificar la respuesta",
+	})
+}
@@ -0,0 +1,74 @@
+package router_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/thenasky/go-framework/internal/router"
+	"github.com/thenasky/go-framework/internal/router/testutil"
+)
+
+func init() {
+	router.RegisterMessages("fr", map[string]string{
+		"Item created":     "Élément créé",
+		"Name is required": "Le nom est obligatoire",
+		"%d items left":    "%d éléments restants",
+	})
+}
+
+func TestResponsesFollowAcceptLanguage(t *testing.T) {
+	created := func(req *router.Req, res *router.Res) {
+		res.Created("Item created", nil)
+	}
+
+	tests := []struct {
+		acceptLanguage string
+		want           string
+	}{
+		{"", "Item created"},
+		{"fr", "Élément créé"},
+		{"fr-CA,fr;q=0.9", "Élément créé"},
+		{"de-DE,en;q=0.8,fr;q=0.5", "Item created"},
+		{"de-DE,fr;q=0.5", "Élément créé"},
+		{"en;q=0.2,fr;q=0.7", "Élément créé"},
+	}
+
+	for _, tt := range tests {
+		ctx := testutil.NewTestContext("POST", "/items", nil)
+		if tt.acceptLanguage != "" {
+			ctx.WithHeader("Accept-Language", tt.acceptLanguage)
+		}
+		ctx.Run(created)
+		ctx.AssertStatus(t, http.StatusCreated)
+		ctx.AssertEnvelope(t, "success", tt.want)
+
+		if vary := ctx.Recorder.Header().Get("Vary"); vary != "Accept-Language" {
+			t.Errorf("Accept-Language %q: Vary = %q, want Accept-Language", tt.acceptLanguage, vary)
+		}
+	}
+}
+
+func TestValidationMessagesAreTranslated(t *testing.T) {
+	ctx := testutil.NewTestContext("POST", "/items", nil).WithHeader("Accept-Language", "fr")
+	ctx.Run(func(req *router.Req, res *router.Res) {
+		res.ValidationErrorSingle("name", "Name is required")
+	})
+
+	envelope, err := ctx.Envelope()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if envelope.Error == nil || len(envelope.Error.Validation) != 1 {
+		t.Fatalf("no validation error in %s", ctx.Recorder.Body.String())
+	}
+	if got := envelope.Error.Validation[0].Message; got != "Le nom est obligatoire" {
+		t.Errorf("validation message = %q, want %q", got, "Le nom est obligatoire")
+	}
+}
+
+func TestTFormatsTranslatedMessages(t *testing.T) {
+	ctx := testutil.NewTestContext("GET", "/items", nil).WithHeader("Accept-Language", "fr")
+	if got := ctx.Res.T("%d items left", 3); got != "3 éléments restants" {
+		t.Errorf("T = %q, want %q", got, "3 éléments restants")
+	}
+}
@@ -20,6 +20,17 @@ type Request struct {
 	Query url.Values        // Query parameters
 }
 
+// AllowedMethodsLookup returns the methods registered for a concrete request path
+type AllowedMethodsLookup func(path string) []string
+
+// allowedMethodsLookup is the installed lookup (nil when none)
+var allowedMethodsLookup AllowedMethodsLookup
+
+// SetAllowedMethodsLookup installs the lookup used by Request.AllowedMethods
+func SetAllowedMethodsLookup(lookup AllowedMethodsLookup) {
+	allowedMethodsLookup = lookup
+}
+
 // NewRequest creates a new request wrapper
 func NewRequest(r *http.Request) *Request {
 	return &Request{
@@ -39,6 +50,15 @@ func (req *Request) Param(name string) string {
 	return req.Vars[name]
 }
 
+// AllowedMethods returns the methods registered for the request's path, for the
+// Allow header of a 405 response: res.MethodNotAllowed(message, req.AllowedMethods())
+func (req *Request) AllowedMethods() []string {
+	if allowedMethodsLookup == nil {
+		return nil
+	}
+	return allowedMethodsLookup(req.URL.Path)
+}
+
 // QueryParam gets a query parameter by name
 func (req *Request) QueryParam(name string) string {
 	return req.Query.Get(name)
@@ -74,6 +94,11 @@ func (req *Request) QueryBool(name string, defaultValue bool) bool {
 	return boolValue
 }
 
+// IfNoneMatch returns the If-None-Match header sent for conditional requests
+func (req *Request) IfNoneMatch() string {
+	return req.Header.Get("If-None-Match")
+}
+
 // GetHeader gets a request header by name (alias for easier access)
 func (req *Request) GetHeader(name string) string {
 	return req.Header.Get(name)
@@ -1,12 +1,24 @@
 package router
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"encoding/xml"
 	"fmt"
 	"net/http"
+	"regexp"
 	"strings"
+	"time"
 )
 
+// jsonpCallbackPattern matches safe JSONP callback names such as "cb" or "app.handlers.onData"
+var jsonpCallbackPattern = regexp.MustCompile(`^[a-zA-Z_$][a-zA-Z0-9_$]*(\.[a-zA-Z_$][a-zA-Z0-9_$]*)*$`)
+
+// maxJSONPCallbackLength bounds the callback name accepted by JSONP
+const maxJSONPCallbackLength = 128
+
 // ErrorType represents the type of error that occurred
 type ErrorType string
 
@@ -23,32 +35,34 @@ const (
 
 // ValidationError represents a field validation error
 type ValidationError struct {
-	Field   string `json:"field"`
-	Message string `json:"message"`
-	Value   string `json:"value,omitempty"`
+	Field   string `json:"field" xml:"field"`
+	Message string `json:"message" xml:"message"`
+	Value   string `json:"value,omitempty" xml:"value,omitempty"`
 }
 
 // APIError represents a detailed API error
 type APIError struct {
-	Type       ErrorType         `json:"type"`
-	Code       string            `json:"code"`
-	Message    string            `json:"message"`
-	Details    interface{}       `json:"details,omitempty"`
-	Validation []ValidationError `json:"validation,omitempty"`
-	InternalID string            `json:"internal_id,omitempty"` // For debugging/tracking
+	Type       ErrorType         `json:"type" xml:"type"`
+	Code       string            `json:"code" xml:"code"`
+	Message    string            `json:"message" xml:"message"`
+	Details    interface{}       `json:"details,omitempty" xml:"details,omitempty"`
+	Validation []ValidationError `json:"validation,omitempty" xml:"validation>error,omitempty"`
+	InternalID string            `json:"internal_id,omitempty" xml:"internal_id,omitempty"` // For debugging/tracking
 }
 
 // StandardResponse represents the standardized API response structure
 type StandardResponse struct {
-	Status  string      `json:"status"`
-	Message string      `json:"message"`
-	Payload interface{} `json:"payload,omitempty"`
-	Error   *APIError   `json:"error,omitempty"`
+	XMLName xml.Name    `json:"-" xml:"response"`
+	Status  string      `json:"status" xml:"status"`
+	Message string      `json:"message" xml:"message"`
+	Payload interface{} `json:"payload,omitempty" xml:"payload,omitempty"`
+	Error   *APIError   `json:"error,omitempty" xml:"error,omitempty"`
 }
 
 // Response provides methods for building standardized responses (like Express.js res)
 type Response struct {
-	writer http.ResponseWriter
+	writer  http.ResponseWriter
+	request *http.Request
 }
 
 // NewResponse creates a new response wrapper
@@ -56,6 +70,12 @@ func NewResponse(w http.ResponseWriter) *Response {
 	return &Response{writer: w}
 }
 
+// WithRequest attaches the request being answered so conditional responses can read its headers
+func (res *Response) WithRequest(r *http.Request) *Response {
+	res.request = r
+	return res
+}
+
 // Success sends a successful response (200)
 func (res *Response) Success(message string, payload interface{}) {
 	res.sendResponse(http.StatusOK, "success", message, payload, nil)
@@ -96,6 +116,48 @@ func (res *Response) Custom(statusCode int, status, message string, payload inte
 	res.sendResponse(statusCode, status, message, payload, nil)
 }
 
+// SendSuccess sends a successful response (200) with a payload whose type is checked at compile time
+func SendSuccess[T any](res *Response, message string, payload T) {
+	res.sendResponse(http.StatusOK, "success", message, payload, nil)
+}
+
+// SendCreated sends a created response (201) with a payload whose type is checked at compile time
+func SendCreated[T any](res *Response, message string, payload T) {
+	res.sendResponse(http.StatusCreated, "success", message, payload, nil)
+}
+
+// Pagination describes the position of a page within a result set
+type Pagination struct {
+	Page       int   `json:"page"`
+	PageSize   int   `json:"page_size"`
+	Total      int64 `json:"total"`
+	TotalPages int64 `json:"total_pages"`
+}
+
+// PaginatedPayload is the payload sent by Paginated
+type PaginatedPayload struct {
+	Items      interface{} `json:"items"`
+	Pagination Pagination  `json:"pagination"`
+}
+
+// Paginated sends a successful response (200) with a page of items and pagination metadata
+func (res *Response) Paginated(message string, items interface{}, page, pageSize int, total int64) {
+	var totalPages int64
+	if pageSize > 0 {
+		totalPages = (total + int64(pageSize) - 1) / int64(pageSize)
+	}
+
+	res.sendResponse(http.StatusOK, "success", message, PaginatedPayload{
+		Items: items,
+		Pagination: Pagination{
+			Page:       page,
+			PageSize:   pageSize,
+			Total:      total,
+			TotalPages: totalPages,
+		},
+	}, nil)
+}
+
 // ===== Enhanced Error Handling Methods =====
 
 // ValidationError sends a validation error response (422)
@@ -199,12 +261,58 @@ func (res *Response) UnprocessableEntity(message string, details interface{}) {
 
 // MethodNotAllowed sends a method not allowed error (405)
 func (res *Response) MethodNotAllowed(message string, allowedMethods []string) {
+	res.writer.Header().Set("Allow", strings.Join(allowedMethods, ", "))
 	details := map[string]interface{}{
 		"allowed_methods": allowedMethods,
 	}
 	res.ErrorWithCode(http.StatusMethodNotAllowed, ErrorTypeValidation, "METHOD_NOT_ALLOWED", message, details)
 }
 
+// ===== Streaming =====
+
+// SSE streams values from ch as Server-Sent Events until ch is closed or ctx is cancelled.
+// Each value is JSON-encoded into a "data:" frame and flushed immediately.
+func (res *Response) SSE(ctx context.Context, ch <-chan interface{}) {
+	controller := http.NewResponseController(res.writer)
+
+	headers := res.writer.Header()
+	headers.Set("Content-Type", "text/event-stream")
+	headers.Set("Cache-Control", "no-cache")
+	headers.Set("Connection", "keep-alive")
+	headers.Set("X-Accel-Buffering", "no") // Disable proxy buffering (nginx)
+
+	// Streams outlive the server's write timeout
+	controller.SetWriteDeadline(time.Time{})
+
+	res.writer.WriteHeader(http.StatusOK)
+	if err := controller.Flush(); err != nil {
+		return
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-ch:
+			if !ok {
+				return
+			}
+
+			data, err := json.Marshal(event)
+			if err != nil {
+				continue
+			}
+
+			if _, err := fmt.Fprintf(res.writer, "data: %s\n\n", data); err != nil {
+				return
+			}
+			if err := controller.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
 // ===== Utility Methods =====
 
 // AddHeader adds a custom header to the response
@@ -217,6 +325,97 @@ func (res *Response) SetContentType(contentType string) {
 	res.writer.Header().Set("Content-Type", contentType)
 }
 
+// JSONP sends a successful response (200) wrapped in a call to callback for legacy
+// cross-origin clients. Callback names that aren't plain JavaScript identifiers
+// (optionally dotted) are rejected with 400 to prevent script injection.
+func (res *Response) JSONP(callback string, payload interface{}) {
+	if len(callback) > maxJSONPCallbackLength || !jsonpCallbackPattern.MatchString(callback) {
+		res.BadRequest("Invalid JSONP callback", map[string]string{"callback": callback})
+		return
+	}
+
+	response := StandardResponse{
+		Status:  "success",
+		Message: "Success",
+		Payload: payload,
+	}
+	res.localize(&response)
+
+	body, err := json.Marshal(response)
+	if err != nil {
+		res.Error("Failed to encode response", nil)
+		return
+	}
+
+	res.writer.Header().Set("Content-Type", "application/javascript; charset=utf-8")
+	res.writer.Header().Set("X-Content-Type-Options", "nosniff")
+	res.writer.WriteHeader(http.StatusOK)
+	// The leading comment guards against content sniffing attacks (Rosetta Flash)
+	fmt.Fprintf(res.writer, "/**/%s(%s);", callback, body)
+}
+
+// JSONWithETag sends a successful response (200) tagged with an ETag computed from the
+// serialized body. When the request's If-None-Match matches, 304 Not Modified is sent
+// without a body instead.
+func (res *Response) JSONWithETag(message string, payload interface{}) {
+	response := StandardResponse{
+		Status:  "success",
+		Message: message,
+		Payload: payload,
+	}
+	res.localize(&response)
+
+	// The tag covers the negotiated representation, so XML and JSON differ
+	contentType := res.writer.Header().Get("Content-Type")
+	var body []byte
+	var err error
+	if contentType == "" && res.request != nil && prefersXML(res.request) {
+		contentType = xmlContentType
+		body, err = encodeXML(response)
+	} else {
+		body, err = json.Marshal(response)
+		body = append(body, '\n')
+	}
+	if err != nil {
+		res.Error("Failed to encode response", nil)
+		return
+	}
+	if res.request != nil {
+		res.writer.Header().Add("Vary", "Accept")
+	}
+
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	res.writer.Header().Set("ETag", etag)
+	res.writer.Header().Set("Cache-Control", "no-cache")
+
+	if res.request != nil && etagMatches(res.request.Header.Get("If-None-Match"), etag) {
+		res.writer.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	if contentType == "" {
+		contentType = "application/json"
+	}
+	res.writer.Header().Set("Content-Type", contentType)
+	res.writer.WriteHeader(http.StatusOK)
+	res.writer.Write(body)
+}
+
+// etagMatches reports whether an If-None-Match header matches etag, using weak comparison
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
 // Redirect sends a redirect response
 func (res *Response) Redirect(statusCode int, url string) {
 	res.writer.Header().Set("Location", url)
@@ -231,8 +430,22 @@ func (res *Response) sendResponse(statusCode int, status, message string, payloa
 		Payload: payload,
 		Error:   apiError,
 	}
+	res.localize(&response)
+
+	// Clients preferring XML get the same envelope as XML, unless a content
+	// type was already chosen with SetContentType (e.g. application/problem+json)
+	if res.request != nil && res.writer.Header().Get("Content-Type") == "" {
+		res.writer.Header().Add("Vary", "Accept")
+		if prefersXML(res.request) {
+			res.sendXML(statusCode, response)
+			return
+		}
+	}
 
-	res.writer.Header().Set("Content-Type", "application/json")
+	// Keep a content type chosen with SetContentType (e.g. application/problem+json)
+	if res.writer.Header().Get("Content-Type") == "" {
+		res.writer.Header().Set("Content-Type", "application/json")
+	}
 	res.writer.WriteHeader(statusCode)
 
 	if err := json.NewEncoder(res.writer).Encode(response); err != nil {
@@ -242,6 +455,21 @@ func (res *Response) sendResponse(statusCode int, status, message string, payloa
 	}
 }
 
+// sendXML writes the response envelope as XML
+func (res *Response) sendXML(statusCode int, response StandardResponse) {
+	body, err := encodeXML(response)
+	if err != nil {
+		res.writer.Header().Set("Content-Type", xmlContentType)
+		res.writer.WriteHeader(http.StatusInternalServerError)
+		res.writer.Write([]byte(xml.Header + "<response><status>error</status><message>Failed to encode response</message></response>\n"))
+		return
+	}
+
+	res.writer.Header().Set("Content-Type", xmlContentType)
+	res.writer.WriteHeader(statusCode)
+	res.writer.Write(body)
+}
+
 // ===== Error Creation Helpers =====
 
 // NewValidationError creates a new validation error
@@ -258,6 +486,11 @@ func NewValidationError(field, message string, value ...string) ValidationError
 	}
 }
 
+// Error implements the error interface so services can return a validation error directly
+func (e ValidationError) Error() string {
+	return fmt.Sprintf("validation failed on %s: %s", e.Field, e.Message)
+}
+
 // NewAPIError creates a new API error
 func NewAPIError(errorType ErrorType, code, message string, details interface{}) *APIError {
 	return &APIError{
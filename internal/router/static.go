@@ -0,0 +1,83 @@
+package router
+
+import (
+	"net/http"
+	"os"
+	"path"
+
+	"github.com/thenasky/go-framework/internal/logger"
+)
+
+// StaticConfig configures how static files are served
+type StaticConfig struct {
+	CacheControl string // Cache-Control header sent with every file (empty to omit)
+	LogRequests  bool   // Log static requests through the request logger
+}
+
+// DefaultStaticConfig returns the configuration used by Static
+func DefaultStaticConfig() StaticConfig {
+	return StaticConfig{
+		CacheControl: "public, max-age=3600",
+		LogRequests:  false,
+	}
+}
+
+// Static serves the files in dir under urlPrefix using the default configuration
+func (r *RouterBuilder) Static(urlPrefix, dir string) *RouterBuilder {
+	return r.StaticWithConfig(urlPrefix, dir, DefaultStaticConfig())
+}
+
+// StaticWithConfig serves the files in dir under urlPrefix. Directory listings are disabled.
+func (r *RouterBuilder) StaticWithConfig(urlPrefix, dir string, config StaticConfig) *RouterBuilder {
+	route := r.subrouter.PathPrefix(urlPrefix).Methods("GET", "HEAD")
+
+	template, err := route.GetPathTemplate()
+	if err != nil {
+		template = urlPrefix
+	}
+
+	fileServer := http.StripPrefix(template, http.FileServer(noListingFileSystem{http.Dir(dir)}))
+	route.HandlerFunc(func(w http.ResponseWriter, httpReq *http.Request) {
+		if config.CacheControl != "" {
+			w.Header().Set("Cache-Control", config.CacheControl)
+		}
+		fileServer.ServeHTTP(w, httpReq)
+	})
+
+	if !config.LogRequests {
+		logger.SkipPath(template)
+	}
+	reportRoute("GET", route)
+
+	return r
+}
+
+// noListingFileSystem hides directories that have no index.html
+type noListingFileSystem struct {
+	fs http.FileSystem
+}
+
+// Open opens the named file, refusing directories without an index page
+func (nfs noListingFileSystem) Open(name string) (http.File, error) {
+	f, err := nfs.fs.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	if info.IsDir() {
+		index, err := nfs.fs.Open(path.Join(name, "index.html"))
+		if err != nil {
+			f.Close()
+			return nil, os.ErrNotExist
+		}
+		index.Close()
+	}
+
+	return f, nil
+}
@@ -0,0 +1,66 @@
+package testutil_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/thenasky/go-framework/internal/router"
+	"github.com/thenasky/go-framework/internal/router/testutil"
+)
+
+// greet answers GET /greet/{name}
+func greet(req *router.Req, res *router.Res) {
+	name := req.Param("name")
+	if name == "" {
+		res.ValidationErrorSingle("name", "Name is required")
+		return
+	}
+	res.Success("Hello", map[string]string{"greeting": "Hello, " + name})
+}
+
+func ExampleContext_Run() {
+	ctx := testutil.NewTestContext("GET", "/greet/ada", nil).
+		WithParams(map[string]string{"name": "ada"}).
+		Run(greet)
+
+	var payload map[string]string
+	if err := ctx.DecodePayload(&payload); err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(ctx.Recorder.Code, payload["greeting"])
+	// Output: 200 Hello, ada
+}
+
+func ExampleContext_Envelope() {
+	ctx := testutil.NewTestContext("POST", "/echo", map[string]string{"text": "hi"})
+	ctx.Run(func(req *router.Req, res *router.Res) {
+		var body map[string]string
+		if err := req.JSON(&body); err != nil {
+			res.BadRequest("Invalid JSON", nil)
+			return
+		}
+		res.Created("Echoed", body)
+	})
+
+	envelope, err := ctx.Envelope()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(ctx.Recorder.Code, envelope.Status, envelope.Message)
+	// Output: 201 success Echoed
+}
+
+func TestAssertions(t *testing.T) {
+	ctx := testutil.NewTestContext("GET", "/greet/", nil).Run(greet)
+	ctx.AssertStatus(t, http.StatusUnprocessableEntity)
+	ctx.AssertValidationError(t, "name")
+
+	ctx = testutil.NewTestContext("GET", "/greet/ada", nil).
+		WithParams(map[string]string{"name": "ada"}).
+		Run(greet)
+	ctx.AssertStatus(t, http.StatusOK)
+	ctx.AssertEnvelope(t, "success", "Hello")
+}
@@ -0,0 +1,145 @@
+// Package testutil calls router handlers directly, without a mux, server or
+// database, and checks the standard response envelope they write.
+//
+//	ctx := testutil.NewTestContext("POST", "/api/v1/emails/send", models.SendEmailRequest{...})
+//	controller.SendEmail(ctx.Req, ctx.Res)
+//	ctx.AssertStatus(t, http.StatusCreated)
+//	ctx.AssertEnvelope(t, "success", "Email queued successfully")
+package testutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+
+	"github.com/gorilla/mux"
+
+	"github.com/thenasky/go-framework/internal/router"
+)
+
+// T is the part of testing.TB the assertions use. The interface keeps the
+// testing package, and its flags, out of binaries that import testutil.
+type T interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+	Fatalf(format string, args ...interface{})
+}
+
+// Context is a request and a recording response for calling a handler directly
+type Context struct {
+	Req      *router.Req
+	Res      *router.Res
+	Recorder *httptest.ResponseRecorder
+}
+
+// NewTestContext builds a request for method and path with a recording response.
+// A string or []byte body is sent as is; any other non-nil body is sent as JSON.
+func NewTestContext(method, path string, body interface{}) *Context {
+	var reader io.Reader
+	switch typed := body.(type) {
+	case nil:
+	case string:
+		reader = bytes.NewBufferString(typed)
+	case []byte:
+		reader = bytes.NewBuffer(typed)
+	default:
+		data, err := json.Marshal(typed)
+		if err != nil {
+			panic("testutil: body is not JSON serializable: " + err.Error())
+		}
+		reader = bytes.NewBuffer(data)
+	}
+
+	httpReq := httptest.NewRequest(method, path, reader)
+	if reader != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+
+	recorder := httptest.NewRecorder()
+	return &Context{
+		Req:      router.NewRequest(httpReq),
+		Res:      router.NewResponse(recorder).WithRequest(httpReq),
+		Recorder: recorder,
+	}
+}
+
+// WithParams sets the URL path variables a route such as /{id}/status would extract
+func (c *Context) WithParams(params map[string]string) *Context {
+	httpReq := mux.SetURLVars(c.Req.Request, params)
+	c.Req = router.NewRequest(httpReq)
+	c.Res = c.Res.WithRequest(httpReq)
+	return c
+}
+
+// WithHeader sets a request header
+func (c *Context) WithHeader(key, value string) *Context {
+	c.Req.Header.Set(key, value)
+	return c
+}
+
+// Run calls handler with the context's request and response
+func (c *Context) Run(handler router.HandlerFunc) *Context {
+	handler(c.Req, c.Res)
+	return c
+}
+
+// Envelope decodes the recorded body as a standard response envelope
+func (c *Context) Envelope() (router.StandardResponse, error) {
+	var envelope router.StandardResponse
+	err := json.Unmarshal(c.Recorder.Body.Bytes(), &envelope)
+	return envelope, err
+}
+
+// DecodePayload decodes the payload of the recorded envelope into v
+func (c *Context) DecodePayload(v interface{}) error {
+	var envelope struct {
+		Payload json.RawMessage `json:"payload"`
+	}
+	if err := json.Unmarshal(c.Recorder.Body.Bytes(), &envelope); err != nil {
+		return err
+	}
+	return json.Unmarshal(envelope.Payload, v)
+}
+
+// AssertStatus fails the test when the recorded status code isn't code
+func (c *Context) AssertStatus(t T, code int) {
+	t.Helper()
+	if c.Recorder.Code != code {
+		t.Fatalf("status = %d, want %d; body: %s", c.Recorder.Code, code, c.Recorder.Body.String())
+	}
+}
+
+// AssertEnvelope fails the test when the recorded envelope's status or message
+// differ; an empty message is not checked
+func (c *Context) AssertEnvelope(t T, status, message string) {
+	t.Helper()
+	envelope, err := c.Envelope()
+	if err != nil {
+		t.Fatalf("body is not a response envelope: %v; body: %s", err, c.Recorder.Body.String())
+	}
+	if envelope.Status != status {
+		t.Errorf("envelope status = %q, want %q", envelope.Status, status)
+	}
+	if message != "" && envelope.Message != message {
+		t.Errorf("envelope message = %q, want %q", envelope.Message, message)
+	}
+}
+
+// AssertValidationError fails the test unless the envelope reports a validation error on field
+func (c *Context) AssertValidationError(t T, field string) {
+	t.Helper()
+	envelope, err := c.Envelope()
+	if err != nil {
+		t.Fatalf("body is not a response envelope: %v; body: %s", err, c.Recorder.Body.String())
+	}
+	if envelope.Error == nil {
+		t.Fatalf("envelope has no error; body: %s", c.Recorder.Body.String())
+	}
+	for _, validationErr := range envelope.Error.Validation {
+		if validationErr.Field == field {
+			return
+		}
+	}
+	t.Errorf("no validation error on %q; body: %s", field, c.Recorder.Body.String())
+}
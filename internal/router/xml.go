@@ -0,0 +1,143 @@
+package router
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// xmlContentType is the content type of negotiated XML responses
+const xmlContentType = "application/xml; charset=utf-8"
+
+// prefersXML reports whether the request's Accept header ranks XML above JSON.
+// Wildcards count towards JSON, so JSON stays the default.
+func prefersXML(r *http.Request) bool {
+	accept := r.Header.Get("Accept")
+	if accept == "" {
+		return false
+	}
+
+	var xmlQuality, jsonQuality float64
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, _ := strings.Cut(part, ";")
+
+		quality := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			quality = parsed
+		}
+
+		switch strings.ToLower(strings.TrimSpace(mediaType)) {
+		case "application/xml", "text/xml":
+			xmlQuality = max(xmlQuality, quality)
+		case "application/json", "application/*", "*/*":
+			jsonQuality = max(jsonQuality, quality)
+		}
+	}
+
+	return xmlQuality > 0 && xmlQuality > jsonQuality
+}
+
+// encodeXML serializes a response as XML. Payloads and error details are
+// arbitrary values (usually maps), so they are encoded from their JSON form.
+func encodeXML(response StandardResponse) ([]byte, error) {
+	if response.Payload != nil {
+		response.Payload = xmlValue{response.Payload}
+	}
+	if response.Error != nil && response.Error.Details != nil {
+		apiError := *response.Error
+		apiError.Details = xmlValue{apiError.Details}
+		response.Error = &apiError
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	if err := xml.NewEncoder(&buf).Encode(response); err != nil {
+		return nil, err
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+// xmlValue encodes any JSON-serializable value as nested XML elements: object
+// keys become elements, array entries become <item> elements
+type xmlValue struct {
+	value interface{}
+}
+
+// MarshalXML implements xml.Marshaler
+func (v xmlValue) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	data, err := json.Marshal(v.value)
+	if err != nil {
+		return err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var generic interface{}
+	if err := decoder.Decode(&generic); err != nil {
+		return err
+	}
+
+	return encodeXMLValue(e, start, generic)
+}
+
+// encodeXMLValue writes a decoded JSON value as the element start
+func encodeXMLValue(e *xml.Encoder, start xml.StartElement, value interface{}) error {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(typed))
+		for key := range typed {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		if err := e.EncodeToken(start); err != nil {
+			return err
+		}
+		for _, key := range keys {
+			child := xml.StartElement{Name: xml.Name{Local: xmlElementName(key)}}
+			if err := encodeXMLValue(e, child, typed[key]); err != nil {
+				return err
+			}
+		}
+		return e.EncodeToken(start.End())
+	case []interface{}:
+		if err := e.EncodeToken(start); err != nil {
+			return err
+		}
+		for _, item := range typed {
+			if err := encodeXMLValue(e, xml.StartElement{Name: xml.Name{Local: "item"}}, item); err != nil {
+				return err
+			}
+		}
+		return e.EncodeToken(start.End())
+	case nil:
+		return e.EncodeElement("", start)
+	default:
+		return e.EncodeElement(fmt.Sprint(typed), start)
+	}
+}
+
+// xmlElementName turns a JSON object key into a valid XML element name
+func xmlElementName(key string) string {
+	name := []rune(key)
+	for i, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' && r != '-' && r != '.' {
+			name[i] = '_'
+		}
+	}
+	if len(name) == 0 || !(unicode.IsLetter(name[0]) || name[0] == '_') {
+		return "_" + string(name)
+	}
+	return string(name)
+}
@@ -0,0 +1,296 @@
+package swaggergen
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// RouteInfo describes a route declared in a module router
+type RouteInfo struct {
+	Module   string
+	Prefix   string
+	Path     string
+	Method   string
+	Handler  string
+	FullPath string
+
+	// Summary and Description come from the handler's doc comment, if any
+	Summary     string
+	Description string
+}
+
+// DiscoverRoutes parses every router.go file under modulesDir
+func DiscoverRoutes(modulesDir string) ([]RouteInfo, error) {
+	var allRoutes []RouteInfo
+
+	// Walk through modules directory
+	err := filepath.Walk(modulesDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if strings.HasSuffix(path, "router.go") {
+			moduleName := filepath.Base(filepath.Dir(path))
+			routes, err := parseRouterFile(path, moduleName)
+			if err != nil {
+				log.Printf("Warning: could not parse %s: %v", path, err)
+				return nil
+			}
+			if err := describeRoutes(filepath.Dir(path), routes); err != nil {
+				log.Printf("Warning: could not read handler comments in %s: %v", filepath.Dir(path), err)
+			}
+			allRoutes = append(allRoutes, routes...)
+		}
+		return nil
+	})
+
+	return allRoutes, err
+}
+
+// summary returns the operation summary, falling back to the method and path
+func (r RouteInfo) summary() string {
+	if r.Summary != "" {
+		return r.Summary
+	}
+	return fmt.Sprintf("%s %s", r.Method, r.FullPath)
+}
+
+// description returns the operation description, falling back to the path
+func (r RouteInfo) description() string {
+	if r.Description != "" {
+		return r.Description
+	}
+	return fmt.Sprintf("Endpoint: %s", r.FullPath)
+}
+
+// describeRoutes fills in summaries and descriptions from the doc comments
+// of the handler functions declared in the module directory
+func describeRoutes(moduleDir string, routes []RouteInfo) error {
+	files, err := filepath.Glob(filepath.Join(moduleDir, "*.go"))
+	if err != nil {
+		return err
+	}
+
+	var source strings.Builder
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		source.Write(content)
+		source.WriteString("\n")
+	}
+
+	for i := range routes {
+		// Handlers may be referenced as getSuccess or m.controller.SendEmail
+		name := routes[i].Handler
+		if idx := strings.LastIndex(name, "."); idx != -1 {
+			name = name[idx+1:]
+		}
+
+		comment := handlerComment(source.String(), name)
+		if comment == "" {
+			continue
+		}
+
+		routes[i].Summary = strings.SplitN(comment, "\n", 2)[0]
+		routes[i].Description = comment
+	}
+
+	return nil
+}
+
+// handlerComment returns the doc comment directly above the named function
+func handlerComment(source, name string) string {
+	pattern := fmt.Sprintf(`(?m)((?:^[ \t]*//.*\n)+)func\s+(?:\([^)]*\)\s*)?%s\s*\(`, regexp.QuoteMeta(name))
+	match := regexp.MustCompile(pattern).FindStringSubmatch(source)
+	if match == nil {
+		return ""
+	}
+
+	var lines []string
+	for _, line := range strings.Split(strings.TrimSpace(match[1]), "\n") {
+		lines = append(lines, strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "//")))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// routeMethods maps RouterBuilder method names to HTTP methods
+var routeMethods = map[string]string{
+	"Get":     "GET",
+	"Post":    "POST",
+	"Put":     "PUT",
+	"Delete":  "DELETE",
+	"Patch":   "PATCH",
+	"Head":    "HEAD",
+	"Options": "OPTIONS",
+	"GetE":    "GET",
+	"PostE":   "POST",
+	"PutE":    "PUT",
+	"DeleteE": "DELETE",
+	"PatchE":  "PATCH",
+}
+
+// parseRouterFile walks the file's AST and collects every route registered on
+// a router.Router(r, "/prefix") chain
+func parseRouterFile(filename, moduleName string) ([]RouteInfo, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	type positionedRoute struct {
+		pos   token.Pos
+		route RouteInfo
+	}
+	var found []positionedRoute
+
+	// Builders assigned to variables (api := router.Router(...); admin := api.Group(...))
+	prefixes := make(map[string]string)
+
+	// Every chained call (e.g. .Get("/path", handler)) is its own CallExpr whose
+	// receiver is the rest of the chain, so each route is visited exactly once
+	ast.Inspect(file, func(n ast.Node) bool {
+		if assign, ok := n.(*ast.AssignStmt); ok {
+			if len(assign.Lhs) == 1 && len(assign.Rhs) == 1 {
+				if ident, ok := assign.Lhs[0].(*ast.Ident); ok {
+					if prefix, ok := chainPrefix(assign.Rhs[0], prefixes); ok {
+						prefixes[ident.Name] = prefix
+					}
+				}
+			}
+			return true
+		}
+
+		call, ok := n.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		method, ok := routeMethods[sel.Sel.Name]
+		if !ok || len(call.Args) != 2 {
+			return true
+		}
+
+		path, ok := stringLiteral(call.Args[0])
+		if !ok {
+			return true
+		}
+
+		prefix, ok := chainPrefix(sel.X, prefixes)
+		if !ok {
+			return true
+		}
+
+		found = append(found, positionedRoute{
+			pos: call.Pos(),
+			route: RouteInfo{
+				Module:   moduleName,
+				Prefix:   prefix,
+				Path:     path,
+				Method:   method,
+				Handler:  types.ExprString(call.Args[1]),
+				FullPath: joinPath(prefix, path),
+			},
+		})
+		return true
+	})
+
+	// Keep routes in declaration order
+	sort.Slice(found, func(i, j int) bool { return found[i].pos < found[j].pos })
+
+	routes := make([]RouteInfo, 0, len(found))
+	for _, f := range found {
+		routes = append(routes, f.route)
+	}
+	return routes, nil
+}
+
+// chainPrefix follows a method chain back to its router.Router(r, "/prefix")
+// call (or a variable holding a builder) and returns the combined prefix,
+// including any Group("/sub") calls along the way
+func chainPrefix(expr ast.Expr, prefixes map[string]string) (string, bool) {
+	var groups []string
+	for {
+		if ident, ok := expr.(*ast.Ident); ok {
+			prefix, ok := prefixes[ident.Name]
+			return joinGroups(prefix, groups), ok
+		}
+
+		call, ok := expr.(*ast.CallExpr)
+		if !ok {
+			return "", false
+		}
+
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok {
+			return "", false
+		}
+
+		if pkg, ok := sel.X.(*ast.Ident); ok && pkg.Name == "router" && sel.Sel.Name == "Router" {
+			if len(call.Args) != 2 {
+				return "", false
+			}
+			prefix, ok := stringLiteral(call.Args[1])
+			return joinGroups(prefix, groups), ok
+		}
+
+		if sel.Sel.Name == "Group" && len(call.Args) == 1 {
+			group, ok := stringLiteral(call.Args[0])
+			if !ok {
+				return "", false
+			}
+			groups = append(groups, group)
+		}
+
+		expr = sel.X
+	}
+}
+
+// joinGroups appends group prefixes (collected innermost first) to prefix
+func joinGroups(prefix string, groups []string) string {
+	for i := len(groups) - 1; i >= 0; i-- {
+		prefix += groups[i]
+	}
+	return prefix
+}
+
+// stringLiteral returns the value of a string literal expression
+func stringLiteral(expr ast.Expr) (string, bool) {
+	lit, ok := expr.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return "", false
+	}
+	value, err := strconv.Unquote(lit.Value)
+	if err != nil {
+		return "", false
+	}
+	return value, true
+}
+
+// joinPath builds the full route path from a prefix and a route path
+func joinPath(prefix, path string) string {
+	fullPath := prefix
+	if path != "" {
+		if !strings.HasPrefix(path, "/") && fullPath != "/" {
+			fullPath += "/"
+		}
+		fullPath += path
+	}
+	return fullPath
+}
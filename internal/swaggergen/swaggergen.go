@@ -0,0 +1,228 @@
+// Package swaggergen generates API documentation from module router definitions.
+package swaggergen
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Supported spec versions
+const (
+	VersionOpenAPI3 = "3.0"
+	VersionSwagger2 = "2.0"
+)
+
+type SwaggerSpec struct {
+	Swagger string                 `json:"swagger"`
+	Info    SwaggerInfo            `json:"info"`
+	Host    string                 `json:"host"`
+	Schemes []string               `json:"schemes"`
+	Paths   map[string]interface{} `json:"paths"`
+}
+
+type OpenAPISpec struct {
+	OpenAPI    string                 `json:"openapi"`
+	Info       SwaggerInfo            `json:"info"`
+	Servers    []OpenAPIServer        `json:"servers"`
+	Paths      map[string]interface{} `json:"paths"`
+	Components map[string]interface{} `json:"components"`
+}
+
+type OpenAPIServer struct {
+	URL         string `json:"url"`
+	Description string `json:"description,omitempty"`
+}
+
+type SwaggerInfo struct {
+	Version     string `json:"version"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+}
+
+var apiInfo = SwaggerInfo{
+	Version:     "1.0",
+	Title:       "Master Server API",
+	Description: "API documentation generated from router definitions",
+}
+
+// Generate writes an OpenAPI 3.0 spec for the routes declared under modulesDir to outPath
+func Generate(modulesDir, outPath string) error {
+	return GenerateVersion(modulesDir, outPath, VersionOpenAPI3)
+}
+
+// GenerateVersion writes a spec of the given version (3.0 or 2.0) to outPath
+func GenerateVersion(modulesDir, outPath, version string) error {
+	routes, err := DiscoverRoutes(modulesDir)
+	if err != nil {
+		return fmt.Errorf("failed to discover routes: %w", err)
+	}
+
+	var spec interface{}
+	switch version {
+	case VersionOpenAPI3, "3":
+		spec = buildOpenAPI3(routes)
+	case VersionSwagger2, "2":
+		spec = buildSwagger2(routes)
+	default:
+		return fmt.Errorf("unsupported spec version %q (use 3.0 or 2.0)", version)
+	}
+
+	jsonBytes, err := json.MarshalIndent(spec, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal spec: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(outPath), 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	if err := os.WriteFile(outPath, jsonBytes, 0644); err != nil {
+		return fmt.Errorf("failed to write spec: %w", err)
+	}
+
+	return nil
+}
+
+// buildSwagger2 builds a Swagger 2.0 spec from the discovered routes
+func buildSwagger2(routes []RouteInfo) SwaggerSpec {
+	swagger := SwaggerSpec{
+		Swagger: "2.0",
+		Info:    apiInfo,
+		Host:    "localhost:8080",
+		Schemes: []string{"http"},
+		Paths:   make(map[string]interface{}),
+	}
+
+	// Add paths from routes
+	for _, route := range routes {
+		if swagger.Paths[route.FullPath] == nil {
+			swagger.Paths[route.FullPath] = make(map[string]interface{})
+		}
+
+		pathMap := swagger.Paths[route.FullPath].(map[string]interface{})
+		methodLower := strings.ToLower(route.Method)
+
+		// Create method definition
+		methodDef := map[string]interface{}{
+			"summary":     route.summary(),
+			"description": route.description(),
+			"tags":        []string{route.Module},
+			"produces":    []string{"application/json"},
+			"responses": map[string]interface{}{
+				"200": map[string]interface{}{
+					"description": "Success",
+				},
+			},
+		}
+
+		if params := pathParameters(route.FullPath, "2.0"); len(params) > 0 {
+			methodDef["parameters"] = params
+		}
+
+		pathMap[methodLower] = methodDef
+	}
+
+	return swagger
+}
+
+// buildOpenAPI3 builds an OpenAPI 3.0 spec from the discovered routes
+func buildOpenAPI3(routes []RouteInfo) OpenAPISpec {
+	spec := OpenAPISpec{
+		OpenAPI: "3.0.0",
+		Info:    apiInfo,
+		Servers: []OpenAPIServer{
+			{URL: "http://localhost:8080", Description: "Local development server"},
+		},
+		Paths: make(map[string]interface{}),
+		Components: map[string]interface{}{
+			"schemas": map[string]interface{}{
+				"Response": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"status":  map[string]interface{}{"type": "string"},
+						"message": map[string]interface{}{"type": "string"},
+						"payload": map[string]interface{}{},
+					},
+				},
+			},
+		},
+	}
+
+	responseContent := map[string]interface{}{
+		"application/json": map[string]interface{}{
+			"schema": map[string]interface{}{"$ref": "#/components/schemas/Response"},
+		},
+	}
+
+	// Add paths from routes
+	for _, route := range routes {
+		if spec.Paths[route.FullPath] == nil {
+			spec.Paths[route.FullPath] = make(map[string]interface{})
+		}
+
+		pathMap := spec.Paths[route.FullPath].(map[string]interface{})
+		methodLower := strings.ToLower(route.Method)
+
+		// Create operation definition
+		operation := map[string]interface{}{
+			"summary":     route.summary(),
+			"description": route.description(),
+			"tags":        []string{route.Module},
+			"responses": map[string]interface{}{
+				"200": map[string]interface{}{
+					"description": "Success",
+					"content":     responseContent,
+				},
+			},
+		}
+
+		if params := pathParameters(route.FullPath, "3.0"); len(params) > 0 {
+			operation["parameters"] = params
+		}
+
+		if hasRequestBody(route.Method) {
+			operation["requestBody"] = map[string]interface{}{
+				"required": false,
+				"content": map[string]interface{}{
+					"application/json": map[string]interface{}{
+						"schema": map[string]interface{}{"type": "object"},
+					},
+				},
+			}
+		}
+
+		pathMap[methodLower] = operation
+	}
+
+	return spec
+}
+
+var pathParamRe = regexp.MustCompile(`\{([^}:]+)(?::[^}]*)?\}`)
+
+// pathParameters returns a required string parameter for every {name} segment in fullPath
+func pathParameters(fullPath, version string) []interface{} {
+	var params []interface{}
+	for _, match := range pathParamRe.FindAllStringSubmatch(fullPath, -1) {
+		param := map[string]interface{}{
+			"name":     match[1],
+			"in":       "path",
+			"required": true,
+		}
+		if version == "2.0" {
+			param["type"] = "string"
+		} else {
+			param["schema"] = map[string]interface{}{"type": "string"}
+		}
+		params = append(params, param)
+	}
+	return params
+}
+
+// hasRequestBody reports whether operations using method accept a JSON body
+func hasRequestBody(method string) bool {
+	return method == "POST" || method == "PUT" || method == "PATCH"
+}
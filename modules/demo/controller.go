@@ -45,6 +45,14 @@ func getDataWithPayload(req *router.Req, res *router.Res) {
 	})
 }
 
+func getJSONP(req *router.Req, res *router.Res) {
+	// e.g. /demo/jsonp?callback=handleData
+	res.JSONP(req.QueryParam("callback"), map[string]interface{}{
+		"timestamp": time.Now().Format(time.RFC3339),
+		"status":    "active",
+	})
+}
+
 // ===== Client Error Response Examples =====
 
 func getBadRequest(req *router.Req, res *router.Res) {
@@ -78,7 +86,7 @@ func getNotFound(req *router.Req, res *router.Res) {
 }
 
 func getMethodNotAllowed(req *router.Req, res *router.Res) {
-	res.MethodNotAllowed("Method not allowed for this endpoint", []string{"GET", "POST"})
+	res.MethodNotAllowed("Method not allowed for this endpoint", req.AllowedMethods())
 }
 
 func getConflict(req *router.Req, res *router.Res) {
@@ -172,6 +180,13 @@ func getBusinessRuleViolation(req *router.Req, res *router.Res) {
 	)
 }
 
+func getReturnedError(req *router.Req, res *router.Res) error {
+	// Returned errors are answered by the router's error handler
+	return router.NewNotFoundError("Order not found").WithDetails(map[string]interface{}{
+		"order_id": req.QueryParam("id"),
+	})
+}
+
 // ===== Middleware Examples =====
 
 func getValidationWithMiddleware(req *router.Req, res *router.Res) {
@@ -18,6 +18,7 @@ func (m *Module) RegisterRoutes(r *mux.Router) {
 		Get("/success", getSuccess).
 		Get("/created", getCreated).
 		Get("/data", getDataWithPayload).
+		Get("/jsonp", getJSONP).
 		// Client error responses
 		Get("/bad-request", getBadRequest).
 		Get("/unauthorized", getUnauthorized).
@@ -36,6 +37,7 @@ func (m *Module) RegisterRoutes(r *mux.Router) {
 		// Custom errors
 		Get("/custom-error", getCustomError).
 		Get("/business-rule", getBusinessRuleViolation).
+		GetE("/returned-error", getReturnedError).
 		// Middleware examples
 		Post("/validate", getValidationWithMiddleware).
 		Get("/panic", getPanicExample).
@@ -0,0 +1,72 @@
+package demo
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/thenasky/go-framework/internal/core"
+	"github.com/thenasky/go-framework/internal/router"
+)
+
+// getPanicEnvelope requests /demo/panic through the full middleware stack
+func getPanicEnvelope(t *testing.T) router.StandardResponse {
+	t.Helper()
+	server := httptest.NewServer(core.NewRouter())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/demo/panic")
+	if err != nil {
+		t.Fatalf("GET /demo/panic: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want 500", resp.StatusCode)
+	}
+	var envelope router.StandardResponse
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		t.Fatalf("body is not a response envelope: %v", err)
+	}
+	if envelope.Status != "error" || envelope.Error == nil {
+		t.Fatalf("envelope = %+v, want an error", envelope)
+	}
+	if envelope.Error.Type != router.ErrorTypeInternal || envelope.Error.Code != "INTERNAL_ERROR" {
+		t.Errorf("error = %s/%s, want internal/INTERNAL_ERROR", envelope.Error.Type, envelope.Error.Code)
+	}
+	if !strings.HasPrefix(envelope.Error.InternalID, "ERR_") {
+		t.Errorf("internal ID = %q, want an ERR_ ID", envelope.Error.InternalID)
+	}
+	return envelope
+}
+
+func TestPanicReturnsErrorEnvelope(t *testing.T) {
+	t.Setenv("DEBUG", "false")
+
+	envelope := getPanicEnvelope(t)
+	if envelope.Error.Details != nil {
+		t.Errorf("panic details exposed without DEBUG: %v", envelope.Error.Details)
+	}
+}
+
+func TestPanicIncludesStackWithDebug(t *testing.T) {
+	t.Setenv("DEBUG", "true")
+
+	envelope := getPanicEnvelope(t)
+	details, ok := envelope.Error.Details.(map[string]interface{})
+	if !ok {
+		t.Fatalf("details = %v, want the panic and its stack", envelope.Error.Details)
+	}
+	if message, _ := details["error"].(string); !strings.Contains(message, "test panic") {
+		t.Errorf("details error = %q, want the panic value", message)
+	}
+	stack, _ := details["stack"].([]interface{})
+	if len(stack) == 0 {
+		t.Fatal("details carry no stack")
+	}
+	if frame, _ := stack[0].(string); !strings.Contains(frame, "getPanicExample") {
+		t.Errorf("stack starts at %q, want the panicking handler", frame)
+	}
+}
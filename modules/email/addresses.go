@@ -0,0 +1,59 @@
+package email
+
+import (
+	"net/mail"
+	"strings"
+
+	"github.com/thenasky/go-framework/modules/email/models"
+)
+
+// normalizeAddress trims an address, extracts it from a "Name <address>" form
+// and lowercases its domain. It returns the bare address and the display name,
+// if any. Input that does not parse is only trimmed so validation can report it.
+func normalizeAddress(address string) (string, string) {
+	address = strings.TrimSpace(address)
+
+	var name string
+	if parsed, err := mail.ParseAddress(address); err == nil {
+		address, name = parsed.Address, parsed.Name
+	}
+
+	// The local part may be case-sensitive; the domain never is
+	if at := strings.LastIndex(address, "@"); at >= 0 {
+		address = address[:at+1] + strings.ToLower(address[at+1:])
+	}
+	return address, name
+}
+
+// normalizeSendRequest normalizes the addresses of a send request. Display
+// names given with the address are kept in FromName and ToName unless those
+// are set explicitly.
+func normalizeSendRequest(req *models.SendEmailRequest) {
+	var name string
+
+	req.To, name = normalizeAddress(req.To)
+	if req.ToName == "" {
+		req.ToName = name
+	}
+
+	req.From, name = normalizeAddress(req.From)
+	if req.FromName == "" {
+		req.FromName = name
+	}
+
+	req.Cc = normalizeAddresses(req.Cc)
+	req.Bcc = normalizeAddresses(req.Bcc)
+}
+
+// normalizeAddresses normalizes a list of addresses, dropping display names
+func normalizeAddresses(addresses []string) []string {
+	if len(addresses) == 0 {
+		return addresses
+	}
+
+	normalized := make([]string, len(addresses))
+	for i, address := range addresses {
+		normalized[i], _ = normalizeAddress(address)
+	}
+	return normalized
+}
@@ -0,0 +1,92 @@
+package email
+
+import (
+	"fmt"
+	"mime"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/thenasky/go-framework/internal/router"
+	"github.com/thenasky/go-framework/modules/email/models"
+)
+
+// maxAttachments caps the attachments of a single email
+const maxAttachments = 20
+
+// contentIDPattern restricts Content-IDs to characters that are safe in a
+// header and in a cid: URL
+var contentIDPattern = regexp.MustCompile(`^[A-Za-z0-9._@-]+$`)
+
+// normalizeAttachments fills in the defaults of a request's attachments: the
+// content type detected from the filename and the attachment disposition
+func normalizeAttachments(req *models.SendEmailRequest) {
+	for i := range req.Attachments {
+		attachment := &req.Attachments[i]
+
+		attachment.Filename = strings.TrimSpace(attachment.Filename)
+		attachment.Disposition = strings.ToLower(strings.TrimSpace(attachment.Disposition))
+		if attachment.Disposition == "" {
+			attachment.Disposition = models.DispositionAttachment
+		}
+		// Bodies reference cid:logo, but clients often send the header form <logo>
+		attachment.ContentID = strings.Trim(strings.TrimSpace(attachment.ContentID), "<>")
+
+		if attachment.ContentType == "" {
+			attachment.ContentType = mime.TypeByExtension(filepath.Ext(attachment.Filename))
+		}
+		if attachment.ContentType == "" {
+			attachment.ContentType = "application/octet-stream"
+		}
+	}
+}
+
+// validateAttachments checks that every attachment can be written into the
+// message headers and that inline attachments have a unique Content-ID
+func validateAttachments(attachments []models.Attachment) error {
+	if len(attachments) > maxAttachments {
+		return router.NewValidationError("attachments", fmt.Sprintf("At most %d attachments are allowed", maxAttachments))
+	}
+
+	contentIDs := make(map[string]bool)
+	for i, attachment := range attachments {
+		field := "attachments[" + strconv.Itoa(i) + "]"
+
+		if attachment.Filename == "" {
+			return router.NewValidationError(field+".filename", "Attachment filename is required")
+		}
+		if strings.ContainsAny(attachment.Filename, "\r\n") {
+			return router.NewValidationError(field+".filename", "Attachment filename must not contain line breaks", attachment.Filename)
+		}
+		if len(attachment.Content) == 0 {
+			return router.NewValidationError(field+".content", "Attachment content is required")
+		}
+		if _, _, err := mime.ParseMediaType(attachment.ContentType); err != nil {
+			return router.NewValidationError(field+".content_type", "Invalid attachment content type", attachment.ContentType)
+		}
+
+		switch attachment.Disposition {
+		case models.DispositionAttachment:
+			if attachment.ContentID == "" {
+				continue
+			}
+		case models.DispositionInline:
+			if attachment.ContentID == "" {
+				return router.NewValidationError(field+".content_id", "Inline attachments require a content ID")
+			}
+		default:
+			return router.NewValidationError(field+".disposition", "Disposition must be 'attachment' or 'inline'", attachment.Disposition)
+		}
+
+		if !contentIDPattern.MatchString(attachment.ContentID) {
+			return router.NewValidationError(field+".content_id", "Content ID may only contain letters, digits and . _ @ -", attachment.ContentID)
+		}
+		if contentIDs[attachment.ContentID] {
+			return router.NewValidationError(field+".content_id", "Content IDs must be unique", attachment.ContentID)
+		}
+		contentIDs[attachment.ContentID] = true
+	}
+
+	return nil
+}
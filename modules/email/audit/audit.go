@@ -0,0 +1,45 @@
+package audit
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Lifecycle events recorded for an email
+const (
+	EventQueued     = "queued"     // Accepted by the API
+	EventDequeued   = "dequeued"   // Claimed by a worker for an attempt
+	EventDeferred   = "deferred"   // Put back until its recipient domain is below its rate limit
+	EventRetrying   = "retrying"   // An attempt failed and the email will be tried again
+	EventSent       = "sent"       // Accepted by a provider
+	EventFailed     = "failed"     // Failed for good
+	EventBounced    = "bounced"    // Reported as bounced by the provider
+	EventComplained = "complained" // Reported as spam by the recipient
+)
+
+// Event is one entry of an email's audit trail
+type Event struct {
+	ID      primitive.ObjectID `json:"-" bson:"_id,omitempty"`
+	JobID   primitive.ObjectID `json:"job_id" bson:"job_id"`
+	Type    string             `json:"event" bson:"event"`
+	Actor   string             `json:"actor" bson:"actor"` // api, worker-1, webhook:ses, ...
+	Details map[string]string  `json:"details,omitempty" bson:"details,omitempty"`
+	At      time.Time          `json:"at" bson:"at"`
+}
+
+// Trail is an append-only log of email lifecycle events, kept apart from the
+// jobs so the history outlives their cleanup
+type Trail interface {
+	// Record appends an event, setting its time when unset
+	Record(ctx context.Context, event Event) error
+	// History returns the events of a job, oldest first
+	History(ctx context.Context, jobID primitive.ObjectID) ([]Event, error)
+}
+
+// Ensure both backends implement Trail
+var (
+	_ Trail = (*MongoTrail)(nil)
+	_ Trail = (*MemoryTrail)(nil)
+)
@@ -0,0 +1,42 @@
+package audit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryTrail keeps audit events in process memory
+type MemoryTrail struct {
+	mu     sync.RWMutex
+	events map[primitive.ObjectID][]Event
+}
+
+// NewMemoryTrail creates a new in-memory audit trail
+func NewMemoryTrail() *MemoryTrail {
+	return &MemoryTrail{events: make(map[primitive.ObjectID][]Event)}
+}
+
+// Record appends an event, setting its time when unset
+func (t *MemoryTrail) Record(ctx context.Context, event Event) error {
+	if event.At.IsZero() {
+		event.At = time.Now()
+	}
+	event.ID = primitive.NewObjectID()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.events[event.JobID] = append(t.events[event.JobID], event)
+	return nil
+}
+
+// History returns the events of a job, oldest first
+func (t *MemoryTrail) History(ctx context.Context, jobID primitive.ObjectID) ([]Event, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return append([]Event(nil), t.events[jobID]...), nil
+}
@@ -0,0 +1,82 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/thenasky/go-framework/internal/database"
+)
+
+// collectionName is the MongoDB collection holding audit events
+const collectionName = "emails_audit"
+
+// indexes are the indexes of the audit collection
+var indexes = []mongo.IndexModel{
+	// History is read per job in order
+	{
+		Keys:    bson.D{{Key: "job_id", Value: 1}, {Key: "at", Value: 1}},
+		Options: options.Index().SetName("job_id_at"),
+	},
+}
+
+// MongoTrail stores audit events in MongoDB. Events are only ever inserted.
+type MongoTrail struct {
+	collection *mongo.Collection
+}
+
+// NewMongoTrail creates a new MongoDB-based audit trail
+func NewMongoTrail() (*MongoTrail, error) {
+	db := database.MongoDB()
+	if db == nil {
+		return nil, fmt.Errorf("MongoDB not connected")
+	}
+
+	collection := db.Collection(collectionName)
+	if err := database.EnsureCollectionIndexes(collection, indexes); err != nil {
+		return nil, fmt.Errorf("failed to create audit indexes: %w", err)
+	}
+
+	return &MongoTrail{collection: collection}, nil
+}
+
+// Record appends an event, setting its time when unset
+func (t *MongoTrail) Record(ctx context.Context, event Event) error {
+	ctx, cancel := database.OpContext(ctx)
+	defer cancel()
+
+	if event.At.IsZero() {
+		event.At = time.Now()
+	}
+	event.ID = primitive.NilObjectID
+
+	if _, err := t.collection.InsertOne(ctx, event); err != nil {
+		return fmt.Errorf("failed to record audit event: %w", err)
+	}
+	return nil
+}
+
+// History returns the events of a job, oldest first
+func (t *MongoTrail) History(ctx context.Context, jobID primitive.ObjectID) ([]Event, error) {
+	ctx, cancel := database.OpContext(ctx)
+	defer cancel()
+
+	// Events recorded within the same millisecond keep their insertion order
+	opts := options.Find().SetSort(bson.D{{Key: "at", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := t.collection.Find(ctx, bson.M{"job_id": jobID}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load audit history: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	events := []Event{}
+	if err := cursor.All(ctx, &events); err != nil {
+		return nil, fmt.Errorf("failed to decode audit history: %w", err)
+	}
+	return events, nil
+}
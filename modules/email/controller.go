@@ -1,10 +1,38 @@
 package email
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/thenasky/go-framework/internal/core"
+	"github.com/thenasky/go-framework/internal/logger"
 	"github.com/thenasky/go-framework/internal/router"
 	"github.com/thenasky/go-framework/modules/email/models"
+	"github.com/thenasky/go-framework/modules/email/providers"
+	"github.com/thenasky/go-framework/modules/email/queue"
+	"github.com/thenasky/go-framework/modules/email/webhooks"
 )
 
+// maxPageSize caps the page size of list endpoints
+const maxPageSize = 100
+
+// sortableFields are the fields emails can be sorted by when listing
+var sortableFields = map[string]bool{
+	"created_at":   true,
+	"scheduled_at": true,
+	"processed_at": true,
+	"priority":     true,
+	"status":       true,
+}
+
+// maxWebhookBodySize caps the size of provider webhook payloads
+const maxWebhookBodySize = 5 << 20
+
 // Controller handles HTTP requests for email operations
 type Controller struct {
 	service *EmailService
@@ -12,8 +40,14 @@ type Controller struct {
 
 // NewController creates a new email controller
 func NewController() *Controller {
+	return NewControllerWithService(GetDefaultService())
+}
+
+// NewControllerWithService creates a controller backed by service, e.g. one
+// using the in-memory queue when testing handlers in isolation
+func NewControllerWithService(service *EmailService) *Controller {
 	return &Controller{
-		service: NewEmailService(),
+		service: service,
 	}
 }
 
@@ -31,15 +65,113 @@ func (c *Controller) SendEmail(req *router.Req, res *router.Res) {
 		sendReq.Priority = models.PriorityNormal
 	}
 
+	// The idempotency key may also be sent as a header
+	if sendReq.IdempotencyKey == "" {
+		sendReq.IdempotencyKey = req.GetHeader("Idempotency-Key")
+	}
+
 	// Send email
-	response, err := c.service.SendEmail(&sendReq)
+	response, err := c.service.SendEmail(req.Context(), &sendReq)
+	var validationErr router.ValidationError
+	if errors.As(err, &validationErr) {
+		res.ValidationError("Validation failed", []router.ValidationError{validationErr})
+		return
+	}
 	if err != nil {
+		logger.FromContext(req.Context()).Error("Failed to queue email: " + err.Error())
 		res.Error("Failed to send email", map[string]string{"error": err.Error()})
 		return
 	}
 
+	// A replayed idempotency key returns the original email with 200 instead of 201
+	if response.Replayed {
+		router.SendSuccess(res, "Email already queued", response)
+		return
+	}
+
 	// Return success response
-	res.Created("Email queued successfully", response)
+	router.SendCreated(res, "Email queued successfully", response)
+}
+
+// SendBulkEmail handles POST /api/v1/emails/bulk
+func (c *Controller) SendBulkEmail(req *router.Req, res *router.Res) {
+	var bulkReq models.SendBulkEmailRequest
+	if err := req.JSON(&bulkReq); err != nil {
+		res.BadRequest("Invalid request body", map[string]string{"error": err.Error()})
+		return
+	}
+
+	if bulkReq.Priority == 0 {
+		bulkReq.Priority = models.PriorityNormal
+	}
+
+	response, err := c.service.SendBulkEmail(req.Context(), &bulkReq)
+	var validationErr router.ValidationError
+	if errors.As(err, &validationErr) {
+		res.ValidationError("Validation failed", []router.ValidationError{validationErr})
+		return
+	}
+	if err != nil {
+		logger.FromContext(req.Context()).Error("Failed to queue bulk email: " + err.Error())
+		res.Error("Failed to send emails", map[string]string{"error": err.Error()})
+		return
+	}
+
+	// Nothing was queued when every recipient was rejected
+	if response.Queued == 0 {
+		res.UnprocessableEntity("No recipient could be queued", response)
+		return
+	}
+
+	router.SendCreated(res, "Emails queued successfully", response)
+}
+
+// GetCampaignStatus handles GET /api/v1/emails/campaigns/{id}
+func (c *Controller) GetCampaignStatus(req *router.Req, res *router.Res) {
+	campaignID := req.Param("id")
+	if campaignID == "" {
+		res.BadRequest("Campaign ID is required", nil)
+		return
+	}
+
+	status, err := c.service.GetCampaignStatus(req.Context(), campaignID)
+	if errors.Is(err, ErrCampaignNotFound) {
+		res.NotFound("Campaign not found", nil)
+		return
+	}
+	if err != nil {
+		res.Error("Failed to get campaign status", map[string]string{"error": err.Error()})
+		return
+	}
+
+	res.JSONWithETag("Campaign status retrieved successfully", status)
+}
+
+// PreviewEmail handles POST /api/v1/emails/preview
+func (c *Controller) PreviewEmail(req *router.Req, res *router.Res) {
+	var sendReq models.SendEmailRequest
+	if err := req.JSON(&sendReq); err != nil {
+		res.BadRequest("Invalid request body", map[string]string{"error": err.Error()})
+		return
+	}
+
+	if sendReq.Priority == 0 {
+		sendReq.Priority = models.PriorityNormal
+	}
+
+	// Validate and render exactly like /send, but never enqueue
+	preview, err := c.service.PreviewEmail(&sendReq)
+	var validationErr router.ValidationError
+	if errors.As(err, &validationErr) {
+		res.ValidationError("Validation failed", []router.ValidationError{validationErr})
+		return
+	}
+	if err != nil {
+		res.BadRequest("Email is not valid", map[string]string{"error": err.Error()})
+		return
+	}
+
+	router.SendSuccess(res, "Email preview rendered successfully", preview)
 }
 
 // GetEmailStatus handles GET /api/v1/emails/{id}/status
@@ -52,38 +184,305 @@ func (c *Controller) GetEmailStatus(req *router.Req, res *router.Res) {
 	}
 
 	// Get email status
-	status, err := c.service.GetEmailStatus(emailID)
+	status, err := c.service.GetEmailStatus(req.Context(), emailID)
 	if err != nil {
 		res.NotFound("Email not found", map[string]string{"error": err.Error()})
 		return
 	}
 
 	// Return status
-	res.Success("Email status retrieved successfully", status)
+	res.JSONWithETag("Email status retrieved successfully", status)
+}
+
+// GetEmailHistory handles GET /api/v1/emails/{id}/history
+func (c *Controller) GetEmailHistory(req *router.Req, res *router.Res) {
+	emailID := req.Param("id")
+	if emailID == "" {
+		res.BadRequest("Email ID is required", nil)
+		return
+	}
+
+	history, err := c.service.GetEmailHistory(req.Context(), emailID)
+	var validationErr router.ValidationError
+	if errors.As(err, &validationErr) {
+		res.ValidationError("Validation failed", []router.ValidationError{validationErr})
+		return
+	}
+	if errors.Is(err, ErrEmailHistoryNotFound) {
+		res.NotFound("Email history not found", nil)
+		return
+	}
+	if err != nil {
+		res.Error("Failed to get email history", map[string]string{"error": err.Error()})
+		return
+	}
+
+	router.SendSuccess(res, "Email history retrieved successfully", history)
+}
+
+// StreamEmailStatus handles GET /api/v1/emails/{id}/events
+func (c *Controller) StreamEmailStatus(req *router.Req, res *router.Res) {
+	// Get email ID from URL parameters
+	emailID := req.Param("id")
+	if emailID == "" {
+		res.BadRequest("Email ID is required", nil)
+		return
+	}
+
+	// The watch stops when the client disconnects
+	ctx := req.Context()
+	events, err := c.service.WatchEmailStatus(ctx, emailID)
+	if err != nil {
+		res.NotFound("Email not found", map[string]string{"error": err.Error()})
+		return
+	}
+
+	res.SSE(ctx, events)
+}
+
+// ListEmails handles GET /api/v1/emails
+func (c *Controller) ListEmails(req *router.Req, res *router.Res) {
+	filter := queue.ListFilter{
+		Status:     req.QueryParam("status"),
+		To:         req.QueryParam("to"),
+		From:       req.QueryParam("from"),
+		CampaignID: req.QueryParam("campaign_id"),
+		Page:       req.QueryInt("page", 1),
+		PageSize:   req.QueryInt("page_size", 20),
+		SortBy:     req.QueryParam("sort"),
+		SortDesc:   req.QueryParam("order") != "asc",
+	}
+
+	// Validate query parameters
+	var validationErrors []router.ValidationError
+
+	if filter.Page < 1 {
+		validationErrors = append(validationErrors, router.NewValidationError("page", "Page must be at least 1", req.QueryParam("page")))
+	}
+	if filter.PageSize < 1 || filter.PageSize > maxPageSize {
+		validationErrors = append(validationErrors, router.NewValidationError("page_size", res.T("Page size must be between 1 and %d", maxPageSize), req.QueryParam("page_size")))
+	}
+	if filter.SortBy != "" && !sortableFields[filter.SortBy] {
+		validationErrors = append(validationErrors, router.NewValidationError("sort", "Unsupported sort field", filter.SortBy))
+	}
+	if order := req.QueryParam("order"); order != "" && order != "asc" && order != "desc" {
+		validationErrors = append(validationErrors, router.NewValidationError("order", "Order must be 'asc' or 'desc'", order))
+	}
+
+	for _, param := range []string{"created_after", "created_before"} {
+		value := req.QueryParam(param)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			validationErrors = append(validationErrors, router.NewValidationError(param, "Date must be in RFC3339 format", value))
+			continue
+		}
+		if param == "created_after" {
+			filter.CreatedAfter = &parsed
+		} else {
+			filter.CreatedBefore = &parsed
+		}
+	}
+
+	if len(validationErrors) > 0 {
+		res.ValidationError("Invalid list parameters", validationErrors)
+		return
+	}
+
+	// List emails
+	emails, total, err := c.service.ListEmails(req.Context(), filter)
+	if err != nil {
+		res.Error("Failed to list emails", map[string]string{"error": err.Error()})
+		return
+	}
+
+	res.Paginated("Emails retrieved successfully", emails, filter.Page, filter.PageSize, total)
+}
+
+// PurgeEmails handles DELETE /api/v1/emails?status=failed&before=<RFC3339 date>
+func (c *Controller) PurgeEmails(req *router.Req, res *router.Res) {
+	var validationErrors []router.ValidationError
+
+	status := req.QueryParam("status")
+	if status == "" {
+		validationErrors = append(validationErrors, router.NewValidationError("status", "Status is required", status))
+	}
+
+	value := req.QueryParam("before")
+	before, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		validationErrors = append(validationErrors, router.NewValidationError("before", "Date must be in RFC3339 format", value))
+	}
+
+	if len(validationErrors) > 0 {
+		res.ValidationError("Invalid purge parameters", validationErrors)
+		return
+	}
+
+	removed, err := c.service.PurgeEmails(req.Context(), status, before)
+	var validationErr router.ValidationError
+	if errors.As(err, &validationErr) {
+		res.ValidationError("Invalid purge parameters", []router.ValidationError{validationErr})
+		return
+	}
+	if err != nil {
+		res.Error("Failed to purge emails", map[string]string{"error": err.Error()})
+		return
+	}
+
+	res.Success("Emails purged successfully", map[string]interface{}{
+		"removed": removed,
+		"status":  status,
+		"before":  before,
+	})
+}
+
+// ValidateAddress handles GET /api/v1/emails/validate?email=...
+func (c *Controller) ValidateAddress(req *router.Req, res *router.Res) {
+	email := req.QueryParam("email")
+	if email == "" {
+		res.ValidationErrorSingle("email", "Email query parameter is required")
+		return
+	}
+
+	syntax := map[string]interface{}{"valid": true}
+	mx := map[string]interface{}{"valid": false}
+
+	// MX records are only looked up for syntactically valid addresses
+	if err := providers.ValidateEmailFormat(email); err != nil {
+		syntax = map[string]interface{}{"valid": false, "error": err.Error()}
+		mx["error"] = "skipped: invalid syntax"
+	} else {
+		domain := email[strings.LastIndex(email, "@")+1:]
+		hosts, err := providers.LookupMX(domain)
+		if err != nil {
+			mx["error"] = err.Error()
+		} else {
+			mx["valid"] = true
+			mx["records"] = hosts
+		}
+	}
+
+	res.Success("Email address validated", map[string]interface{}{
+		"email":       email,
+		"syntax":      syntax,
+		"mx":          mx,
+		"deliverable": syntax["valid"] == true && mx["valid"] == true,
+	})
+}
+
+// HandleWebhook handles POST /api/v1/emails/webhooks/{provider}
+func (c *Controller) HandleWebhook(req *router.Req, res *router.Res) {
+	provider := req.Param("provider")
+
+	body, err := io.ReadAll(io.LimitReader(req.Body, maxWebhookBodySize))
+	if err != nil {
+		res.BadRequest("Failed to read webhook body", map[string]string{"error": err.Error()})
+		return
+	}
+
+	// Apply delivery events
+	applied, err := c.service.HandleWebhook(req.Context(), provider, req.Header, body)
+	if err != nil {
+		if errors.Is(err, webhooks.ErrUnsupportedProvider) {
+			res.NotFound("Unsupported webhook provider", map[string]string{"provider": provider})
+			return
+		}
+		if errors.Is(err, webhooks.ErrInvalidSignature) {
+			logger.FromContext(req.Context()).Warn(fmt.Sprintf("Rejected %s webhook: %v", provider, err))
+			res.Unauthorized("Invalid webhook signature", nil)
+			return
+		}
+		logger.FromContext(req.Context()).Warn(fmt.Sprintf("Rejected %s webhook: %v", provider, err))
+		res.BadRequest("Failed to process webhook", map[string]string{"error": err.Error()})
+		return
+	}
+
+	res.Success("Webhook processed successfully", map[string]interface{}{
+		"provider":       provider,
+		"events_applied": applied,
+	})
+}
+
+// Unsubscribe handles GET and POST /api/v1/emails/unsubscribe. POST is the
+// RFC 8058 one-click request mail clients send from the List-Unsubscribe header.
+func (c *Controller) Unsubscribe(req *router.Req, res *router.Res) {
+	token := req.QueryParam("token")
+	if token == "" {
+		res.BadRequest("Unsubscribe token is required", nil)
+		return
+	}
+
+	email, err := c.service.Unsubscribe(token)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidToken):
+			res.BadRequest("Invalid unsubscribe token", nil)
+		case errors.Is(err, ErrUnsubscribeDisabled):
+			res.NotFound("Unsubscribe is not configured", nil)
+		default:
+			res.Error("Failed to unsubscribe", map[string]string{"error": err.Error()})
+		}
+		return
+	}
+
+	res.Success("Unsubscribed successfully", map[string]string{"email": email})
 }
 
 // GetStats handles GET /api/v1/emails/stats
 func (c *Controller) GetStats(req *router.Req, res *router.Res) {
 	// Get email statistics
-	stats, err := c.service.GetStats()
+	stats, err := c.service.GetStats(req.Context())
 	if err != nil {
 		res.Error("Failed to get statistics", map[string]string{"error": err.Error()})
 		return
 	}
 
 	// Return statistics
-	res.Success("Statistics retrieved successfully", stats)
+	res.JSONWithETag("Statistics retrieved successfully", stats)
+}
+
+// GetConfig handles GET /api/v1/emails/config
+func (c *Controller) GetConfig(req *router.Req, res *router.Res) {
+	config, err := c.service.WorkerConfig()
+	if err != nil {
+		res.Error("Failed to get worker configuration", map[string]string{"error": err.Error()})
+		return
+	}
+
+	router.SendSuccess(res, "Worker configuration retrieved successfully", config)
+}
+
+// GetErrors handles GET /api/v1/emails/errors
+func (c *Controller) GetErrors(req *router.Req, res *router.Res) {
+	records, err := c.service.RecentErrors()
+	if err != nil {
+		res.Error("Failed to get errors", map[string]string{"error": err.Error()})
+		return
+	}
+
+	router.SendSuccess(res, "Errors retrieved successfully", records)
 }
 
 // Health handles GET /api/v1/emails/health
 func (c *Controller) Health(req *router.Req, res *router.Res) {
-	// Check if service is running
-	health := map[string]interface{}{
-		"status":    "healthy",
-		"service":   "email",
-		"timestamp": "2024-01-01T00:00:00Z",
-		"version":   "1.0.0",
+	ctx, cancel := context.WithTimeout(req.Context(), 5*time.Second)
+	defer cancel()
+
+	// Check the worker, database and queue
+	health, healthy := c.service.Health(ctx)
+	health["service"] = "email"
+	health["timestamp"] = time.Now().Format(time.RFC3339)
+	health["version"] = core.GetBuildInfo().Version
+
+	if !healthy {
+		health["status"] = "unhealthy"
+		res.Custom(http.StatusServiceUnavailable, "error", "Email service is unhealthy", health)
+		return
 	}
 
+	health["status"] = "healthy"
 	res.Success("Email service is healthy", health)
 }
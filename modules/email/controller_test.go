@@ -0,0 +1,60 @@
+package email
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/thenasky/go-framework/internal/router/testutil"
+	"github.com/thenasky/go-framework/modules/email/models"
+)
+
+// newTestController returns a controller on its own service and in-memory
+// queue, with the worker left stopped so queued emails stay pending
+func newTestController(t *testing.T) *Controller {
+	t.Helper()
+	t.Setenv("EMAIL_QUEUE_BACKEND", "memory")
+	t.Setenv("EMAIL_WORKER_ENABLED", "false")
+	return NewControllerWithService(NewEmailService())
+}
+
+func TestSendEmailQueuesAndReportsStatus(t *testing.T) {
+	controller := newTestController(t)
+
+	ctx := testutil.NewTestContext("POST", "/api/v1/emails/send", models.SendEmailRequest{
+		To: "user@example.com", From: "noreply@example.com", Subject: "Hi", HTML: "<p>Hi</p>", Priority: 2,
+	})
+	ctx.Run(controller.SendEmail)
+	ctx.AssertStatus(t, http.StatusCreated)
+	ctx.AssertEnvelope(t, "success", "Email queued successfully")
+
+	var queued models.EmailResponse
+	if err := ctx.DecodePayload(&queued); err != nil {
+		t.Fatalf("decoding payload: %v", err)
+	}
+
+	status := testutil.NewTestContext("GET", "/api/v1/emails/"+queued.ID+"/status", nil).
+		WithParams(map[string]string{"id": queued.ID}).
+		Run(controller.GetEmailStatus)
+	status.AssertStatus(t, http.StatusOK)
+	status.AssertEnvelope(t, "success", "")
+}
+
+func TestListEmailsRejectsInvalidPage(t *testing.T) {
+	controller := newTestController(t)
+
+	ctx := testutil.NewTestContext("GET", "/api/v1/emails?page=0", nil)
+	ctx.Run(controller.ListEmails)
+	ctx.AssertStatus(t, http.StatusUnprocessableEntity)
+	ctx.AssertValidationError(t, "page")
+}
+
+func TestSendEmailAnswersInRequestedLanguage(t *testing.T) {
+	controller := newTestController(t)
+
+	ctx := testutil.NewTestContext("POST", "/api/v1/emails/send", models.SendEmailRequest{
+		To: "user@example.com", From: "noreply@example.com", Subject: "Hola", HTML: "<p>Hola</p>", Priority: 2,
+	}).WithHeader("Accept-Language", "es-AR,es;q=0.9,en;q=0.8")
+	ctx.Run(controller.SendEmail)
+	ctx.AssertStatus(t, http.StatusCreated)
+	ctx.AssertEnvelope(t, "success", "Correo encolado correctamente")
+}
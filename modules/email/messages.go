@@ -0,0 +1,82 @@
+package email
+
+import "github.com/thenasky/go-framework/internal/router"
+
+// Spanish translations of the email module's response messages
+func init() {
+	router.RegisterMessages("es", map[string]string{
+		// Successful responses
+		"Email queued successfully":                   "Correo encolado correctamente",
+		"Email already queued":                        "El correo ya estaba encolado",
+		"Emails queued successfully":                  "Correos encolados correctamente",
+		"Email preview rendered successfully":         "Vista previa del correo generada correctamente",
+		"Email status retrieved successfully":         "Estado del correo obtenido correctamente",
+		"Email history retrieved successfully":        "Historial del correo obtenido correctamente",
+		"Emails retrieved successfully":               "Correos obtenidos correctamente",
+		"Emails purged successfully":                  "Correos eliminados correctamente",
+		"Campaign status retrieved successfully":      "Estado de la campaña obtenido correctamente",
+		"Statistics retrieved successfully":           "Estadísticas obtenidas correctamente",
+		"Errors retrieved successfully":               "Errores obtenidos correctamente",
+		"Worker configuration retrieved successfully": "Configuración del worker obtenida correctamente",
+		"Email address validated":                     "Dirección de correo validada",
+		"Email service is healthy":                    "El servicio de correo funciona correctamente",
+		"Unsubscribed successfully":                   "Suscripción cancelada correctamente",
+		"Webhook processed successfully":              "Webhook procesado correctamente",
+
+		// Client errors
+		"Invalid request body":          "Cuerpo de la solicitud inválido",
+		"Validation failed":             "La validación falló",
+		"Invalid list parameters":       "Parámetros de listado inválidos",
+		"Invalid purge parameters":      "Parámetros de eliminación inválidos",
+		"Email ID is required":          "Se requiere el ID del correo",
+		"Email not found":               "Correo no encontrado",
+		"Email history not found":       "Historial del correo no encontrado",
+		"Campaign ID is required":       "Se requiere el ID de la campaña",
+		"Campaign not found":            "Campaña no encontrada",
+		"Email is not valid":            "El correo no es válido",
+		"No recipient could be queued":  "No se pudo encolar ningún destinatario",
+		"Unsubscribe is not configured": "La cancelación de suscripción no está configurada",
+		"Unsubscribe token is required": "Se requiere el token de cancelación de suscripción",
+		"Invalid unsubscribe token":     "Token de cancelación de suscripción inválido",
+		"Unsupported webhook provider":  "Proveedor de webhook no soportado",
+		"Invalid webhook signature":     "Firma de webhook inválida",
+
+		// Validation messages
+		"At least one recipient is required":                        "Se requiere al menos un destinatario",
+		"Invalid email ID":                                          "ID de correo inválido",
+		"Date must be in RFC3339 format":                            "La fecha debe estar en formato RFC3339",
+		"Order must be 'asc' or 'desc'":                             "El orden debe ser 'asc' o 'desc'",
+		"Page must be at least 1":                                   "La página debe ser al menos 1",
+		"Page size must be between 1 and %d":                        "El tamaño de página debe estar entre 1 y %d",
+		"Unsupported sort field":                                    "Campo de ordenamiento no soportado",
+		"Status is required":                                        "Se requiere el estado",
+		"Status must be one of failed, sent, bounced or complained": "El estado debe ser failed, sent, bounced o complained",
+		"Sender is not in the list of allowed senders":              "El remitente no está en la lista de remitentes permitidos",
+		"Sender name must not contain line breaks":                  "El nombre del remitente no debe contener saltos de línea",
+		"Recipient name must not contain line breaks":               "El nombre del destinatario no debe contener saltos de línea",
+		"Unsubscribe URL must be an absolute http(s) URL":           "La URL de cancelación debe ser una URL http(s) absoluta",
+		"Signed unsubscribe tokens are not configured":              "Los tokens de cancelación firmados no están configurados",
+		"Attachment filename is required":                           "Se requiere el nombre del archivo adjunto",
+		"Attachment filename must not contain line breaks":          "El nombre del archivo adjunto no debe contener saltos de línea",
+		"Attachment content is required":                            "Se requiere el contenido del archivo adjunto",
+		"Invalid attachment content type":                           "Tipo de contenido del archivo adjunto inválido",
+		"Disposition must be 'attachment' or 'inline'":              "La disposición debe ser 'attachment' o 'inline'",
+		"Inline attachments require a content ID":                   "Los adjuntos en línea requieren un content ID",
+		"Content ID may only contain letters, digits and . _ @ -":   "El content ID solo puede contener letras, dígitos y . _ @ -",
+		"Content IDs must be unique":                                "Los content ID deben ser únicos",
+
+		// Server errors
+		"Failed to send email":               "No se pudo enviar el correo",
+		"Failed to send emails":              "No se pudieron enviar los correos",
+		"Failed to list emails":              "No se pudieron listar los correos",
+		"Failed to get worker configuration": "No se pudo obtener la configuración del worker",
+		"Failed to get email history":        "No se pudo obtener el historial del correo",
+		"Failed to purge emails":             "No se pudieron eliminar los correos",
+		"Failed to get campaign status":      "No se pudo obtener el estado de la campaña",
+		"Failed to get statistics":           "No se pudieron obtener las estadísticas",
+		"Failed to get errors":               "No se pudieron obtener los errores",
+		"Failed to unsubscribe":              "No se pudo cancelar la suscripción",
+		"Failed to read webhook body":        "No se pudo leer el cuerpo del webhook",
+		"Failed to process webhook":          "No se pudo procesar el webhook",
+	})
+}
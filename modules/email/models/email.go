@@ -8,30 +8,116 @@ import (
 
 // EmailJob represents an email job in the queue
 type EmailJob struct {
-	ID            primitive.ObjectID `json:"id" bson:"_id,omitempty"`
-	To            string             `json:"to" bson:"to" validate:"required,email"`
-	Subject       string             `json:"subject" bson:"subject" validate:"required"`
-	HTML          string             `json:"html" bson:"html" validate:"required"`
-	From          string             `json:"from" bson:"from" validate:"required,email"`
-	Status        string             `json:"status" bson:"status"`             // pending, processing, sent, failed
-	Priority      int                `json:"priority" bson:"priority"`         // 1=high, 2=normal, 3=low
-	Attempts      int                `json:"attempts" bson:"attempts"`         // Number of attempts made
-	MaxAttempts   int                `json:"max_attempts" bson:"max_attempts"` // Maximum attempts allowed
-	CreatedAt     time.Time          `json:"created_at" bson:"created_at"`
-	ScheduledAt   time.Time          `json:"scheduled_at" bson:"scheduled_at"`
-	ProcessedAt   *time.Time         `json:"processed_at,omitempty" bson:"processed_at,omitempty"`
-	ErrorMessage  *string            `json:"error_message,omitempty" bson:"error_message,omitempty"`
-	Provider      string             `json:"provider,omitempty" bson:"provider,omitempty"`               // Which provider was used
-	ProviderMsgID string             `json:"provider_msg_id,omitempty" bson:"provider_msg_id,omitempty"` // Provider's message ID
+	ID             primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	To             string             `json:"to" bson:"to" validate:"required,email"`
+	Subject        string             `json:"subject" bson:"subject" validate:"required"`
+	HTML           string             `json:"html" bson:"html" validate:"required"`
+	From           string             `json:"from" bson:"from" validate:"required,email"`
+	Status         string             `json:"status" bson:"status"`             // pending, processing, sent, failed
+	Priority       int                `json:"priority" bson:"priority"`         // 1=high, 2=normal, 3=low
+	Attempts       int                `json:"attempts" bson:"attempts"`         // Number of attempts made
+	MaxAttempts    int                `json:"max_attempts" bson:"max_attempts"` // Maximum attempts allowed
+	CreatedAt      time.Time          `json:"created_at" bson:"created_at"`
+	ScheduledAt    time.Time          `json:"scheduled_at" bson:"scheduled_at"`
+	ProcessedAt    *time.Time         `json:"processed_at,omitempty" bson:"processed_at,omitempty"`
+	ErrorMessage   *string            `json:"error_message,omitempty" bson:"error_message,omitempty"`
+	Provider       string             `json:"provider,omitempty" bson:"provider,omitempty"`               // Which provider was used
+	ProviderMsgID  string             `json:"provider_msg_id,omitempty" bson:"provider_msg_id,omitempty"` // Provider's message ID
+	IdempotencyKey string             `json:"idempotency_key,omitempty" bson:"idempotency_key,omitempty"` // Client key used to detect retried requests
+	DeliveryReason string             `json:"delivery_reason,omitempty" bson:"delivery_reason,omitempty"` // Bounce/complaint reason reported by the provider
+	UnsubscribeURL string             `json:"unsubscribe_url,omitempty" bson:"unsubscribe_url,omitempty"` // Sent as List-Unsubscribe
+	DryRun         bool               `json:"dry_run,omitempty" bson:"dry_run,omitempty"`                 // Go through the queue but skip the provider
+	CampaignID     string             `json:"campaign_id,omitempty" bson:"campaign_id,omitempty"`         // Shared by the jobs of a fan-out send
+	FromName       string             `json:"from_name,omitempty" bson:"from_name,omitempty"`             // Sender display name
+	ToName         string             `json:"to_name,omitempty" bson:"to_name,omitempty"`                 // Recipient display name
+	Cc             []string           `json:"cc,omitempty" bson:"cc,omitempty"`
+	Bcc            []string           `json:"bcc,omitempty" bson:"bcc,omitempty"`             // Envelope only, never written to headers
+	Delivered      []string           `json:"delivered,omitempty" bson:"delivered,omitempty"` // Recipients that accepted the email on a partial delivery
+	Attachments    []Attachment       `json:"attachments,omitempty" bson:"attachments,omitempty"`
+}
+
+// Attachment dispositions
+const (
+	DispositionAttachment = "attachment" // Offered as a file to download
+	DispositionInline     = "inline"     // Shown in the HTML body where it references cid:<content_id>
+)
+
+// Attachment is a file sent with an email. Inline attachments, typically images,
+// are referenced from the HTML body by Content-ID: <img src="cid:logo">.
+type Attachment struct {
+	Filename    string `json:"filename" bson:"filename"`
+	ContentType string `json:"content_type,omitempty" bson:"content_type,omitempty"` // Detected from the filename when empty
+	Content     []byte `json:"content" bson:"content"`                               // Base64 encoded in JSON
+	Disposition string `json:"disposition,omitempty" bson:"disposition,omitempty"`   // attachment (default) or inline
+	ContentID   string `json:"content_id,omitempty" bson:"content_id,omitempty"`     // Required for inline attachments, without angle brackets
 }
 
 // SendEmailRequest represents the API request for sending an email
 type SendEmailRequest struct {
-	To       string `json:"to" validate:"required,email"`
-	Subject  string `json:"subject" validate:"required"`
-	HTML     string `json:"html" validate:"required"`
-	From     string `json:"from" validate:"required,email"`
-	Priority int    `json:"priority" validate:"min=1,max=3"` // 1=high, 2=normal, 3=low
+	To             string                 `json:"to" validate:"required,email"`
+	Subject        string                 `json:"subject" validate:"required"`
+	HTML           string                 `json:"html"`
+	From           string                 `json:"from" validate:"required,email"`
+	Priority       int                    `json:"priority" validate:"min=1,max=3"` // 1=high, 2=normal, 3=low
+	IdempotencyKey string                 `json:"idempotency_key,omitempty"`       // Optional: a repeated key returns the original email
+	UnsubscribeURL string                 `json:"unsubscribe_url,omitempty"`       // Optional: one-click unsubscribe link, {token} is replaced with a signed token
+	DryRun         bool                   `json:"dry_run,omitempty"`               // Optional: process the email without sending it
+	Template       string                 `json:"template,omitempty"`              // Optional: Go html/template source rendered into HTML
+	TemplateData   map[string]interface{} `json:"template_data,omitempty"`         // Optional: values available to Template
+	FromName       string                 `json:"from_name,omitempty"`             // Optional: sender display name, e.g. "José from Acme"
+	ToName         string                 `json:"to_name,omitempty"`               // Optional: recipient display name
+	Cc             []string               `json:"cc,omitempty"`                    // Optional: carbon-copy recipients
+	Bcc            []string               `json:"bcc,omitempty"`                   // Optional: blind carbon-copy recipients, hidden from the others
+	MaxAttempts    int                    `json:"max_attempts,omitempty"`          // Optional: send attempts before the email fails, default EMAIL_MAX_RETRIES
+	Attachments    []Attachment           `json:"attachments,omitempty"`           // Optional: files and inline images
+}
+
+// SendBulkEmailRequest represents the API request for sending one email to many recipients
+type SendBulkEmailRequest struct {
+	Recipients     []string               `json:"recipients"`
+	Subject        string                 `json:"subject"`
+	HTML           string                 `json:"html"`
+	From           string                 `json:"from"`
+	Priority       int                    `json:"priority"`                  // 1=high, 2=normal, 3=low
+	UnsubscribeURL string                 `json:"unsubscribe_url,omitempty"` // Optional: signed per recipient
+	DryRun         bool                   `json:"dry_run,omitempty"`
+	Template       string                 `json:"template,omitempty"`
+	TemplateData   map[string]interface{} `json:"template_data,omitempty"`
+	FromName       string                 `json:"from_name,omitempty"`
+	MaxAttempts    int                    `json:"max_attempts,omitempty"` // Optional: send attempts per recipient
+	Attachments    []Attachment           `json:"attachments,omitempty"`  // Optional: sent to every recipient
+	FanOut         bool                   `json:"fan_out,omitempty"`      // Enqueue one job per recipient under a shared campaign ID
+}
+
+// BulkEmailResult reports what happened to one recipient of a bulk send
+type BulkEmailResult struct {
+	To    string `json:"to"`
+	ID    string `json:"id,omitempty"`
+	Error string `json:"error,omitempty"` // Why the recipient was rejected
+}
+
+// BulkEmailResponse represents the API response of a bulk send
+type BulkEmailResponse struct {
+	CampaignID string            `json:"campaign_id,omitempty"` // Set for fan-out sends
+	Queued     int               `json:"queued"`
+	Rejected   int               `json:"rejected"`
+	Results    []BulkEmailResult `json:"results"`
+}
+
+// CampaignStatus aggregates the status of a fan-out campaign's emails
+type CampaignStatus struct {
+	ID       string           `json:"id"`
+	Total    int64            `json:"total"`
+	ByStatus map[string]int64 `json:"by_status"`
+}
+
+// EmailPreview is the rendered email returned by the preview endpoint
+type EmailPreview struct {
+	To      string `json:"to"`
+	From    string `json:"from"`
+	Subject string `json:"subject"`
+	HTML    string `json:"html"`
+	Text    string `json:"text"`
 }
 
 // EmailResponse represents the API response
@@ -41,19 +127,24 @@ type EmailResponse struct {
 	Message           string    `json:"message"`
 	QueuedAt          time.Time `json:"queued_at"`
 	EstimatedDelivery time.Time `json:"estimated_delivery"`
+	Replayed          bool      `json:"replayed,omitempty"` // True when an existing email was returned for a repeated idempotency key
 }
 
 // EmailStatus represents the current status of an email
 type EmailStatus struct {
-	ID            string     `json:"id"`
-	Status        string     `json:"status"`
-	To            string     `json:"to"`
-	Subject       string     `json:"subject"`
-	CreatedAt     time.Time  `json:"created_at"`
-	ProcessedAt   *time.Time `json:"processed_at,omitempty"`
-	ErrorMessage  *string    `json:"error_message,omitempty"`
-	Provider      string     `json:"provider,omitempty"`
-	ProviderMsgID string     `json:"provider_msg_id,omitempty"`
+	ID             string     `json:"id"`
+	Status         string     `json:"status"`
+	To             string     `json:"to"`
+	Subject        string     `json:"subject"`
+	CreatedAt      time.Time  `json:"created_at"`
+	ProcessedAt    *time.Time `json:"processed_at,omitempty"`
+	ErrorMessage   *string    `json:"error_message,omitempty"`
+	Provider       string     `json:"provider,omitempty"`
+	ProviderMsgID  string     `json:"provider_msg_id,omitempty"`
+	DeliveryReason string     `json:"delivery_reason,omitempty"`
+	DryRun         bool       `json:"dry_run,omitempty"`
+	CampaignID     string     `json:"campaign_id,omitempty"`
+	Delivered      []string   `json:"delivered,omitempty"`
 }
 
 // RateLimit represents rate limiting information
@@ -73,6 +164,39 @@ type EmailStats struct {
 	PendingCount    int64 `json:"pending_count"`
 	ProcessingCount int64 `json:"processing_count"`
 	QueueSize       int64 `json:"queue_size"`
+	DryRun          bool  `json:"dry_run"` // The worker skips providers (EMAIL_DRY_RUN)
+
+	OldestPendingAge  time.Duration `json:"oldest_pending_age"`            // How long the oldest due pending job has waited (nanoseconds)
+	PendingByPriority map[int]int64 `json:"pending_by_priority,omitempty"` // Pending jobs per priority
+
+	LastError   string     `json:"last_error,omitempty"`    // Most recent queue or provider failure
+	LastErrorAt *time.Time `json:"last_error_at,omitempty"` // When LastError occurred
+
+	Providers  []ProviderStatus         `json:"providers,omitempty"`
+	ByProvider map[string]ProviderStats `json:"by_provider,omitempty"` // Outcome of each job's latest attempt, by provider
+}
+
+// ProviderStats counts the jobs a provider handled
+type ProviderStats struct {
+	Sent       int64         `json:"sent"`        // Accepted by the provider, including later bounces and complaints
+	Failed     int64         `json:"failed"`      // Failed on this provider and not sent by another one since
+	AvgLatency time.Duration `json:"avg_latency"` // Average time from due to accepted for sent jobs (nanoseconds)
+}
+
+// ErrorRecord describes a failed queue or provider operation
+type ErrorRecord struct {
+	Operation  string    `json:"operation"` // enqueue, dequeue, send, mark_failed, ...
+	JobID      string    `json:"job_id,omitempty"`
+	Message    string    `json:"message"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+// ProviderStatus represents the health of an email provider
+type ProviderStatus struct {
+	Name                string     `json:"name"`
+	CircuitState        string     `json:"circuit_state"` // closed, open, half_open
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	OpenedAt            *time.Time `json:"opened_at,omitempty"`
 }
 
 // Constants
@@ -81,6 +205,8 @@ const (
 	StatusProcessing = "processing"
 	StatusSent       = "sent"
 	StatusFailed     = "failed"
+	StatusBounced    = "bounced"    // Reported as bounced by the provider after sending
+	StatusComplained = "complained" // Recipient marked the email as spam
 
 	PriorityHigh   = 1
 	PriorityNormal = 2
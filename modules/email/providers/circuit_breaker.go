@@ -0,0 +1,164 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/thenasky/go-framework/modules/email/models"
+)
+
+// Circuit breaker states
+const (
+	CircuitClosed   = "closed"
+	CircuitOpen     = "open"
+	CircuitHalfOpen = "half_open"
+)
+
+// ErrCircuitOpen is returned by Send while a provider's circuit is open
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitBreakerConfig holds the settings of a provider circuit breaker
+type CircuitBreakerConfig struct {
+	FailureThreshold int           `json:"failure_threshold"` // Consecutive failures before opening
+	OpenTimeout      time.Duration `json:"open_timeout"`      // Time before a trial request is allowed
+}
+
+// DefaultCircuitBreakerConfig returns sensible default configuration
+func DefaultCircuitBreakerConfig() *CircuitBreakerConfig {
+	return &CircuitBreakerConfig{
+		FailureThreshold: 5,
+		OpenTimeout:      1 * time.Minute,
+	}
+}
+
+// CircuitBreaker wraps an EmailProvider and stops calling it after repeated
+// failures. Once OpenTimeout has passed a single trial send is let through
+// (half-open); its outcome closes or re-opens the circuit.
+type CircuitBreaker struct {
+	provider            EmailProvider
+	config              *CircuitBreakerConfig
+	state               string
+	consecutiveFailures int
+	openedAt            time.Time
+	trialInFlight       bool
+	mu                  sync.Mutex
+}
+
+// NewCircuitBreaker wraps provider with a circuit breaker
+func NewCircuitBreaker(provider EmailProvider, config *CircuitBreakerConfig) *CircuitBreaker {
+	if config == nil {
+		config = DefaultCircuitBreakerConfig()
+	}
+
+	return &CircuitBreaker{
+		provider: provider,
+		config:   config,
+		state:    CircuitClosed,
+	}
+}
+
+// Send sends through the wrapped provider unless the circuit is open
+func (cb *CircuitBreaker) Send(ctx context.Context, email *models.EmailJob) error {
+	if err := cb.allow(); err != nil {
+		return err
+	}
+
+	err := cb.provider.Send(ctx, email)
+	// A send its caller aborted says nothing about the provider's health
+	if errors.Is(err, context.Canceled) {
+		cb.release()
+		return err
+	}
+	cb.record(err)
+	return err
+}
+
+// allow decides whether a send may go through, moving open circuits to half-open when due
+func (cb *CircuitBreaker) allow() error {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	switch cb.state {
+	case CircuitOpen:
+		if time.Since(cb.openedAt) < cb.config.OpenTimeout {
+			return fmt.Errorf("%s: %w", cb.provider.GetName(), ErrCircuitOpen)
+		}
+		cb.state = CircuitHalfOpen
+		cb.trialInFlight = true
+		return nil
+	case CircuitHalfOpen:
+		// Only one trial request at a time
+		if cb.trialInFlight {
+			return fmt.Errorf("%s: %w", cb.provider.GetName(), ErrCircuitOpen)
+		}
+		cb.trialInFlight = true
+		return nil
+	default:
+		return nil
+	}
+}
+
+// record updates the circuit state with the outcome of a send
+func (cb *CircuitBreaker) record(err error) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.trialInFlight = false
+
+	// A permanent error (e.g. bad recipient) means the provider itself is working
+	if err == nil || IsPermanent(err) {
+		cb.state = CircuitClosed
+		cb.consecutiveFailures = 0
+		return
+	}
+
+	cb.consecutiveFailures++
+	if cb.state == CircuitHalfOpen || cb.consecutiveFailures >= cb.config.FailureThreshold {
+		cb.state = CircuitOpen
+		cb.openedAt = time.Now()
+	}
+}
+
+// release ends a trial send without an outcome, leaving the circuit as it was
+func (cb *CircuitBreaker) release() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.trialInFlight = false
+}
+
+// Status returns the current breaker state of the wrapped provider
+func (cb *CircuitBreaker) Status() models.ProviderStatus {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	status := models.ProviderStatus{
+		Name:                cb.provider.GetName(),
+		CircuitState:        cb.state,
+		ConsecutiveFailures: cb.consecutiveFailures,
+	}
+	if cb.state != CircuitClosed {
+		openedAt := cb.openedAt
+		status.OpenedAt = &openedAt
+	}
+
+	return status
+}
+
+// GetName returns the wrapped provider name
+func (cb *CircuitBreaker) GetName() string {
+	return cb.provider.GetName()
+}
+
+// GetQuota returns the wrapped provider quota
+func (cb *CircuitBreaker) GetQuota() (*QuotaInfo, error) {
+	return cb.provider.GetQuota()
+}
+
+// ValidateEmail validates through the wrapped provider
+func (cb *CircuitBreaker) ValidateEmail(email string) error {
+	return cb.provider.ValidateEmail(email)
+}
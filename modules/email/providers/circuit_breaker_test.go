@@ -0,0 +1,103 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/thenasky/go-framework/modules/email/models"
+)
+
+// fakeProvider returns err from every send and counts the sends that reached it
+type fakeProvider struct {
+	err   error
+	sends int
+}
+
+func (p *fakeProvider) Send(ctx context.Context, email *models.EmailJob) error {
+	p.sends++
+	return p.err
+}
+
+func (p *fakeProvider) GetName() string                  { return "fake" }
+func (p *fakeProvider) GetQuota() (*QuotaInfo, error)    { return &QuotaInfo{Provider: "fake"}, nil }
+func (p *fakeProvider) ValidateEmail(email string) error { return nil }
+
+func TestCircuitBreakerOpensAndRecovers(t *testing.T) {
+	provider := &fakeProvider{err: errors.New("connection refused")}
+	breaker := NewCircuitBreaker(provider, &CircuitBreakerConfig{
+		FailureThreshold: 3,
+		OpenTimeout:      50 * time.Millisecond,
+	})
+	ctx := context.Background()
+	job := &models.EmailJob{To: "user@example.com"}
+
+	for i := 0; i < 3; i++ {
+		if err := breaker.Send(ctx, job); errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("circuit opened after %d failures, want 3", i)
+		}
+	}
+	if state := breaker.Status().CircuitState; state != CircuitOpen {
+		t.Fatalf("state after 3 failures = %s, want %s", state, CircuitOpen)
+	}
+
+	// While open, sends fail fast without reaching the provider
+	if err := breaker.Send(ctx, job); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("send while open = %v, want ErrCircuitOpen", err)
+	}
+	if provider.sends != 3 {
+		t.Fatalf("provider called %d times, want 3", provider.sends)
+	}
+
+	// A failed trial after the cooldown re-opens the circuit at once
+	time.Sleep(60 * time.Millisecond)
+	if err := breaker.Send(ctx, job); errors.Is(err, ErrCircuitOpen) {
+		t.Fatal("trial send rejected after the cooldown")
+	}
+	if state := breaker.Status().CircuitState; state != CircuitOpen {
+		t.Fatalf("state after failed trial = %s, want %s", state, CircuitOpen)
+	}
+
+	// A successful trial closes it
+	time.Sleep(60 * time.Millisecond)
+	provider.err = nil
+	if err := breaker.Send(ctx, job); err != nil {
+		t.Fatalf("trial send: %v", err)
+	}
+	status := breaker.Status()
+	if status.CircuitState != CircuitClosed || status.ConsecutiveFailures != 0 {
+		t.Fatalf("after successful trial: state %s, %d failures; want closed, 0", status.CircuitState, status.ConsecutiveFailures)
+	}
+}
+
+func TestCircuitBreakerAllowsOneTrialAtATime(t *testing.T) {
+	breaker := NewCircuitBreaker(&fakeProvider{}, &CircuitBreakerConfig{FailureThreshold: 1, OpenTimeout: time.Millisecond})
+	breaker.record(errors.New("timeout"))
+	time.Sleep(5 * time.Millisecond)
+
+	if err := breaker.allow(); err != nil {
+		t.Fatalf("first trial rejected: %v", err)
+	}
+	if err := breaker.allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("second concurrent trial = %v, want ErrCircuitOpen", err)
+	}
+
+	// An aborted trial frees the slot without changing the state
+	breaker.release()
+	if err := breaker.allow(); err != nil {
+		t.Fatalf("trial after release rejected: %v", err)
+	}
+}
+
+func TestCircuitBreakerIgnoresPermanentErrors(t *testing.T) {
+	provider := &fakeProvider{err: &ProviderError{Provider: "fake", Code: "550", Permanent: true, Err: errors.New("mailbox unavailable")}}
+	breaker := NewCircuitBreaker(provider, &CircuitBreakerConfig{FailureThreshold: 2, OpenTimeout: time.Hour})
+
+	for i := 0; i < 5; i++ {
+		breaker.Send(context.Background(), &models.EmailJob{})
+	}
+	if state := breaker.Status().CircuitState; state != CircuitClosed {
+		t.Fatalf("state after permanent errors = %s, want %s", state, CircuitClosed)
+	}
+}
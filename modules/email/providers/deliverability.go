@@ -0,0 +1,83 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/thenasky/go-framework/internal/cache"
+)
+
+// MX lookup settings
+const (
+	mxLookupTimeout = 3 * time.Second
+	mxCacheTTL      = 1 * time.Hour
+)
+
+// ErrNoMailExchanger is returned when a domain has no usable MX records
+var ErrNoMailExchanger = errors.New("domain has no mail exchangers")
+
+// mxCacheEntry is a cached MX lookup result
+type mxCacheEntry struct {
+	hosts []string
+	err   error
+}
+
+// mxCache holds MX lookup results by domain
+var mxCache = cache.New[string, mxCacheEntry]("mx", mxCacheTTL)
+
+// ValidateEmailDeliverable performs the syntactic check and then verifies that
+// the domain has at least one mail exchanger. It involves a DNS lookup, so use
+// ValidateEmail in hot paths and this only when deliverability matters.
+func ValidateEmailDeliverable(email string) error {
+	if err := ValidateEmailFormat(email); err != nil {
+		return err
+	}
+
+	domain := email[strings.LastIndex(email, "@")+1:]
+	if _, err := LookupMX(domain); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// LookupMX returns the mail exchanger hosts of a domain ordered by preference.
+// Definitive answers (including "no MX") are cached; transient DNS errors are not.
+func LookupMX(domain string) ([]string, error) {
+	domain = strings.ToLower(strings.TrimSuffix(domain, "."))
+
+	if entry, ok := mxCache.Get(domain); ok {
+		return entry.hosts, entry.err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), mxLookupTimeout)
+	defer cancel()
+
+	records, err := net.DefaultResolver.LookupMX(ctx, domain)
+
+	var hosts []string
+	for _, record := range records {
+		// A single "." is a null MX (RFC 7505): the domain accepts no mail
+		if host := strings.TrimSuffix(record.Host, "."); host != "" {
+			hosts = append(hosts, host)
+		}
+	}
+
+	var dnsErr *net.DNSError
+	switch {
+	case err != nil && errors.As(err, &dnsErr) && dnsErr.IsNotFound:
+		err = fmt.Errorf("%w: %s", ErrNoMailExchanger, domain)
+	case err != nil:
+		return nil, fmt.Errorf("MX lookup for %s failed: %w", domain, err)
+	case len(hosts) == 0:
+		err = fmt.Errorf("%w: %s", ErrNoMailExchanger, domain)
+	}
+
+	mxCache.Set(domain, mxCacheEntry{hosts: hosts, err: err})
+
+	return hosts, err
+}
@@ -0,0 +1,166 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/textproto"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ProviderError describes a failed send so the worker can decide between
+// retrying and giving up without inspecting provider-specific messages.
+// Errors that are neither retryable nor permanent follow the normal retry schedule.
+type ProviderError struct {
+	Provider  string
+	Code      string // Provider status or error code, e.g. SMTP "550" or HTTP "429"
+	Retryable bool   // Transient (rate limit, temporary outage): retry after a backoff
+	Permanent bool   // The email can never be delivered as is (e.g. bad recipient): don't retry
+	Err       error
+}
+
+// Error implements the error interface
+func (e *ProviderError) Error() string {
+	if e.Code == "" {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("%s (code %s)", e.Err.Error(), e.Code)
+}
+
+// Unwrap returns the underlying error
+func (e *ProviderError) Unwrap() error {
+	return e.Err
+}
+
+// IsRetryable reports whether err is a transient provider failure
+func IsRetryable(err error) bool {
+	var providerErr *ProviderError
+	return errors.As(err, &providerErr) && providerErr.Retryable
+}
+
+// IsPermanent reports whether err is a provider failure that retrying cannot fix
+func IsPermanent(err error) bool {
+	var providerErr *ProviderError
+	return errors.As(err, &providerErr) && providerErr.Permanent
+}
+
+// PartialDeliveryError reports a send that reached some recipients but not others.
+// Accepted recipients are recorded on the job so retries only target the rejected ones.
+type PartialDeliveryError struct {
+	Accepted []string
+	Rejected map[string]error // Rejection per recipient address
+}
+
+// Error implements the error interface
+func (e *PartialDeliveryError) Error() string {
+	recipients := make([]string, 0, len(e.Rejected))
+	for recipient := range e.Rejected {
+		recipients = append(recipients, recipient)
+	}
+	sort.Strings(recipients)
+
+	reasons := make([]string, len(recipients))
+	for i, recipient := range recipients {
+		reasons[i] = fmt.Sprintf("%s: %v", recipient, e.Rejected[recipient])
+	}
+	return fmt.Sprintf("delivered to %d recipient(s), rejected %d: %s",
+		len(e.Accepted), len(e.Rejected), strings.Join(reasons, "; "))
+}
+
+// newPartialDeliveryError wraps a partial delivery in a ProviderError that is retryable
+// when any rejection is transient, and permanent when every rejection is permanent
+func newPartialDeliveryError(provider string, accepted []string, rejected map[string]error) error {
+	providerErr := &ProviderError{
+		Provider:  provider,
+		Code:      "partial",
+		Permanent: true,
+		Err:       &PartialDeliveryError{Accepted: accepted, Rejected: rejected},
+	}
+	for _, err := range rejected {
+		if IsRetryable(err) {
+			providerErr.Retryable = true
+		}
+		if !IsPermanent(err) {
+			providerErr.Permanent = false
+		}
+	}
+	if providerErr.Retryable {
+		providerErr.Permanent = false
+	}
+	return providerErr
+}
+
+// classifySMTPError maps SMTP reply codes to a ProviderError: 4xx replies are
+// transient, 5xx replies are permanent except authentication failures, which
+// are a configuration problem rather than a problem with the email.
+func classifySMTPError(err error) error {
+	var protoErr *textproto.Error
+	if !errors.As(err, &protoErr) {
+		return err
+	}
+
+	providerErr := &ProviderError{
+		Provider: "smtp",
+		Code:     strconv.Itoa(protoErr.Code),
+		Err:      err,
+	}
+	switch {
+	case protoErr.Code >= 400 && protoErr.Code < 500:
+		providerErr.Retryable = true
+	case protoErr.Code == 530 || protoErr.Code == 534 || protoErr.Code == 535:
+		// Authentication required or rejected
+	case protoErr.Code >= 500:
+		providerErr.Permanent = true
+	}
+	return providerErr
+}
+
+// classifyTimeout marks a send that ran out of time as retryable: the provider
+// may just be slow or hung, so the job is tried again later. A send aborted by
+// its caller is retryable too, since the email may not have gone out.
+func classifyTimeout(provider string, err error) error {
+	var providerErr *ProviderError
+	if errors.As(err, &providerErr) {
+		return err
+	}
+
+	if errors.Is(err, context.Canceled) {
+		return &ProviderError{
+			Provider:  provider,
+			Code:      "canceled",
+			Retryable: true,
+			Err:       err,
+		}
+	}
+
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return &ProviderError{
+			Provider:  provider,
+			Code:      "timeout",
+			Retryable: true,
+			Err:       err,
+		}
+	}
+	return err
+}
+
+// classifyHTTPStatus maps an HTTP API status code to a ProviderError
+func classifyHTTPStatus(provider string, statusCode int, err error) error {
+	providerErr := &ProviderError{
+		Provider: provider,
+		Code:     strconv.Itoa(statusCode),
+		Err:      err,
+	}
+	switch {
+	case statusCode == 429 || statusCode >= 500:
+		providerErr.Retryable = true
+	case statusCode == 400:
+		// The request itself was rejected (invalid recipient, content, ...)
+		providerErr.Permanent = true
+	}
+	return providerErr
+}
@@ -0,0 +1,104 @@
+package providers
+
+import (
+	"mime"
+	"net/mail"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/thenasky/go-framework/modules/email/models"
+)
+
+// messageHeader is a header added to outgoing messages on top of the standard ones
+type messageHeader struct {
+	Name  string
+	Value string
+}
+
+// encodeHeader prepares free text such as a subject for a message header. Line
+// breaks are replaced so the value can't inject headers, and non-ASCII text is
+// MIME-encoded (RFC 2047), folding long values onto continuation lines.
+func encodeHeader(value string) string {
+	value = strings.NewReplacer("\r\n", " ", "\r", " ", "\n", " ").Replace(value)
+	if isASCII(value) {
+		return value
+	}
+
+	// The encoder splits long text into several encoded-words separated by spaces
+	encoded := mime.BEncoding.Encode("UTF-8", value)
+	return strings.ReplaceAll(encoded, "?= =?", "?=\r\n =?")
+}
+
+// isASCII reports whether s only contains ASCII characters
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// formatAddress builds an address header value such as "Name" <addr>. Non-ASCII
+// names are MIME-encoded (RFC 2047); without a name the bare address is returned.
+func formatAddress(name, address string) string {
+	if name == "" {
+		return address
+	}
+	return (&mail.Address{Name: name, Address: address}).String()
+}
+
+// fromHeader returns the From header for an email sent from the provider's
+// configured sender, replacing its display name with the job's FromName if set
+func fromHeader(configuredFrom string, email *models.EmailJob) string {
+	if email.FromName == "" {
+		return configuredFrom
+	}
+	return formatAddress(email.FromName, extractEmailAddress(configuredFrom))
+}
+
+// toHeader returns the To header for an email
+func toHeader(email *models.EmailJob) string {
+	return formatAddress(email.ToName, email.To)
+}
+
+// recipients returns the To, Cc and Bcc addresses of an email that have not
+// received it yet; recipients reached on an earlier partial delivery are skipped
+func recipients(email *models.EmailJob) (to, cc, bcc []string) {
+	delivered := make(map[string]bool, len(email.Delivered))
+	for _, address := range email.Delivered {
+		delivered[strings.ToLower(address)] = true
+	}
+
+	pending := func(addresses []string) []string {
+		var result []string
+		for _, address := range addresses {
+			if !delivered[strings.ToLower(address)] {
+				result = append(result, address)
+			}
+		}
+		return result
+	}
+	return pending([]string{email.To}), pending(email.Cc), pending(email.Bcc)
+}
+
+// pendingRecipients returns every envelope recipient of an email that has not received it yet
+func pendingRecipients(email *models.EmailJob) []string {
+	to, cc, bcc := recipients(email)
+	return append(append(to, cc...), bcc...)
+}
+
+// extraHeaders returns the additional headers for an email, such as List-Unsubscribe
+func extraHeaders(email *models.EmailJob) []messageHeader {
+	var headers []messageHeader
+
+	// RFC 8058 one-click unsubscribe
+	if email.UnsubscribeURL != "" {
+		headers = append(headers,
+			messageHeader{"List-Unsubscribe", "<" + email.UnsubscribeURL + ">"},
+			messageHeader{"List-Unsubscribe-Post", "List-Unsubscribe=One-Click"},
+		)
+	}
+
+	return headers
+}
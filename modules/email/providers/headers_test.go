@@ -0,0 +1,58 @@
+package providers
+
+import (
+	"bytes"
+	"mime"
+	"net/mail"
+	"strings"
+	"testing"
+)
+
+// TestSubjectRoundTrip builds messages with non-ASCII and multi-line subjects
+// and reads them back as a mail client would
+func TestSubjectRoundTrip(t *testing.T) {
+	tests := []struct {
+		subject string
+		want    string
+	}{
+		{"Plain ASCII", "Plain ASCII"},
+		{"Grüße aus München", "Grüße aus München"},
+		{"注文確認 – ご注文ありがとうございます。発送準備が整い次第、改めてご連絡いたします。", "注文確認 – ご注文ありがとうございます。発送準備が整い次第、改めてご連絡いたします。"},
+		{"Line one\r\nBcc: victim@example.com", "Line one Bcc: victim@example.com"},
+		{"Café\nmenu", "Café menu"},
+	}
+
+	provider := NewSMTPProvider(&ProviderConfig{SMTPHost: "localhost", SMTPFrom: "noreply@example.com"})
+	decoder := new(mime.WordDecoder)
+
+	for _, tt := range tests {
+		email := testEmail()
+		email.Subject = tt.subject
+
+		raw, err := provider.createEmailMessage(email, "test@localhost")
+		if err != nil {
+			t.Fatalf("createEmailMessage(%q): %v", tt.subject, err)
+		}
+		message, err := mail.ReadMessage(bytes.NewReader(raw))
+		if err != nil {
+			t.Fatalf("ReadMessage(%q): %v", tt.subject, err)
+		}
+
+		if message.Header.Get("Bcc") != "" {
+			t.Errorf("subject %q injected a Bcc header", tt.subject)
+		}
+		for _, line := range strings.Split(string(raw), "\r\n") {
+			if len(line) > 998 {
+				t.Errorf("subject %q produced a %d byte line", tt.subject, len(line))
+			}
+		}
+
+		got, err := decoder.DecodeHeader(message.Header.Get("Subject"))
+		if err != nil {
+			t.Fatalf("DecodeHeader(%q): %v", tt.subject, err)
+		}
+		if got != tt.want {
+			t.Errorf("subject %q decoded as %q, want %q", tt.subject, got, tt.want)
+		}
+	}
+}
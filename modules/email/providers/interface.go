@@ -1,13 +1,21 @@
 package providers
 
 import (
+	"context"
+	"time"
+
 	"github.com/thenasky/go-framework/modules/email/models"
 )
 
+// DefaultSendTimeout bounds a single send when ProviderConfig.SendTimeout is unset
+const DefaultSendTimeout = 30 * time.Second
+
 // EmailProvider defines the interface for email service providers
 type EmailProvider interface {
-	// Send sends a single email
-	Send(email *models.EmailJob) error
+	// Send sends a single email. On success providers store the message ID the
+	// provider knows the email by in email.ProviderMsgID, so delivery webhooks
+	// can be matched back to the job. The send is aborted when ctx ends.
+	Send(ctx context.Context, email *models.EmailJob) error
 
 	// GetName returns the provider name
 	GetName() string
@@ -19,6 +27,11 @@ type EmailProvider interface {
 	ValidateEmail(email string) error
 }
 
+// StatusReporter is implemented by providers that can report their health
+type StatusReporter interface {
+	Status() models.ProviderStatus
+}
+
 // QuotaInfo represents provider quota information
 type QuotaInfo struct {
 	Provider    string `json:"provider"`
@@ -37,11 +50,34 @@ type ProviderConfig struct {
 	SMTPUsername string `json:"smtp_username"`
 	SMTPPassword string `json:"smtp_password"`
 	SMTPFrom     string `json:"smtp_from"`
+	// SMTPEncryption is one of the SMTPEncryption* modes; empty means auto
+	SMTPEncryption string `json:"smtp_encryption"`
+	// SMTPAllowInsecureAuth permits AUTH over an unencrypted connection, for local testing only
+	SMTPAllowInsecureAuth bool `json:"smtp_allow_insecure_auth"`
 
 	SendGridAPIKey string `json:"sendgrid_api_key"`
 	SendGridFrom   string `json:"sendgrid_from"`
 
+	SESRegion string `json:"ses_region"`
+	SESFrom   string `json:"ses_from"`
+
+	MailgunDomain  string `json:"mailgun_domain"`
+	MailgunAPIKey  string `json:"mailgun_api_key"`
+	MailgunFrom    string `json:"mailgun_from"`
+	MailgunBaseURL string `json:"mailgun_base_url"` // Optional, e.g. https://api.eu.mailgun.net/v3
+
 	// Rate limiting per provider
 	MaxEmailsPerHour int `json:"max_emails_per_hour"`
 	MaxEmailsPerDay  int `json:"max_emails_per_day"`
+
+	// SendTimeout bounds a whole send, from connecting to the provider's reply
+	SendTimeout time.Duration `json:"send_timeout"`
+}
+
+// sendTimeout returns the configured send timeout, or DefaultSendTimeout
+func (c *ProviderConfig) sendTimeout() time.Duration {
+	if c.SendTimeout > 0 {
+		return c.SendTimeout
+	}
+	return DefaultSendTimeout
 }
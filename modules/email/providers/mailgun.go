@@ -0,0 +1,184 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"strings"
+
+	"github.com/thenasky/go-framework/modules/email/models"
+)
+
+// defaultMailgunBaseURL is the Mailgun API endpoint for the US region
+const defaultMailgunBaseURL = "https://api.mailgun.net/v3"
+
+// MailgunProvider implements EmailProvider for the Mailgun HTTP API
+type MailgunProvider struct {
+	config     *ProviderConfig
+	httpClient *http.Client
+}
+
+// mailgunResponse is the body returned by the Mailgun messages API
+type mailgunResponse struct {
+	ID      string `json:"id"`
+	Message string `json:"message"`
+}
+
+// NewMailgunProvider creates a new Mailgun provider
+func NewMailgunProvider(config *ProviderConfig) *MailgunProvider {
+	return &MailgunProvider{
+		config: config,
+		httpClient: &http.Client{
+			Timeout: config.sendTimeout(),
+		},
+	}
+}
+
+// Send sends an email via the Mailgun API and stores the Mailgun message ID on the job
+func (p *MailgunProvider) Send(ctx context.Context, email *models.EmailJob) error {
+	form := url.Values{}
+	form.Set("from", fromHeader(p.config.MailgunFrom, email))
+	// Recipients reached on an earlier partial delivery are left out
+	to, cc, bcc := recipients(email)
+	if len(to) > 0 {
+		form.Set("to", toHeader(email))
+	}
+	for _, address := range cc {
+		form.Add("cc", address)
+	}
+	for _, address := range bcc {
+		form.Add("bcc", address)
+	}
+	form.Set("subject", email.Subject)
+	form.Set("html", email.HTML)
+	for _, h := range extraHeaders(email) {
+		form.Set("h:"+h.Name, h.Value)
+	}
+
+	baseURL := p.config.MailgunBaseURL
+	if baseURL == "" {
+		baseURL = defaultMailgunBaseURL
+	}
+	endpoint := fmt.Sprintf("%s/%s/messages", strings.TrimSuffix(baseURL, "/"), p.config.MailgunDomain)
+
+	// Attachments are uploaded as files, which needs a multipart form
+	body, contentType := strings.NewReader(form.Encode()), "application/x-www-form-urlencoded"
+	if len(email.Attachments) > 0 {
+		multipartBody, multipartType, err := mailgunMultipartForm(form, email.Attachments)
+		if err != nil {
+			return &ProviderError{Provider: p.GetName(), Permanent: true, Err: err}
+		}
+		body, contentType = multipartBody, multipartType
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, body)
+	if err != nil {
+		return fmt.Errorf("failed to create Mailgun request: %w", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.SetBasicAuth("api", p.config.MailgunAPIKey)
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return classifyTimeout(p.GetName(), fmt.Errorf("Mailgun request failed: %w", err))
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+
+	// The status code decides whether the worker retries (429, 5xx) or gives up (400)
+	if resp.StatusCode != http.StatusOK {
+		var apiResp mailgunResponse
+		message := strings.TrimSpace(string(respBody))
+		if json.Unmarshal(respBody, &apiResp) == nil && apiResp.Message != "" {
+			message = apiResp.Message
+		}
+
+		var sendErr error
+		switch resp.StatusCode {
+		case http.StatusUnauthorized:
+			sendErr = fmt.Errorf("Mailgun API error 401: invalid API key or domain: %s", message)
+		case http.StatusTooManyRequests:
+			sendErr = fmt.Errorf("Mailgun API error 429: rate limit exceeded: %s", message)
+		default:
+			sendErr = fmt.Errorf("Mailgun API error %d: %s", resp.StatusCode, message)
+		}
+		return classifyHTTPStatus(p.GetName(), resp.StatusCode, sendErr)
+	}
+
+	var apiResp mailgunResponse
+	if err := json.Unmarshal(respBody, &apiResp); err != nil {
+		return fmt.Errorf("failed to decode Mailgun response: %w", err)
+	}
+	email.ProviderMsgID = strings.Trim(apiResp.ID, "<>")
+
+	return nil
+}
+
+// mailgunMultipartForm encodes form and attachments as multipart/form-data.
+// Mailgun resolves cid: references by filename, so inline attachments are
+// uploaded under their Content-ID.
+func mailgunMultipartForm(form url.Values, attachments []models.Attachment) (*strings.Reader, string, error) {
+	var body strings.Builder
+	writer := multipart.NewWriter(&body)
+
+	for key, values := range form {
+		for _, value := range values {
+			if err := writer.WriteField(key, value); err != nil {
+				return nil, "", fmt.Errorf("failed to encode Mailgun form: %w", err)
+			}
+		}
+	}
+
+	for _, attachment := range attachments {
+		field, filename := "attachment", attachment.Filename
+		if attachment.Disposition == models.DispositionInline {
+			field, filename = "inline", attachment.ContentID
+		}
+
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Disposition", mime.FormatMediaType("form-data", map[string]string{"name": field, "filename": filename}))
+		header.Set("Content-Type", attachment.ContentType)
+		part, err := writer.CreatePart(header)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to encode Mailgun attachment: %w", err)
+		}
+		if _, err := part.Write(attachment.Content); err != nil {
+			return nil, "", fmt.Errorf("failed to encode Mailgun attachment: %w", err)
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, "", fmt.Errorf("failed to encode Mailgun form: %w", err)
+	}
+	return strings.NewReader(body.String()), writer.FormDataContentType(), nil
+}
+
+// GetName returns the provider name
+func (p *MailgunProvider) GetName() string {
+	return "mailgun"
+}
+
+// GetQuota returns the configured quota (Mailgun doesn't expose a quota API)
+func (p *MailgunProvider) GetQuota() (*QuotaInfo, error) {
+	return &QuotaInfo{
+		Provider:    "mailgun",
+		DailyLimit:  p.config.MaxEmailsPerDay,
+		DailyUsed:   0, // Not tracked
+		HourlyLimit: p.config.MaxEmailsPerHour,
+		HourlyUsed:  0, // Not tracked
+		Remaining:   p.config.MaxEmailsPerHour,
+		ResetTime:   "N/A",
+	}, nil
+}
+
+// ValidateEmail validates an email address format
+func (p *MailgunProvider) ValidateEmail(email string) error {
+	return ValidateEmailFormat(email)
+}
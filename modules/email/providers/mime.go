@@ -0,0 +1,149 @@
+package providers
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"strings"
+
+	"github.com/thenasky/go-framework/modules/email/models"
+)
+
+// base64LineLength is the longest base64 line allowed in a MIME body (RFC 2045)
+const base64LineLength = 76
+
+// mimeBody builds the body of an SMTP message and the Content-Type and
+// Content-Transfer-Encoding headers that go with it. An email without
+// attachments is a single text/html part. Inline attachments are grouped with
+// the HTML in multipart/related so cid: references resolve, and regular
+// attachments wrap everything in multipart/mixed:
+//
+//	multipart/mixed
+//	├── multipart/related
+//	│   ├── text/html
+//	│   └── image/png (inline, Content-ID: <logo>)
+//	└── application/pdf (attachment)
+func mimeBody(email *models.EmailJob) (contentType, transferEncoding string, body []byte, err error) {
+	html := htmlBody(email.HTML)
+
+	var inline, attached []models.Attachment
+	for _, attachment := range email.Attachments {
+		if attachment.Disposition == models.DispositionInline {
+			inline = append(inline, attachment)
+		} else {
+			attached = append(attached, attachment)
+		}
+	}
+
+	if len(inline) == 0 && len(attached) == 0 {
+		return "text/html; charset=UTF-8", "8bit", html, nil
+	}
+
+	// The HTML part, on its own or related to its inline attachments
+	content := mimeEntity{header: htmlPartHeader(), body: html}
+	if len(inline) > 0 {
+		parts := []mimeEntity{content}
+		for _, attachment := range inline {
+			parts = append(parts, attachmentEntity(attachment))
+		}
+		if content, err = multipartEntity("related", parts); err != nil {
+			return "", "", nil, err
+		}
+	}
+
+	if len(attached) > 0 {
+		parts := []mimeEntity{content}
+		for _, attachment := range attached {
+			parts = append(parts, attachmentEntity(attachment))
+		}
+		if content, err = multipartEntity("mixed", parts); err != nil {
+			return "", "", nil, err
+		}
+	}
+
+	return content.header.Get("Content-Type"), "", content.body, nil
+}
+
+// mimeEntity is a MIME part: its headers and its encoded body
+type mimeEntity struct {
+	header textproto.MIMEHeader
+	body   []byte
+}
+
+// multipartEntity combines parts into a multipart entity of the given subtype
+func multipartEntity(subtype string, parts []mimeEntity) (mimeEntity, error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	for _, part := range parts {
+		partWriter, err := writer.CreatePart(part.header)
+		if err != nil {
+			return mimeEntity{}, fmt.Errorf("failed to create MIME part: %w", err)
+		}
+		if _, err := partWriter.Write(part.body); err != nil {
+			return mimeEntity{}, fmt.Errorf("failed to write MIME part: %w", err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return mimeEntity{}, fmt.Errorf("failed to close MIME body: %w", err)
+	}
+
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Type", mime.FormatMediaType("multipart/"+subtype, map[string]string{"boundary": writer.Boundary()}))
+	return mimeEntity{header: header, body: body.Bytes()}, nil
+}
+
+// htmlPartHeader returns the headers of the HTML part of a multipart message
+func htmlPartHeader() textproto.MIMEHeader {
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Type", "text/html; charset=UTF-8")
+	header.Set("Content-Transfer-Encoding", "8bit")
+	return header
+}
+
+// htmlBody normalizes the line endings of an HTML body to CRLF, as SMTP requires
+func htmlBody(html string) []byte {
+	body := strings.ReplaceAll(html, "\r\n", "\n")
+	body = strings.ReplaceAll(body, "\n", "\r\n")
+	if !strings.HasSuffix(body, "\r\n") {
+		body += "\r\n"
+	}
+	return []byte(body)
+}
+
+// attachmentEntity encodes an attachment as a base64 MIME part. Inline
+// attachments get the Content-ID that cid: URLs in the HTML refer to.
+func attachmentEntity(attachment models.Attachment) mimeEntity {
+	contentType, params, err := mime.ParseMediaType(attachment.ContentType)
+	if err != nil {
+		contentType, params = "application/octet-stream", map[string]string{}
+	}
+	params["name"] = attachment.Filename
+
+	disposition := models.DispositionInline
+	if attachment.Disposition != models.DispositionInline {
+		disposition = models.DispositionAttachment
+	}
+
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Type", mime.FormatMediaType(contentType, params))
+	header.Set("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": attachment.Filename}))
+	header.Set("Content-Transfer-Encoding", "base64")
+	if attachment.ContentID != "" {
+		// Set canonicalizes the key to Content-Id; keep the spelling of RFC 2392
+		header["Content-ID"] = []string{"<" + attachment.ContentID + ">"}
+	}
+
+	encoded := base64.StdEncoding.EncodeToString(attachment.Content)
+	var body strings.Builder
+	for len(encoded) > base64LineLength {
+		body.WriteString(encoded[:base64LineLength] + "\r\n")
+		encoded = encoded[base64LineLength:]
+	}
+	body.WriteString(encoded + "\r\n")
+
+	return mimeEntity{header: header, body: []byte(body.String())}
+}
@@ -0,0 +1,123 @@
+package providers
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"net/textproto"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/thenasky/go-framework/modules/email/models"
+)
+
+// mimePart is a decoded leaf part of a parsed message
+type mimePart struct {
+	header textproto.MIMEHeader
+	body   []byte
+}
+
+// readParts parses an entity with the given headers into its leaf parts,
+// grouped by the multipart/related part they belong to (-1 for none)
+func readParts(t *testing.T, header textproto.MIMEHeader, body io.Reader, related int, next *int, parts map[int][]mimePart) {
+	t.Helper()
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		t.Fatalf("parsing Content-Type %q: %v", header.Get("Content-Type"), err)
+	}
+	if header.Get("Content-Transfer-Encoding") == "base64" {
+		body = base64.NewDecoder(base64.StdEncoding, body)
+	}
+
+	if !strings.HasPrefix(mediaType, "multipart/") {
+		data, err := io.ReadAll(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		parts[related] = append(parts[related], mimePart{header: header, body: data})
+		return
+	}
+
+	if mediaType == "multipart/related" {
+		related = *next
+		*next++
+	}
+	reader := multipart.NewReader(body, params["boundary"])
+	for {
+		part, err := reader.NextRawPart()
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			t.Fatalf("reading %s part: %v", mediaType, err)
+		}
+		readParts(t, part.Header, part, related, next, parts)
+	}
+}
+
+// cidReference matches the cid: URLs in an HTML body
+var cidReference = regexp.MustCompile(`cid:([^"'\s>]+)`)
+
+func TestInlineImagesResolveByContentID(t *testing.T) {
+	logo := []byte("\x89PNG\r\n\x1a\nnot really a png")
+	email := testEmail()
+	email.HTML = `<p><img src="cid:logo@example.com"> Your invoice is attached.</p>`
+	email.Attachments = []models.Attachment{
+		{Filename: "invoice.pdf", ContentType: "application/pdf", Content: []byte("%PDF-1.4")},
+		{Filename: "logo.png", ContentType: "image/png", Content: logo, Disposition: models.DispositionInline, ContentID: "logo@example.com"},
+	}
+
+	provider := NewSMTPProvider(&ProviderConfig{SMTPHost: "localhost", SMTPFrom: "noreply@example.com"})
+	raw, err := provider.createEmailMessage(email, "test@localhost")
+	if err != nil {
+		t.Fatalf("createEmailMessage: %v", err)
+	}
+	message, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatalf("ReadMessage: %v", err)
+	}
+
+	parts := map[int][]mimePart{}
+	next := 0
+	readParts(t, textproto.MIMEHeader(message.Header), message.Body, -1, &next, parts)
+
+	if next != 1 || len(parts[0]) != 2 || len(parts[-1]) != 1 {
+		t.Fatalf("want the HTML and the logo in multipart/related and the invoice outside it; got %d related groups, %d related parts, %d others",
+			next, len(parts[0]), len(parts[-1]))
+	}
+
+	// Every cid: URL in the HTML names an inline part of the same multipart/related
+	related := parts[0]
+	if contentType := related[0].header.Get("Content-Type"); !strings.HasPrefix(contentType, "text/html") {
+		t.Fatalf("first related part is %s, want text/html", contentType)
+	}
+	contentIDs := map[string]mimePart{}
+	for _, part := range related[1:] {
+		contentIDs[strings.Trim(part.header.Get("Content-ID"), "<>")] = part
+	}
+	references := cidReference.FindAllStringSubmatch(string(related[0].body), -1)
+	if len(references) != 1 {
+		t.Fatalf("found %d cid references in the HTML, want 1", len(references))
+	}
+	for _, reference := range references {
+		part, ok := contentIDs[reference[1]]
+		if !ok {
+			t.Fatalf("cid:%s has no matching Content-ID", reference[1])
+		}
+		if !bytes.Equal(part.body, logo) {
+			t.Errorf("inline part content = %q, want the logo", part.body)
+		}
+		if disposition, _, _ := mime.ParseMediaType(part.header.Get("Content-Disposition")); disposition != models.DispositionInline {
+			t.Errorf("inline part disposition = %s, want inline", disposition)
+		}
+	}
+
+	invoice := parts[-1][0]
+	if contentType := invoice.header.Get("Content-Type"); !strings.HasPrefix(contentType, "application/pdf") || string(invoice.body) != "%PDF-1.4" {
+		t.Errorf("attachment = %s %q, want the invoice", contentType, invoice.body)
+	}
+}
@@ -0,0 +1,179 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/sesv2"
+	"github.com/aws/aws-sdk-go-v2/service/sesv2/types"
+	"github.com/aws/smithy-go"
+
+	"github.com/thenasky/go-framework/modules/email/models"
+)
+
+// sesRequestTimeout bounds every call made to the SES API
+const sesRequestTimeout = 30 * time.Second
+
+// SESProvider implements EmailProvider for Amazon SES (API v2)
+type SESProvider struct {
+	config *ProviderConfig
+	client *sesv2.Client
+}
+
+// NewSESProvider creates a new SES provider. Region and credentials are resolved
+// through the standard AWS chain (AWS_REGION, AWS_ACCESS_KEY_ID, shared config, IAM role...)
+func NewSESProvider(config *ProviderConfig) (*SESProvider, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), sesRequestTimeout)
+	defer cancel()
+
+	var opts []func(*awsconfig.LoadOptions) error
+	if config.SESRegion != "" {
+		opts = append(opts, awsconfig.WithRegion(config.SESRegion))
+	}
+
+	awsConfig, err := awsconfig.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS configuration: %w", err)
+	}
+
+	if awsConfig.Region == "" {
+		return nil, fmt.Errorf("AWS region is not configured")
+	}
+
+	return &SESProvider{
+		config: config,
+		client: sesv2.NewFromConfig(awsConfig),
+	}, nil
+}
+
+// Send sends an email via SES and stores the SES message ID on the job
+func (p *SESProvider) Send(ctx context.Context, email *models.EmailJob) error {
+	ctx, cancel := context.WithTimeout(ctx, p.config.sendTimeout())
+	defer cancel()
+
+	// Recipients reached on an earlier partial delivery are left out
+	to, cc, bcc := recipients(email)
+	destination := &types.Destination{CcAddresses: cc, BccAddresses: bcc}
+	if len(to) > 0 {
+		destination.ToAddresses = []string{toHeader(email)}
+	}
+
+	input := &sesv2.SendEmailInput{
+		FromEmailAddress: aws.String(fromHeader(p.config.SESFrom, email)),
+		Destination:      destination,
+		Content: &types.EmailContent{
+			Simple: &types.Message{
+				Subject: &types.Content{
+					Data:    aws.String(email.Subject),
+					Charset: aws.String("UTF-8"),
+				},
+				Body: &types.Body{
+					Html: &types.Content{
+						Data:    aws.String(email.HTML),
+						Charset: aws.String("UTF-8"),
+					},
+				},
+			},
+		},
+	}
+
+	// SES builds the multipart structure itself, relating inline parts to the HTML
+	for _, attachment := range email.Attachments {
+		sesAttachment := types.Attachment{
+			FileName:           aws.String(attachment.Filename),
+			RawContent:         attachment.Content,
+			ContentType:        aws.String(attachment.ContentType),
+			ContentDisposition: types.AttachmentContentDispositionAttachment,
+		}
+		if attachment.Disposition == models.DispositionInline {
+			sesAttachment.ContentDisposition = types.AttachmentContentDispositionInline
+		}
+		if attachment.ContentID != "" {
+			sesAttachment.ContentId = aws.String(attachment.ContentID)
+		}
+		input.Content.Simple.Attachments = append(input.Content.Simple.Attachments, sesAttachment)
+	}
+
+	for _, h := range extraHeaders(email) {
+		input.Content.Simple.Headers = append(input.Content.Simple.Headers, types.MessageHeader{
+			Name:  aws.String(h.Name),
+			Value: aws.String(h.Value),
+		})
+	}
+
+	output, err := p.client.SendEmail(ctx, input)
+	if err != nil {
+		return classifyTimeout(p.GetName(), classifySESError(fmt.Errorf("SES send failed: %w", err)))
+	}
+
+	if output.MessageId != nil {
+		email.ProviderMsgID = *output.MessageId
+	}
+
+	return nil
+}
+
+// GetName returns the provider name
+func (p *SESProvider) GetName() string {
+	return "ses"
+}
+
+// GetQuota returns the sending quota reported by SES
+func (p *SESProvider) GetQuota() (*QuotaInfo, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), sesRequestTimeout)
+	defer cancel()
+
+	// SES v2 reports the send quota as part of the account details
+	output, err := p.client.GetAccount(ctx, &sesv2.GetAccountInput{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get SES quota: %w", err)
+	}
+
+	quota := &QuotaInfo{
+		Provider:    "ses",
+		DailyLimit:  p.config.MaxEmailsPerDay,
+		HourlyLimit: p.config.MaxEmailsPerHour,
+		ResetTime:   "rolling 24h",
+	}
+
+	if output.SendQuota != nil {
+		quota.DailyLimit = int(output.SendQuota.Max24HourSend)
+		quota.DailyUsed = int(output.SendQuota.SentLast24Hours)
+		quota.HourlyLimit = int(output.SendQuota.MaxSendRate * 3600)
+	}
+	quota.Remaining = quota.DailyLimit - quota.DailyUsed
+
+	return quota, nil
+}
+
+// ValidateEmail validates an email address format
+func (p *SESProvider) ValidateEmail(email string) error {
+	return ValidateEmailFormat(email)
+}
+
+// classifySESError maps SES API error codes to a ProviderError
+func classifySESError(err error) error {
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) {
+		return err
+	}
+
+	providerErr := &ProviderError{
+		Provider: "ses",
+		Code:     apiErr.ErrorCode(),
+		Err:      err,
+	}
+	switch apiErr.ErrorCode() {
+	case "TooManyRequestsException", "LimitExceededException", "Throttling", "ThrottlingException":
+		providerErr.Retryable = true
+	case "MessageRejected", "BadRequestException":
+		providerErr.Permanent = true
+	default:
+		providerErr.Retryable = apiErr.ErrorFault() == smithy.FaultServer
+	}
+	return providerErr
+}
@@ -1,16 +1,47 @@
 package providers
 
 import (
+	"context"
 	"crypto/tls"
+	"errors"
 	"fmt"
 	"log"
+	"net"
 	"net/smtp"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/thenasky/go-framework/modules/email/models"
 )
 
+// SMTP encryption modes for ProviderConfig.SMTPEncryption
+const (
+	// SMTPEncryptionAuto picks STARTTLS on 587, implicit TLS on 465 and
+	// STARTTLS whenever the server offers it on any other port
+	SMTPEncryptionAuto = "auto"
+	// SMTPEncryptionSTARTTLS always upgrades the connection with STARTTLS
+	SMTPEncryptionSTARTTLS = "starttls"
+	// SMTPEncryptionTLS connects over implicit TLS
+	SMTPEncryptionTLS = "tls"
+	// SMTPEncryptionNone never encrypts the connection
+	SMTPEncryptionNone = "none"
+)
+
+// ErrInsecureAuth is returned instead of sending credentials over an unencrypted connection
+var ErrInsecureAuth = errors.New("refusing to send SMTP credentials over an unencrypted connection; " +
+	"use an encrypted SMTP_ENCRYPTION mode or set SMTP_ALLOW_INSECURE_AUTH=true for local testing")
+
+// ValidSMTPEncryption reports whether mode is a known SMTP encryption mode
+func ValidSMTPEncryption(mode string) bool {
+	switch mode {
+	case "", SMTPEncryptionAuto, SMTPEncryptionSTARTTLS, SMTPEncryptionTLS, SMTPEncryptionNone:
+		return true
+	default:
+		return false
+	}
+}
+
 // SMTPProvider implements EmailProvider for SMTP
 type SMTPProvider struct {
 	config *ProviderConfig
@@ -37,44 +68,93 @@ func NewSMTPProvider(config *ProviderConfig) *SMTPProvider {
 	}
 }
 
-// Send sends an email via SMTP
-func (p *SMTPProvider) Send(email *models.EmailJob) error {
-	// Set default values if not provided
-	if p.config.SMTPFrom == "" {
-		p.config.SMTPFrom = p.config.SMTPUsername
-	}
+// Send sends an email via SMTP and stores the Message-ID header it generated on the job
+func (p *SMTPProvider) Send(ctx context.Context, email *models.EmailJob) error {
+	ctx, cancel := context.WithTimeout(ctx, p.config.sendTimeout())
+	defer cancel()
 
 	// Create email message
-	message := p.createEmailMessage(email)
+	messageID := p.messageID(email)
+	message, err := p.createEmailMessage(email, messageID)
+	if err != nil {
+		// Building the same email again would fail the same way
+		return &ProviderError{Provider: p.GetName(), Permanent: true, Err: err}
+	}
 
-	// Connect to SMTP server
-	auth := smtp.PlainAuth("", p.config.SMTPUsername, p.config.SMTPPassword, p.config.SMTPHost)
+	// Servers without authentication are used when no username is configured
+	var auth smtp.Auth
+	if p.config.SMTPUsername != "" {
+		auth = smtp.PlainAuth("", p.config.SMTPUsername, p.config.SMTPPassword, p.config.SMTPHost)
+	}
 
-	// Determine if we need TLS
-	var err error
-	if p.config.SMTPPort == 587 {
-		// Use STARTTLS
-		err = p.sendWithSTARTTLS(auth, message, email)
-	} else if p.config.SMTPPort == 465 {
-		// Use SSL/TLS
-		err = p.sendWithTLS(auth, message, email)
-	} else {
-		// Use plain SMTP
-		err = p.sendPlain(auth, message, email)
+	switch p.encryption() {
+	case SMTPEncryptionSTARTTLS:
+		err = p.sendWithSTARTTLS(ctx, auth, message, email)
+	case SMTPEncryptionTLS:
+		err = p.sendWithTLS(ctx, auth, message, email)
+	case SMTPEncryptionNone:
+		err = p.sendPlain(ctx, auth, message, email, false)
+	default:
+		// Unknown ports still upgrade when the server offers STARTTLS
+		err = p.sendPlain(ctx, auth, message, email, true)
 	}
 
 	if err != nil {
+		// An aborted connection fails with an I/O timeout; report why it was aborted
+		if ctxErr := ctx.Err(); ctxErr != nil && !errors.Is(err, ctxErr) {
+			err = fmt.Errorf("%w: %w", ctxErr, err)
+		}
 		// Log the email message for debugging
 		log.Printf("SMTP send failed for email to %s: %v", email.To, err)
 		log.Printf("Email message content: %s", string(message))
-		return fmt.Errorf("SMTP send failed: %w", err)
+		return classifyTimeout(p.GetName(), classifySMTPError(fmt.Errorf("SMTP send failed: %w", err)))
 	}
 
+	// Bounce reports quote the Message-ID, so it identifies the email with the provider
+	email.ProviderMsgID = messageID
+
 	return nil
 }
 
+// messageID generates the Message-ID for an email, without the angle brackets
+func (p *SMTPProvider) messageID(email *models.EmailJob) string {
+	return fmt.Sprintf("%d.%s@%s", time.Now().UnixNano(), email.ID.Hex(), p.config.SMTPHost)
+}
+
+// sender returns the configured sender address, falling back to the SMTP username.
+// The config is shared by concurrent sends, so it is never modified here.
+func (p *SMTPProvider) sender() string {
+	if p.config.SMTPFrom != "" {
+		return p.config.SMTPFrom
+	}
+	return p.config.SMTPUsername
+}
+
+// encryption resolves the configured encryption mode, mapping auto to a
+// concrete mode for the well-known submission ports
+func (p *SMTPProvider) encryption() string {
+	if mode := p.config.SMTPEncryption; mode != "" && mode != SMTPEncryptionAuto {
+		return mode
+	}
+
+	switch p.config.SMTPPort {
+	case 587:
+		return SMTPEncryptionSTARTTLS
+	case 465:
+		return SMTPEncryptionTLS
+	default:
+		return SMTPEncryptionAuto
+	}
+}
+
 // createEmailMessage creates the email message in proper format
-func (p *SMTPProvider) createEmailMessage(email *models.EmailJob) []byte {
+func (p *SMTPProvider) createEmailMessage(email *models.EmailJob, messageID string) ([]byte, error) {
+	// The body decides the content type: plain HTML, or multipart with attachments
+	contentType, transferEncoding, body, err := mimeBody(email)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build message body: %w", err)
+	}
+
 	// Create headers with proper RFC 5322 format in consistent order
 	type header struct {
 		key   string
@@ -82,14 +162,19 @@ func (p *SMTPProvider) createEmailMessage(email *models.EmailJob) []byte {
 	}
 
 	headers := []header{
-		{"From", p.config.SMTPFrom},
-		{"To", email.To},
-		{"Subject", email.Subject},
+		{"From", fromHeader(p.sender(), email)},
+		{"To", toHeader(email)},
+		// Bcc recipients only appear on the envelope, never in the headers
+		{"Cc", strings.Join(email.Cc, ", ")},
+		{"Subject", encodeHeader(email.Subject)},
 		{"Date", time.Now().Format("Mon, 02 Jan 2006 15:04:05 -0700")},
-		{"Message-ID", fmt.Sprintf("<%d.%s@%s>", time.Now().UnixNano(), email.ID.Hex(), p.config.SMTPHost)},
+		{"Message-ID", "<" + messageID + ">"},
 		{"MIME-Version", "1.0"},
-		{"Content-Type", "text/html; charset=UTF-8"},
-		{"Content-Transfer-Encoding", "8bit"},
+		{"Content-Type", contentType},
+		{"Content-Transfer-Encoding", transferEncoding},
+	}
+	for _, h := range extraHeaders(email) {
+		headers = append(headers, header{h.Name, h.Value})
 	}
 
 	// Build message
@@ -97,6 +182,9 @@ func (p *SMTPProvider) createEmailMessage(email *models.EmailJob) []byte {
 
 	// Add headers in consistent order
 	for _, h := range headers {
+		if h.value == "" {
+			continue
+		}
 		message.WriteString(fmt.Sprintf("%s: %s\r\n", h.key, h.value))
 	}
 
@@ -104,19 +192,8 @@ func (p *SMTPProvider) createEmailMessage(email *models.EmailJob) []byte {
 	// This creates the required separation: \r\n\r\n
 	message.WriteString("\r\n")
 
-	// Add body with proper line ending handling
-	// Ensure HTML content doesn't break SMTP formatting
-	body := strings.ReplaceAll(email.HTML, "\n", "\r\n")
-	// Remove any carriage returns that might cause issues
-	body = strings.ReplaceAll(body, "\r\r", "\r")
-
-	// Write the body content
-	message.WriteString(body)
-
-	// Ensure message ends with proper line ending
-	if !strings.HasSuffix(body, "\r\n") {
-		message.WriteString("\r\n")
-	}
+	// Write the body content, already using CRLF line endings
+	message.Write(body)
 
 	// Log the message for debugging (remove in production)
 	messageStr := message.String()
@@ -136,14 +213,52 @@ func (p *SMTPProvider) createEmailMessage(email *models.EmailJob) []byte {
 		log.Printf("✓ Body section:\n%s", parts[1])
 	}
 
-	return []byte(messageStr)
+	return []byte(messageStr), nil
+}
+
+// dial connects to the SMTP server, over TLS when useTLS is set. The connection
+// gets ctx's deadline, covering the whole send so a hung server can't block a
+// worker, and is interrupted as soon as ctx is cancelled.
+func (p *SMTPProvider) dial(ctx context.Context, useTLS bool) (*smtp.Client, error) {
+	host := net.JoinHostPort(p.config.SMTPHost, strconv.Itoa(p.config.SMTPPort))
+
+	var conn net.Conn
+	var err error
+	if useTLS {
+		dialer := &tls.Dialer{Config: &tls.Config{ServerName: p.config.SMTPHost}}
+		conn, err = dialer.DialContext(ctx, "tcp", host)
+	} else {
+		conn, err = (&net.Dialer{}).DialContext(ctx, "tcp", host)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+
+	// Unblock any read or write in progress when ctx is cancelled. Send cancels
+	// ctx when it returns, which then only touches the closed connection.
+	context.AfterFunc(ctx, func() {
+		conn.SetDeadline(time.Now())
+	})
+
+	client, err := smtp.NewClient(conn, p.config.SMTPHost)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return client, nil
 }
 
 // sendWithSTARTTLS sends email using STARTTLS
-func (p *SMTPProvider) sendWithSTARTTLS(auth smtp.Auth, message []byte, email *models.EmailJob) error {
+func (p *SMTPProvider) sendWithSTARTTLS(ctx context.Context, auth smtp.Auth, message []byte, email *models.EmailJob) error {
 	// Connect to server
-	host := fmt.Sprintf("%s:%d", p.config.SMTPHost, p.config.SMTPPort)
-	client, err := smtp.Dial(host)
+	client, err := p.dial(ctx, false)
 	if err != nil {
 		return err
 	}
@@ -155,71 +270,115 @@ func (p *SMTPProvider) sendWithSTARTTLS(auth smtp.Auth, message []byte, email *m
 	}
 
 	// Authenticate
-	if err = client.Auth(auth); err != nil {
-		return err
+	if auth != nil {
+		if err = client.Auth(auth); err != nil {
+			return err
+		}
 	}
 
-	// Send email - FIXED: Extract email address from display name format
-	fromEmail := extractEmailAddress(p.config.SMTPFrom)
-	if err = client.Mail(fromEmail); err != nil {
+	return p.deliver(client, message, email)
+}
+
+// sendWithTLS sends email using SSL/TLS
+func (p *SMTPProvider) sendWithTLS(ctx context.Context, auth smtp.Auth, message []byte, email *models.EmailJob) error {
+	// Connect with TLS
+	client, err := p.dial(ctx, true)
+	if err != nil {
 		return err
 	}
-	if err = client.Rcpt(email.To); err != nil {
-		return err
+	defer client.Close()
+
+	// Authenticate
+	if auth != nil {
+		if err = client.Auth(auth); err != nil {
+			return err
+		}
 	}
 
-	// Write message
-	w, err := client.Data()
+	return p.deliver(client, message, email)
+}
+
+// sendPlain sends email over an unencrypted connection. With upgrade set it
+// switches to STARTTLS when the server offers it, like smtp.SendMail does.
+func (p *SMTPProvider) sendPlain(ctx context.Context, auth smtp.Auth, message []byte, email *models.EmailJob, upgrade bool) error {
+	client, err := p.dial(ctx, false)
 	if err != nil {
 		return err
 	}
-	_, err = w.Write(message)
-	if err != nil {
-		return err
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok && upgrade {
+		if err = client.StartTLS(&tls.Config{ServerName: p.config.SMTPHost}); err != nil {
+			return err
+		}
 	}
-	err = w.Close()
-	if err != nil {
-		return err
+
+	if auth != nil {
+		// Never leak credentials over plaintext unless explicitly allowed
+		if _, encrypted := client.TLSConnectionState(); !encrypted {
+			if !p.config.SMTPAllowInsecureAuth {
+				return ErrInsecureAuth
+			}
+			auth = insecureAuth{auth}
+		}
+		if ok, _ := client.Extension("AUTH"); !ok {
+			return errors.New("smtp: server doesn't support AUTH")
+		}
+		if err = client.Auth(auth); err != nil {
+			return err
+		}
 	}
 
-	return client.Quit()
+	return p.deliver(client, message, email)
 }
 
-// sendWithTLS sends email using SSL/TLS
-func (p *SMTPProvider) sendWithTLS(auth smtp.Auth, message []byte, email *models.EmailJob) error {
-	host := fmt.Sprintf("%s:%d", p.config.SMTPHost, p.config.SMTPPort)
-
-	// Create TLS config
-	tlsConfig := &tls.Config{
-		ServerName: p.config.SMTPHost,
-	}
+// insecureAuth lets smtp.PlainAuth run over an unencrypted connection, which it
+// otherwise refuses for any host but localhost
+type insecureAuth struct {
+	smtp.Auth
+}
 
-	// Connect with TLS
-	conn, err := tls.Dial("tcp", host, tlsConfig)
-	if err != nil {
-		return err
-	}
-	defer conn.Close()
+// Start reports the connection as encrypted to the wrapped auth
+func (a insecureAuth) Start(server *smtp.ServerInfo) (string, []byte, error) {
+	info := *server
+	info.TLS = true
+	return a.Auth.Start(&info)
+}
 
-	// Create SMTP client
-	client, err := smtp.NewClient(conn, p.config.SMTPHost)
-	if err != nil {
+// deliver sends the message over an established connection. Every recipient is
+// offered to the server even when others are rejected, so one bad address
+// doesn't stop the rest. Recipients that accept the message are added to
+// email.Delivered and skipped on later attempts; when some are rejected a
+// PartialDeliveryError is returned so only those are retried.
+func (p *SMTPProvider) deliver(client *smtp.Client, message []byte, email *models.EmailJob) error {
+	// Extract email address from display name format
+	sender := p.sender()
+	fromEmail := extractEmailAddress(sender)
+	log.Printf("SMTP MAIL FROM: %s (extracted from: %s)", fromEmail, sender)
+	if err := client.Mail(fromEmail); err != nil {
 		return err
 	}
-	defer client.Close()
 
-	// Authenticate
-	if err = client.Auth(auth); err != nil {
-		return err
+	var accepted []string
+	rejected := map[string]error{}
+	var firstRejection error
+	for _, recipient := range pendingRecipients(email) {
+		if err := client.Rcpt(recipient); err != nil {
+			rejected[recipient] = classifySMTPError(err)
+			if firstRejection == nil {
+				firstRejection = err
+			}
+			continue
+		}
+		accepted = append(accepted, recipient)
 	}
 
-	// Send email - FIXED: Extract email address from display name format
-	fromEmail := extractEmailAddress(p.config.SMTPFrom)
-	if err = client.Mail(fromEmail); err != nil {
-		return err
-	}
-	if err = client.Rcpt(email.To); err != nil {
-		return err
+	// Nobody accepted the message: report it like a single failed recipient
+	if len(accepted) == 0 {
+		if firstRejection == nil {
+			return errors.New("no recipients left to deliver to")
+		}
+		return firstRejection
 	}
 
 	// Write message
@@ -227,25 +386,20 @@ func (p *SMTPProvider) sendWithTLS(auth smtp.Auth, message []byte, email *models
 	if err != nil {
 		return err
 	}
-	_, err = w.Write(message)
-	if err != nil {
+	if _, err = w.Write(message); err != nil {
 		return err
 	}
-	err = w.Close()
-	if err != nil {
+	if err = w.Close(); err != nil {
 		return err
 	}
+	email.Delivered = append(email.Delivered, accepted...)
 
-	return client.Quit()
-}
+	if len(rejected) > 0 {
+		client.Quit()
+		return newPartialDeliveryError(p.GetName(), accepted, rejected)
+	}
 
-// sendPlain sends email using plain SMTP
-func (p *SMTPProvider) sendPlain(auth smtp.Auth, message []byte, email *models.EmailJob) error {
-	host := fmt.Sprintf("%s:%d", p.config.SMTPHost, p.config.SMTPPort)
-	// FIXED: Extract email address from display name format
-	fromEmail := extractEmailAddress(p.config.SMTPFrom)
-	log.Printf("SMTP MAIL FROM: %s (extracted from: %s)", fromEmail, p.config.SMTPFrom)
-	return smtp.SendMail(host, auth, fromEmail, []string{email.To}, message)
+	return client.Quit()
 }
 
 // GetName returns the provider name
@@ -268,6 +422,11 @@ func (p *SMTPProvider) GetQuota() (*QuotaInfo, error) {
 
 // ValidateEmail validates an email address format
 func (p *SMTPProvider) ValidateEmail(email string) error {
+	return ValidateEmailFormat(email)
+}
+
+// ValidateEmailFormat performs the cheap syntactic check shared by all providers
+func ValidateEmailFormat(email string) error {
 	if email == "" {
 		return fmt.Errorf("email address is empty")
 	}
@@ -0,0 +1,265 @@
+package providers
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/thenasky/go-framework/modules/email/models"
+)
+
+// testEmail returns a job for user@example.com
+func testEmail() *models.EmailJob {
+	return &models.EmailJob{
+		ID:      primitive.NewObjectID(),
+		To:      "user@example.com",
+		From:    "noreply@example.com",
+		Subject: "Hello",
+		HTML:    "<p>Hello</p>",
+	}
+}
+
+// testSMTPConfig returns a provider config for the server listening on addr
+func testSMTPConfig(t *testing.T, addr net.Addr) *ProviderConfig {
+	t.Helper()
+	host, port, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	portNumber, _ := strconv.Atoi(port)
+	return &ProviderConfig{
+		SMTPHost:       host,
+		SMTPPort:       portNumber,
+		SMTPFrom:       "noreply@example.com",
+		SMTPEncryption: SMTPEncryptionNone,
+	}
+}
+
+// fakeSMTPServer is a minimal plaintext SMTP server that accepts every
+// recipient and records the commands and messages it receives
+type fakeSMTPServer struct {
+	listener net.Listener
+	auth     bool // Advertise AUTH PLAIN
+
+	mu       sync.Mutex
+	commands []string
+	messages []string
+}
+
+// newFakeSMTPServer starts a fake server that is closed when the test ends
+func newFakeSMTPServer(t *testing.T, auth bool) *fakeSMTPServer {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &fakeSMTPServer{listener: listener, auth: auth}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.serve(conn)
+		}
+	}()
+	return server
+}
+
+// serve speaks SMTP on a single connection
+func (s *fakeSMTPServer) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	reply := func(lines ...string) {
+		conn.Write([]byte(strings.Join(lines, "\r\n") + "\r\n"))
+	}
+
+	reply("220 localhost ESMTP")
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		verb := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+
+		s.mu.Lock()
+		s.commands = append(s.commands, verb)
+		s.mu.Unlock()
+
+		switch verb {
+		case "EHLO":
+			if s.auth {
+				reply("250-localhost", "250-AUTH PLAIN", "250 8BITMIME")
+			} else {
+				reply("250-localhost", "250 8BITMIME")
+			}
+		case "AUTH":
+			reply("235 Authentication successful")
+		case "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var data strings.Builder
+			for {
+				line, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if line == ".\r\n" {
+					break
+				}
+				data.WriteString(line)
+			}
+			s.mu.Lock()
+			s.messages = append(s.messages, data.String())
+			s.mu.Unlock()
+			reply("250 OK")
+		case "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
+
+// received reports whether the server got verb from any client
+func (s *fakeSMTPServer) received(verb string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, command := range s.commands {
+		if command == verb {
+			return true
+		}
+	}
+	return false
+}
+
+// messageCount returns the number of messages delivered to the server
+func (s *fakeSMTPServer) messageCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.messages)
+}
+
+func TestSMTPRefusesAuthOverPlaintext(t *testing.T) {
+	server := newFakeSMTPServer(t, true)
+	config := testSMTPConfig(t, server.listener.Addr())
+	config.SMTPUsername = "user"
+	config.SMTPPassword = "secret"
+
+	err := NewSMTPProvider(config).Send(context.Background(), testEmail())
+	if !errors.Is(err, ErrInsecureAuth) {
+		t.Fatalf("Send = %v, want ErrInsecureAuth", err)
+	}
+	if server.received("AUTH") || server.messageCount() != 0 {
+		t.Fatal("credentials or message sent over plaintext")
+	}
+}
+
+func TestSMTPAllowsInsecureAuthWhenEnabled(t *testing.T) {
+	server := newFakeSMTPServer(t, true)
+	config := testSMTPConfig(t, server.listener.Addr())
+	config.SMTPUsername = "user"
+	config.SMTPPassword = "secret"
+	config.SMTPAllowInsecureAuth = true
+
+	if err := NewSMTPProvider(config).Send(context.Background(), testEmail()); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if !server.received("AUTH") || server.messageCount() != 1 {
+		t.Fatal("message not sent after authenticating")
+	}
+}
+
+// TestSMTPSendTimesOutOnHungServer connects to a server that accepts the
+// connection but never sends its greeting
+func TestSMTPSendTimesOutOnHungServer(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	config := testSMTPConfig(t, listener.Addr())
+	config.SendTimeout = 200 * time.Millisecond
+	provider := NewSMTPProvider(config)
+
+	start := time.Now()
+	err = provider.Send(context.Background(), testEmail())
+	elapsed := time.Since(start)
+
+	if err == nil {
+		t.Fatal("send to a hung server succeeded")
+	}
+	if elapsed > 2*time.Second {
+		t.Fatalf("send returned after %v, want about %v", elapsed, config.SendTimeout)
+	}
+	if !IsRetryable(err) {
+		t.Errorf("timeout is not retryable: %v", err)
+	}
+}
+
+// TestSMTPConcurrentSends shares one provider between goroutines the way the
+// worker pool does. Run with -race to catch sends writing to the shared config.
+func TestSMTPConcurrentSends(t *testing.T) {
+	server := newFakeSMTPServer(t, true)
+	config := testSMTPConfig(t, server.listener.Addr())
+	config.SMTPFrom = "" // Falls back to the username
+	config.SMTPUsername = "sender@example.com"
+	config.SMTPPassword = "secret"
+	config.SMTPAllowInsecureAuth = true
+	provider := NewSMTPProvider(config)
+
+	const sends = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, sends)
+	for i := 0; i < sends; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- provider.Send(context.Background(), testEmail())
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("Send: %v", err)
+		}
+	}
+	if count := server.messageCount(); count != sends {
+		t.Fatalf("server received %d messages, want %d", count, sends)
+	}
+	if config.SMTPFrom != "" {
+		t.Errorf("Send changed the shared config: SMTPFrom = %q", config.SMTPFrom)
+	}
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	for _, message := range server.messages {
+		if !strings.Contains(message, "From: sender@example.com\r\n") {
+			t.Fatalf("message not sent from the username:\n%s", message)
+		}
+	}
+}
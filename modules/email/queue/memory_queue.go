@@ -0,0 +1,414 @@
+package queue
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/thenasky/go-framework/internal/jobs"
+	"github.com/thenasky/go-framework/modules/email/models"
+)
+
+// MemoryQueue implements the email queue in process memory. Jobs are lost on
+// restart and are not shared between nodes, so it suits tests and single-node use.
+type MemoryQueue struct {
+	mu      sync.Mutex
+	jobs    map[primitive.ObjectID]*models.EmailJob
+	byKey   map[string]primitive.ObjectID // idempotency key -> job ID
+	newJobs *jobs.Notifier
+}
+
+// NewMemoryQueue creates a new in-memory email queue
+func NewMemoryQueue() *MemoryQueue {
+	return &MemoryQueue{
+		jobs:    make(map[primitive.ObjectID]*models.EmailJob),
+		byKey:   make(map[string]primitive.ObjectID),
+		newJobs: jobs.NewNotifier(),
+	}
+}
+
+// cloneJob deep-copies a job, so callers never share slices or pointers with
+// the stored jobs
+func cloneJob(job *models.EmailJob) *models.EmailJob {
+	clone := *job
+	if job.ProcessedAt != nil {
+		processedAt := *job.ProcessedAt
+		clone.ProcessedAt = &processedAt
+	}
+	if job.ErrorMessage != nil {
+		errorMessage := *job.ErrorMessage
+		clone.ErrorMessage = &errorMessage
+	}
+	clone.Cc = slices.Clone(job.Cc)
+	clone.Bcc = slices.Clone(job.Bcc)
+	clone.Delivered = slices.Clone(job.Delivered)
+	if job.Attachments != nil {
+		clone.Attachments = make([]models.Attachment, len(job.Attachments))
+		for i, attachment := range job.Attachments {
+			attachment.Content = slices.Clone(attachment.Content)
+			clone.Attachments[i] = attachment
+		}
+	}
+	return &clone
+}
+
+// Enqueue adds an email job to the queue
+func (q *MemoryQueue) Enqueue(ctx context.Context, job *models.EmailJob) error {
+	q.mu.Lock()
+
+	if job.IdempotencyKey != "" {
+		if id, ok := q.byKey[job.IdempotencyKey]; ok {
+			*job = *cloneJob(q.jobs[id])
+			q.mu.Unlock()
+			return ErrDuplicateJob
+		}
+	}
+
+	applyDefaults(job)
+	job.ID = primitive.NewObjectID()
+
+	q.jobs[job.ID] = cloneJob(job)
+	if job.IdempotencyKey != "" {
+		q.byKey[job.IdempotencyKey] = job.ID
+	}
+	q.mu.Unlock()
+
+	// Wake up idle workers
+	q.newJobs.Broadcast()
+
+	return nil
+}
+
+// EnqueueBatch adds several email jobs to the queue. When a job's idempotency key
+// is already in use, or repeated within the batch, nothing is queued and
+// ErrDuplicateJob is returned.
+func (q *MemoryQueue) EnqueueBatch(ctx context.Context, jobs []*models.EmailJob) error {
+	q.mu.Lock()
+
+	keys := make(map[string]bool)
+	for _, job := range jobs {
+		if job.IdempotencyKey == "" {
+			continue
+		}
+		if _, ok := q.byKey[job.IdempotencyKey]; ok || keys[job.IdempotencyKey] {
+			q.mu.Unlock()
+			return ErrDuplicateJob
+		}
+		keys[job.IdempotencyKey] = true
+	}
+
+	for _, job := range jobs {
+		applyDefaults(job)
+		job.ID = primitive.NewObjectID()
+
+		q.jobs[job.ID] = cloneJob(job)
+		if job.IdempotencyKey != "" {
+			q.byKey[job.IdempotencyKey] = job.ID
+		}
+	}
+	q.mu.Unlock()
+
+	// Wake up idle workers
+	q.newJobs.Broadcast()
+
+	return nil
+}
+
+// NewJobs returns a channel that is closed the next time a job is enqueued
+func (q *MemoryQueue) NewJobs() <-chan struct{} {
+	return q.newJobs.Wait()
+}
+
+// Watch blocks until ctx is cancelled; every enqueue already happens in this process
+func (q *MemoryQueue) Watch(ctx context.Context) error {
+	<-ctx.Done()
+	return nil
+}
+
+// Dequeue gets the next available job from the queue
+func (q *MemoryQueue) Dequeue(ctx context.Context) (*models.EmailJob, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := time.Now()
+	var next *models.EmailJob
+	for _, job := range q.jobs {
+		// Failed jobs are only picked up again while they have attempts left
+		due := job.Status == models.StatusPending ||
+			(job.Status == models.StatusFailed && job.Attempts < job.MaxAttempts)
+		if !due || job.ScheduledAt.After(now) {
+			continue
+		}
+
+		if next == nil || job.Priority < next.Priority ||
+			(job.Priority == next.Priority && job.CreatedAt.Before(next.CreatedAt)) {
+			next = job
+		}
+	}
+
+	if next == nil {
+		return nil, nil // No jobs available
+	}
+
+	next.Status = models.StatusProcessing
+	next.Attempts++
+
+	return cloneJob(next), nil
+}
+
+// MarkComplete marks a job as successfully completed
+func (q *MemoryQueue) MarkComplete(ctx context.Context, jobID primitive.ObjectID, provider, providerMsgID string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if job, ok := q.jobs[jobID]; ok {
+		now := time.Now()
+		job.Status = models.StatusSent
+		job.ProcessedAt = &now
+		job.Provider = provider
+		job.ProviderMsgID = providerMsgID
+	}
+
+	return nil
+}
+
+// MarkFailed marks a job as failed. Jobs with attempts left are retried no earlier than retryAt.
+func (q *MemoryQueue) MarkFailed(ctx context.Context, jobID primitive.ObjectID, provider, errorMessage string, retryAt time.Time) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if job, ok := q.jobs[jobID]; ok {
+		now := time.Now()
+		job.Status = models.StatusFailed
+		job.ErrorMessage = &errorMessage
+		job.ScheduledAt = retryAt
+		job.ProcessedAt = &now
+		if provider != "" {
+			job.Provider = provider
+		}
+	}
+
+	return nil
+}
+
+// MarkDead marks a job as failed without any attempts left, so it is never retried
+func (q *MemoryQueue) MarkDead(ctx context.Context, jobID primitive.ObjectID, provider, errorMessage string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if job, ok := q.jobs[jobID]; ok {
+		now := time.Now()
+		job.Status = models.StatusFailed
+		job.ErrorMessage = &errorMessage
+		job.ProcessedAt = &now
+		job.MaxAttempts = job.Attempts
+		if provider != "" {
+			job.Provider = provider
+		}
+	}
+
+	return nil
+}
+
+// RecordDelivered adds recipients that accepted a partially delivered job
+func (q *MemoryQueue) RecordDelivered(ctx context.Context, jobID primitive.ObjectID, recipients []string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if job, ok := q.jobs[jobID]; ok {
+		job.Delivered = mergeRecipients(job.Delivered, recipients)
+	}
+
+	return nil
+}
+
+// MarkDeliveryEvent records a provider-reported delivery outcome (bounce, complaint)
+// on the job sent with the given provider message ID and returns the job's ID.
+func (q *MemoryQueue) MarkDeliveryEvent(ctx context.Context, providerMsgID, status, reason string) (primitive.ObjectID, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, job := range q.jobs {
+		if job.ProviderMsgID == providerMsgID {
+			job.Status = status
+			job.DeliveryReason = reason
+			return job.ID, nil
+		}
+	}
+
+	return primitive.NilObjectID, nil
+}
+
+// Requeue puts a job that could not be processed back into the pending state
+func (q *MemoryQueue) Requeue(ctx context.Context, jobID primitive.ObjectID) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if job, ok := q.jobs[jobID]; ok && job.Status == models.StatusProcessing {
+		job.Status = models.StatusPending
+	}
+
+	return nil
+}
+
+// Reschedule puts a claimed job back into pending until at, without using up an attempt
+func (q *MemoryQueue) Reschedule(ctx context.Context, jobID primitive.ObjectID, at time.Time) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if job, ok := q.jobs[jobID]; ok && job.Status == models.StatusProcessing {
+		job.Status = models.StatusPending
+		job.ScheduledAt = at
+		job.Attempts--
+	}
+
+	return nil
+}
+
+// GetJobByID retrieves a job by its ID
+func (q *MemoryQueue) GetJobByID(ctx context.Context, jobID primitive.ObjectID) (*models.EmailJob, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	stored, ok := q.jobs[jobID]
+	if !ok {
+		return nil, nil
+	}
+
+	return cloneJob(stored), nil
+}
+
+// ListJobs returns the jobs matching filter for the requested page, plus the total match count
+func (q *MemoryQueue) ListJobs(ctx context.Context, filter ListFilter) ([]models.EmailJob, int64, error) {
+	filter.normalize()
+
+	q.mu.Lock()
+	var matches []models.EmailJob
+	for _, job := range q.jobs {
+		if matchesFilter(job, filter) {
+			matches = append(matches, *cloneJob(job))
+		}
+	}
+	q.mu.Unlock()
+
+	jobs, total := pageJobs(matches, filter)
+	return jobs, total, nil
+}
+
+// GetQueueStats returns queue statistics
+func (q *MemoryQueue) GetQueueStats(ctx context.Context) (*models.EmailStats, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	stats := &models.EmailStats{}
+	now := time.Now()
+
+	// Count by status
+	for _, job := range q.jobs {
+		addProviderStats(stats, job)
+
+		switch job.Status {
+		case models.StatusPending:
+			stats.PendingCount++
+			addPending(stats, job, now)
+		case models.StatusProcessing:
+			stats.ProcessingCount++
+		case models.StatusSent:
+			stats.TotalSent++
+		case models.StatusFailed:
+			stats.TotalFailed++
+		}
+	}
+
+	// Total queued (pending + processing)
+	stats.TotalQueued = stats.PendingCount + stats.ProcessingCount
+	stats.QueueSize = stats.PendingCount
+
+	return stats, nil
+}
+
+// CountByCampaign counts the jobs of a fan-out campaign by status
+func (q *MemoryQueue) CountByCampaign(ctx context.Context, campaignID string) (map[string]int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	counts := map[string]int64{}
+	for _, job := range q.jobs {
+		if job.CampaignID == campaignID {
+			counts[job.Status]++
+		}
+	}
+	return counts, nil
+}
+
+// CleanupOldJobs removes terminal jobs processed more than olderThan ago
+func (q *MemoryQueue) CleanupOldJobs(ctx context.Context, olderThan time.Duration) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	cutoff := time.Now().Add(-olderThan)
+	for id, job := range q.jobs {
+		if isTerminal(job) && job.ProcessedAt != nil && job.ProcessedAt.Before(cutoff) {
+			delete(q.jobs, id)
+			if job.IdempotencyKey != "" {
+				delete(q.byKey, job.IdempotencyKey)
+			}
+		}
+	}
+
+	return nil
+}
+
+// PurgeJobs removes terminal jobs matching filter
+func (q *MemoryQueue) PurgeJobs(ctx context.Context, filter PurgeFilter) (int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var removed int64
+	for id, job := range q.jobs {
+		if filter.matches(job) {
+			delete(q.jobs, id)
+			if job.IdempotencyKey != "" {
+				delete(q.byKey, job.IdempotencyKey)
+			}
+			removed++
+		}
+	}
+
+	return removed, nil
+}
+
+// PromoteAgedJobs raises due jobs waiting longer than olderThan to high priority
+func (q *MemoryQueue) PromoteAgedJobs(ctx context.Context, olderThan time.Duration) (int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	cutoff := time.Now().Add(-olderThan)
+	var promoted int64
+	for _, job := range q.jobs {
+		due := job.Status == models.StatusPending ||
+			(job.Status == models.StatusFailed && job.Attempts < job.MaxAttempts)
+		if due && job.Priority > models.PriorityHigh && !job.ScheduledAt.After(cutoff) {
+			job.Priority = models.PriorityHigh
+			promoted++
+		}
+	}
+	return promoted, nil
+}
+
+// GetPendingJobsCount returns the count of pending jobs
+func (q *MemoryQueue) GetPendingJobsCount(ctx context.Context) (int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var count int64
+	for _, job := range q.jobs {
+		if job.Status == models.StatusPending {
+			count++
+		}
+	}
+	return count, nil
+}
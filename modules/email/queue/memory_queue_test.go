@@ -0,0 +1,126 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/thenasky/go-framework/modules/email/models"
+)
+
+func TestMemoryQueueKeepsFarFutureJobs(t *testing.T) {
+	q := NewMemoryQueue()
+	ctx := context.Background()
+
+	job := &models.EmailJob{
+		To:          "user@example.com",
+		CreatedAt:   time.Now().Add(-48 * time.Hour),
+		ScheduledAt: time.Now().Add(25 * time.Hour),
+	}
+	if err := q.Enqueue(ctx, job); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+
+	if err := q.CleanupOldJobs(ctx, 24*time.Hour); err != nil {
+		t.Fatalf("CleanupOldJobs: %v", err)
+	}
+	if stored, err := q.GetJobByID(ctx, job.ID); err != nil || stored == nil {
+		t.Fatalf("scheduled job removed by cleanup: %v", err)
+	}
+}
+
+func TestMemoryQueuePromotesAgedJobs(t *testing.T) {
+	testPromoteAgedJobs(t, NewMemoryQueue())
+}
+
+func TestMemoryQueueDequeueOrder(t *testing.T) {
+	testDequeueOrder(t, NewMemoryQueue())
+}
+
+func TestMemoryQueueIdempotentEnqueue(t *testing.T) {
+	testIdempotentEnqueue(t, NewMemoryQueue())
+}
+
+func TestMemoryQueueDoesNotShareJobs(t *testing.T) {
+	q := NewMemoryQueue()
+	ctx := context.Background()
+
+	job := &models.EmailJob{
+		To:          "user@example.com",
+		Cc:          []string{"cc@example.com"},
+		Attachments: []models.Attachment{{Filename: "a.txt", Content: []byte("original")}},
+	}
+	if err := q.Enqueue(ctx, job); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	// Neither the enqueued job nor a returned copy may reach the stored one
+	job.Cc[0] = "changed@example.com"
+	job.Attachments[0].Content[0] = 'X'
+
+	claimed, err := q.Dequeue(ctx)
+	if err != nil || claimed == nil {
+		t.Fatalf("Dequeue = %v, %v", claimed, err)
+	}
+	claimed.Cc[0] = "changed@example.com"
+	claimed.Attachments[0].Content[0] = 'X'
+	if err := q.MarkFailed(ctx, job.ID, "fake", "boom", time.Now()); err != nil {
+		t.Fatalf("MarkFailed: %v", err)
+	}
+	if err := q.RecordDelivered(ctx, job.ID, []string{"cc@example.com"}); err != nil {
+		t.Fatalf("RecordDelivered: %v", err)
+	}
+	failed, err := q.GetJobByID(ctx, job.ID)
+	if err != nil {
+		t.Fatalf("GetJobByID: %v", err)
+	}
+	*failed.ErrorMessage = "changed"
+	failed.Delivered[0] = "changed@example.com"
+
+	stored, err := q.GetJobByID(ctx, job.ID)
+	if err != nil {
+		t.Fatalf("GetJobByID: %v", err)
+	}
+	if stored.Cc[0] != "cc@example.com" || string(stored.Attachments[0].Content) != "original" {
+		t.Errorf("stored job changed through a copy: cc %v, attachment %q", stored.Cc, stored.Attachments[0].Content)
+	}
+	if *stored.ErrorMessage != "boom" || stored.Delivered[0] != "cc@example.com" {
+		t.Errorf("stored job changed through a copy: error %q, delivered %v", *stored.ErrorMessage, stored.Delivered)
+	}
+}
+
+func TestMemoryQueueBatchChecksIdempotencyKeys(t *testing.T) {
+	q := NewMemoryQueue()
+	ctx := context.Background()
+
+	if err := q.Enqueue(ctx, &models.EmailJob{To: "user@example.com", IdempotencyKey: "order-42"}); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+
+	for name, batch := range map[string][]*models.EmailJob{
+		"key in use": {
+			{To: "a@example.com"},
+			{To: "b@example.com", IdempotencyKey: "order-42"},
+		},
+		"key repeated in batch": {
+			{To: "a@example.com", IdempotencyKey: "order-43"},
+			{To: "b@example.com", IdempotencyKey: "order-43"},
+		},
+	} {
+		if err := q.EnqueueBatch(ctx, batch); !errors.Is(err, ErrDuplicateJob) {
+			t.Errorf("%s: EnqueueBatch = %v, want ErrDuplicateJob", name, err)
+		}
+	}
+	if count, _ := q.GetPendingJobsCount(ctx); count != 1 {
+		t.Fatalf("%d pending jobs after rejected batches, want 1", count)
+	}
+
+	batch := []*models.EmailJob{{To: "a@example.com", IdempotencyKey: "order-44"}}
+	if err := q.EnqueueBatch(ctx, batch); err != nil {
+		t.Fatalf("EnqueueBatch: %v", err)
+	}
+	repeated := &models.EmailJob{To: "b@example.com", IdempotencyKey: "order-44"}
+	if err := q.Enqueue(ctx, repeated); !errors.Is(err, ErrDuplicateJob) || repeated.ID != batch[0].ID {
+		t.Errorf("Enqueue after batch = %v (job %s), want ErrDuplicateJob for the batched job", err, repeated.ID.Hex())
+	}
+}
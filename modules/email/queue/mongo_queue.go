@@ -2,6 +2,7 @@ package queue
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -11,87 +12,188 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 
 	"github.com/thenasky/go-framework/internal/database"
+	"github.com/thenasky/go-framework/internal/jobs"
 	"github.com/thenasky/go-framework/modules/email/models"
 )
 
+// DefaultCollectionName is the MongoDB collection backing the queue unless configured otherwise
+const DefaultCollectionName = "emails_queue"
+
+// ttlIndexName is the TTL index expiring delivered jobs
+const ttlIndexName = "ttl_processed_at"
+
+// legacyTTLIndexName is the TTL index on created_at that older versions created.
+// It expired every job a day after creation, including emails scheduled later.
+const legacyTTLIndexName = "ttl_created_at"
+
+// MongoDB error codes handled when managing indexes
+const (
+	indexNotFoundCode        = 27
+	indexOptionsConflictCode = 85
+)
+
+// ErrDisconnected is returned by queue operations while MongoDB is unreachable
+var ErrDisconnected = database.ErrDisconnected
+
+// ErrDuplicateJob is returned by Enqueue when a job with the same idempotency key
+// already exists; the passed job is then populated with the existing one
+var ErrDuplicateJob = errors.New("job with this idempotency key already exists")
+
 // MongoQueue implements email queue using MongoDB
 type MongoQueue struct {
-	collection *mongo.Collection
-	ctx        context.Context
+	collection *database.Collection
+	retention  time.Duration // How long terminal jobs are kept
+	newJobs    *jobs.Notifier
 }
 
-// NewMongoQueue creates a new MongoDB-based email queue
-func NewMongoQueue() *MongoQueue {
-	// Check if MongoDB is connected
-	if database.MongoDB == nil {
-		panic("MongoDB not connected. Call database.ConnectMongoDB() first.")
+// NewMongoQueue creates a new MongoDB-based email queue that keeps delivered jobs for
+// retention. The queue lives in collName of database dbName; empty names use the
+// default database and the emails_queue collection.
+func NewMongoQueue(dbName, collName string, retention time.Duration) (*MongoQueue, error) {
+	if collName == "" {
+		collName = DefaultCollectionName
 	}
 
-	collection := database.MongoDB.Collection("emails_queue")
-
-	// Create indexes for performance
-	createIndexes(collection)
+	// Create indexes for performance, again after every reconnect
+	collection, err := database.NewCollection(dbName, collName, func(collection *mongo.Collection) error {
+		return createIndexes(collection, retention)
+	})
+	if err != nil {
+		return nil, err
+	}
 
 	return &MongoQueue{
 		collection: collection,
-		ctx:        context.Background(),
+		retention:  retention,
+		newJobs:    jobs.NewNotifier(),
+	}, nil
+}
+
+// getCollection returns the queue collection, re-acquiring it after a reconnect
+func (q *MongoQueue) getCollection() (*mongo.Collection, error) {
+	return q.collection.Get()
+}
+
+// queueIndexes are the indexes of the queue collection, apart from the TTL index
+// whose expiry follows the configured retention
+func queueIndexes() []mongo.IndexModel {
+	return []mongo.IndexModel{
+		// Finding the next job
+		{
+			Keys: bson.D{
+				{Key: "status", Value: 1},
+				{Key: "priority", Value: 1},
+				{Key: "scheduled_at", Value: 1},
+			},
+			Options: options.Index().SetName("status_priority_scheduled"),
+		},
+		// Status queries
+		{
+			Keys:    bson.D{{Key: "status", Value: 1}},
+			Options: options.Index().SetName("status_index"),
+		},
+		// Idempotency keys, unique among the documents that have one
+		{
+			Keys: bson.D{{Key: "idempotency_key", Value: 1}},
+			Options: options.Index().
+				SetName("idempotency_key_unique").
+				SetUnique(true).
+				SetPartialFilterExpression(bson.M{"idempotency_key": bson.M{"$type": "string"}}),
+		},
+		// Aggregating fan-out campaigns
+		{
+			Keys:    bson.D{{Key: "campaign_id", Value: 1}},
+			Options: options.Index().SetName("campaign_id_index").SetSparse(true),
+		},
+		// Matching provider webhooks back to jobs
+		{
+			Keys:    bson.D{{Key: "provider_msg_id", Value: 1}},
+			Options: options.Index().SetName("provider_msg_id_index").SetSparse(true),
+		},
 	}
 }
 
 // createIndexes creates necessary indexes for the queue
-func createIndexes(collection *mongo.Collection) {
-	// Index for finding next job (status + priority + scheduled_at)
-	indexModel := mongo.IndexModel{
-		Keys: bson.D{
-			{Key: "status", Value: 1},
-			{Key: "priority", Value: 1},
-			{Key: "scheduled_at", Value: 1},
-		},
-		Options: options.Index().SetName("status_priority_scheduled"),
+func createIndexes(collection *mongo.Collection, retention time.Duration) error {
+	if err := database.EnsureCollectionIndexes(collection, queueIndexes()); err != nil {
+		return fmt.Errorf("failed to create queue indexes: %w", err)
+	}
+
+	// TTL index removing delivered jobs once the retention period has passed
+	if err := createTTLIndex(collection, retention); err != nil {
+		return err
+	}
+
+	// Drop the blanket TTL of older versions so scheduled emails survive
+	ctx, cancel := database.OpContext(context.Background())
+	defer cancel()
+
+	_, err := collection.Indexes().DropOne(ctx, legacyTTLIndexName)
+	var cmdErr mongo.CommandError
+	if err != nil && !(errors.As(err, &cmdErr) && cmdErr.Code == indexNotFoundCode) {
+		return fmt.Errorf("failed to drop legacy queue TTL index: %w", err)
 	}
-	collection.Indexes().CreateOne(context.Background(), indexModel)
 
-	// TTL index to automatically clean up old jobs (24 hours)
+	return nil
+}
+
+// createTTLIndex creates the TTL index on processed_at, or updates its expiry when
+// the retention changed. Failed jobs are left to CleanupOldJobs, which knows
+// whether they have attempts left.
+func createTTLIndex(collection *mongo.Collection, retention time.Duration) error {
+	expireAfter := int32(retention / time.Second)
 	ttlIndex := mongo.IndexModel{
 		Keys: bson.D{
-			{Key: "created_at", Value: 1},
+			{Key: "processed_at", Value: 1},
 		},
-		Options: options.Index().SetExpireAfterSeconds(86400).SetName("ttl_created_at"),
+		Options: options.Index().
+			SetExpireAfterSeconds(expireAfter).
+			SetName(ttlIndexName).
+			SetPartialFilterExpression(bson.M{"status": bson.M{"$in": []string{
+				models.StatusSent, models.StatusBounced, models.StatusComplained,
+			}}}),
 	}
-	collection.Indexes().CreateOne(context.Background(), ttlIndex)
 
-	// Index for status queries
-	statusIndex := mongo.IndexModel{
-		Keys: bson.D{
-			{Key: "status", Value: 1},
-		},
-		Options: options.Index().SetName("status_index"),
+	ctx, cancel := database.OpContext(context.Background())
+	defer cancel()
+
+	_, err := collection.Indexes().CreateOne(ctx, ttlIndex)
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) && cmdErr.Code == indexOptionsConflictCode {
+		err = collection.Database().RunCommand(ctx, bson.D{
+			{Key: "collMod", Value: collection.Name()},
+			{Key: "index", Value: bson.D{
+				{Key: "name", Value: ttlIndexName},
+				{Key: "expireAfterSeconds", Value: expireAfter},
+			}},
+		}).Err()
 	}
-	collection.Indexes().CreateOne(context.Background(), statusIndex)
+	if err != nil {
+		return fmt.Errorf("failed to create queue TTL index: %w", err)
+	}
+
+	return nil
 }
 
 // Enqueue adds an email job to the queue
-func (q *MongoQueue) Enqueue(job *models.EmailJob) error {
-	// Set default values
-	if job.CreatedAt.IsZero() {
-		job.CreatedAt = time.Now()
-	}
-	if job.ScheduledAt.IsZero() {
-		job.ScheduledAt = time.Now()
-	}
-	if job.Status == "" {
-		job.Status = models.StatusPending
-	}
-	if job.Priority == 0 {
-		job.Priority = models.PriorityNormal
-	}
-	if job.MaxAttempts == 0 {
-		job.MaxAttempts = 3
+func (q *MongoQueue) Enqueue(ctx context.Context, job *models.EmailJob) error {
+	ctx, cancel := database.OpContext(ctx)
+	defer cancel()
+
+	collection, err := q.getCollection()
+	if err != nil {
+		return err
 	}
 
+	// Set default values
+	applyDefaults(job)
+
 	// Insert the job
-	result, err := q.collection.InsertOne(q.ctx, job)
+	result, err := collection.InsertOne(ctx, job)
 	if err != nil {
+		if job.IdempotencyKey != "" && mongo.IsDuplicateKeyError(err) {
+			return q.loadByIdempotencyKey(ctx, collection, job)
+		}
 		return fmt.Errorf("failed to enqueue email: %w", err)
 	}
 
@@ -100,14 +202,117 @@ func (q *MongoQueue) Enqueue(job *models.EmailJob) error {
 		job.ID = oid
 	}
 
+	// Wake up idle workers in this process
+	q.newJobs.Broadcast()
+
+	return nil
+}
+
+// EnqueueBatch adds several email jobs to the queue in one insert
+func (q *MongoQueue) EnqueueBatch(ctx context.Context, jobs []*models.EmailJob) error {
+	ctx, cancel := database.OpContext(ctx)
+	defer cancel()
+
+	if len(jobs) == 0 {
+		return nil
+	}
+
+	collection, err := q.getCollection()
+	if err != nil {
+		return err
+	}
+
+	documents := make([]interface{}, len(jobs))
+	for i, job := range jobs {
+		applyDefaults(job)
+		documents[i] = job
+	}
+
+	result, err := collection.InsertMany(ctx, documents)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue emails: %w", err)
+	}
+
+	for i, id := range result.InsertedIDs {
+		if oid, ok := id.(primitive.ObjectID); ok {
+			jobs[i].ID = oid
+		}
+	}
+
+	// Wake up idle workers in this process
+	q.newJobs.Broadcast()
+
 	return nil
 }
 
+// loadByIdempotencyKey replaces job with the stored job sharing its idempotency key
+func (q *MongoQueue) loadByIdempotencyKey(ctx context.Context, collection *mongo.Collection, job *models.EmailJob) error {
+	var existing models.EmailJob
+	err := collection.FindOne(ctx, bson.M{"idempotency_key": job.IdempotencyKey}).Decode(&existing)
+	if err != nil {
+		return fmt.Errorf("failed to load job for idempotency key: %w", err)
+	}
+
+	*job = existing
+	return ErrDuplicateJob
+}
+
+// NewJobs returns a channel that is closed the next time a job is enqueued,
+// either in this process or (while Watch is running) by any other node
+func (q *MongoQueue) NewJobs() <-chan struct{} {
+	return q.newJobs.Wait()
+}
+
+// Watch follows inserts on the queue collection through a MongoDB change stream
+// and wakes up waiting workers for each one. It blocks until ctx is cancelled or
+// the stream fails; change streams require a replica set, so callers should fall
+// back to polling when an error is returned.
+func (q *MongoQueue) Watch(ctx context.Context) error {
+	collection, err := q.getCollection()
+	if err != nil {
+		return err
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"operationType": "insert"}}},
+	}
+
+	stream, err := collection.Watch(ctx, pipeline)
+	if err != nil {
+		return fmt.Errorf("failed to open change stream: %w", err)
+	}
+	defer stream.Close(context.Background())
+
+	for stream.Next(ctx) {
+		q.newJobs.Broadcast()
+	}
+
+	if ctx.Err() != nil {
+		return nil
+	}
+	return fmt.Errorf("change stream closed: %w", stream.Err())
+}
+
 // Dequeue gets the next available job from the queue
-func (q *MongoQueue) Dequeue() (*models.EmailJob, error) {
+func (q *MongoQueue) Dequeue(ctx context.Context) (*models.EmailJob, error) {
+	ctx, cancel := database.OpContext(ctx)
+	defer cancel()
+
+	collection, err := q.getCollection()
+	if err != nil {
+		return nil, err
+	}
+
 	// Use findOneAndUpdate for atomic operation
+	// Failed jobs are only picked up again while they have attempts left
 	filter := bson.M{
-		"status":       bson.M{"$in": []string{models.StatusPending, models.StatusFailed}},
+		"$or": []bson.M{
+			{"status": models.StatusPending},
+			{
+				"status": models.StatusFailed,
+				"$expr":  bson.M{"$lt": []string{"$attempts", "$max_attempts"}},
+			},
+		},
 		"scheduled_at": bson.M{"$lte": time.Now()},
 	}
 
@@ -126,7 +331,7 @@ func (q *MongoQueue) Dequeue() (*models.EmailJob, error) {
 	}).SetReturnDocument(options.After)
 
 	var job models.EmailJob
-	err := q.collection.FindOneAndUpdate(q.ctx, filter, update, opts).Decode(&job)
+	err = collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&job)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			return nil, nil // No jobs available
@@ -138,7 +343,15 @@ func (q *MongoQueue) Dequeue() (*models.EmailJob, error) {
 }
 
 // MarkComplete marks a job as successfully completed
-func (q *MongoQueue) MarkComplete(jobID primitive.ObjectID, provider, providerMsgID string) error {
+func (q *MongoQueue) MarkComplete(ctx context.Context, jobID primitive.ObjectID, provider, providerMsgID string) error {
+	ctx, cancel := database.OpContext(ctx)
+	defer cancel()
+
+	collection, err := q.getCollection()
+	if err != nil {
+		return err
+	}
+
 	now := time.Now()
 	update := bson.M{
 		"$set": bson.M{
@@ -149,8 +362,8 @@ func (q *MongoQueue) MarkComplete(jobID primitive.ObjectID, provider, providerMs
 		},
 	}
 
-	_, err := q.collection.UpdateOne(
-		q.ctx,
+	_, err = collection.UpdateOne(
+		ctx,
 		bson.M{"_id": jobID},
 		update,
 	)
@@ -161,31 +374,201 @@ func (q *MongoQueue) MarkComplete(jobID primitive.ObjectID, provider, providerMs
 	return nil
 }
 
-// MarkFailed marks a job as failed
-func (q *MongoQueue) MarkFailed(jobID primitive.ObjectID, errorMessage string) error {
-	update := bson.M{
-		"$set": bson.M{
+// MarkFailed marks a job as failed. Jobs with attempts left are retried no earlier than retryAt.
+func (q *MongoQueue) MarkFailed(ctx context.Context, jobID primitive.ObjectID, provider, errorMessage string, retryAt time.Time) error {
+	ctx, cancel := database.OpContext(ctx)
+	defer cancel()
+
+	collection, err := q.getCollection()
+	if err != nil {
+		return err
+	}
+
+	set := bson.M{
+		"status":        models.StatusFailed,
+		"error_message": errorMessage,
+		"scheduled_at":  retryAt,
+		"processed_at":  time.Now(),
+	}
+	if provider != "" {
+		set["provider"] = provider
+	}
+	update := bson.M{"$set": set}
+
+	_, err = collection.UpdateOne(
+		ctx,
+		bson.M{"_id": jobID},
+		update,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to mark job failed: %w", err)
+	}
+
+	return nil
+}
+
+// MarkDead marks a job as failed without any attempts left, so it is never retried
+func (q *MongoQueue) MarkDead(ctx context.Context, jobID primitive.ObjectID, provider, errorMessage string) error {
+	ctx, cancel := database.OpContext(ctx)
+	defer cancel()
+
+	collection, err := q.getCollection()
+	if err != nil {
+		return err
+	}
+
+	// Pipeline update so max_attempts can be set from the job's own attempts
+	update := []bson.M{
+		{"$set": bson.M{
 			"status":        models.StatusFailed,
 			"error_message": errorMessage,
-		},
+			"processed_at":  time.Now(),
+			"max_attempts":  "$attempts",
+		}},
+	}
+	if provider != "" {
+		update[0]["$set"].(bson.M)["provider"] = provider
 	}
 
-	_, err := q.collection.UpdateOne(
-		q.ctx,
+	_, err = collection.UpdateOne(
+		ctx,
 		bson.M{"_id": jobID},
 		update,
 	)
 	if err != nil {
-		return fmt.Errorf("failed to mark job failed: %w", err)
+		return fmt.Errorf("failed to mark job dead: %w", err)
+	}
+
+	return nil
+}
+
+// RecordDelivered adds recipients that accepted a partially delivered job
+func (q *MongoQueue) RecordDelivered(ctx context.Context, jobID primitive.ObjectID, recipients []string) error {
+	ctx, cancel := database.OpContext(ctx)
+	defer cancel()
+
+	collection, err := q.getCollection()
+	if err != nil {
+		return err
+	}
+
+	_, err = collection.UpdateOne(
+		ctx,
+		bson.M{"_id": jobID},
+		bson.M{"$addToSet": bson.M{"delivered": bson.M{"$each": recipients}}},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record delivered recipients: %w", err)
+	}
+
+	return nil
+}
+
+// MarkDeliveryEvent records a provider-reported delivery outcome (bounce, complaint)
+// on the job sent with the given provider message ID and returns the job's ID.
+func (q *MongoQueue) MarkDeliveryEvent(ctx context.Context, providerMsgID, status, reason string) (primitive.ObjectID, error) {
+	ctx, cancel := database.OpContext(ctx)
+	defer cancel()
+
+	collection, err := q.getCollection()
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"status":          status,
+			"delivery_reason": reason,
+		},
+	}
+
+	var job struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	opts := options.FindOneAndUpdate().SetProjection(bson.M{"_id": 1})
+	err = collection.FindOneAndUpdate(ctx, bson.M{"provider_msg_id": providerMsgID}, update, opts).Decode(&job)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return primitive.NilObjectID, nil
+		}
+		return primitive.NilObjectID, fmt.Errorf("failed to record delivery event: %w", err)
+	}
+
+	return job.ID, nil
+}
+
+// Requeue puts a job that could not be processed back into the pending state
+func (q *MongoQueue) Requeue(ctx context.Context, jobID primitive.ObjectID) error {
+	ctx, cancel := database.OpContext(ctx)
+	defer cancel()
+
+	collection, err := q.getCollection()
+	if err != nil {
+		return err
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"status": models.StatusPending,
+		},
+	}
+
+	_, err = collection.UpdateOne(
+		ctx,
+		bson.M{"_id": jobID, "status": models.StatusProcessing},
+		update,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to requeue job: %w", err)
+	}
+
+	return nil
+}
+
+// Reschedule puts a claimed job back into pending until at, without using up an attempt
+func (q *MongoQueue) Reschedule(ctx context.Context, jobID primitive.ObjectID, at time.Time) error {
+	ctx, cancel := database.OpContext(ctx)
+	defer cancel()
+
+	collection, err := q.getCollection()
+	if err != nil {
+		return err
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"status":       models.StatusPending,
+			"scheduled_at": at,
+		},
+		"$inc": bson.M{
+			"attempts": -1,
+		},
+	}
+
+	_, err = collection.UpdateOne(
+		ctx,
+		bson.M{"_id": jobID, "status": models.StatusProcessing},
+		update,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to reschedule job: %w", err)
 	}
 
 	return nil
 }
 
 // GetJobByID retrieves a job by its ID
-func (q *MongoQueue) GetJobByID(jobID primitive.ObjectID) (*models.EmailJob, error) {
+func (q *MongoQueue) GetJobByID(ctx context.Context, jobID primitive.ObjectID) (*models.EmailJob, error) {
+	ctx, cancel := database.OpContext(ctx)
+	defer cancel()
+
+	collection, err := q.getCollection()
+	if err != nil {
+		return nil, err
+	}
+
 	var job models.EmailJob
-	err := q.collection.FindOne(q.ctx, bson.M{"_id": jobID}).Decode(&job)
+	err = collection.FindOne(ctx, bson.M{"_id": jobID}).Decode(&job)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			return nil, nil
@@ -196,8 +579,80 @@ func (q *MongoQueue) GetJobByID(jobID primitive.ObjectID) (*models.EmailJob, err
 	return &job, nil
 }
 
+// ListJobs returns the jobs matching filter for the requested page, plus the total match count
+func (q *MongoQueue) ListJobs(ctx context.Context, filter ListFilter) ([]models.EmailJob, int64, error) {
+	ctx, cancel := database.OpContext(ctx)
+	defer cancel()
+
+	collection, err := q.getCollection()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query := bson.M{}
+	if filter.Status != "" {
+		query["status"] = filter.Status
+	}
+	if filter.To != "" {
+		query["to"] = filter.To
+	}
+	if filter.From != "" {
+		query["from"] = filter.From
+	}
+	if filter.CampaignID != "" {
+		query["campaign_id"] = filter.CampaignID
+	}
+	if filter.CreatedAfter != nil || filter.CreatedBefore != nil {
+		createdAt := bson.M{}
+		if filter.CreatedAfter != nil {
+			createdAt["$gte"] = *filter.CreatedAfter
+		}
+		if filter.CreatedBefore != nil {
+			createdAt["$lte"] = *filter.CreatedBefore
+		}
+		query["created_at"] = createdAt
+	}
+
+	total, err := collection.CountDocuments(ctx, query)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count jobs: %w", err)
+	}
+
+	filter.normalize()
+	sortOrder := 1
+	if filter.SortDesc {
+		sortOrder = -1
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: filter.SortBy, Value: sortOrder}, {Key: "_id", Value: sortOrder}}).
+		SetSkip(int64((filter.Page - 1) * filter.PageSize)).
+		SetLimit(int64(filter.PageSize))
+
+	cursor, err := collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list jobs: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	jobs := make([]models.EmailJob, 0, filter.PageSize)
+	if err := cursor.All(ctx, &jobs); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode jobs: %w", err)
+	}
+
+	return jobs, total, nil
+}
+
 // GetQueueStats returns queue statistics
-func (q *MongoQueue) GetQueueStats() (*models.EmailStats, error) {
+func (q *MongoQueue) GetQueueStats(ctx context.Context) (*models.EmailStats, error) {
+	ctx, cancel := database.OpContext(ctx)
+	defer cancel()
+
+	collection, err := q.getCollection()
+	if err != nil {
+		return nil, err
+	}
+
 	stats := &models.EmailStats{}
 
 	// Count by status
@@ -210,19 +665,19 @@ func (q *MongoQueue) GetQueueStats() (*models.EmailStats, error) {
 		},
 	}
 
-	cursor, err := q.collection.Aggregate(q.ctx, pipeline)
+	cursor, err := collection.Aggregate(ctx, pipeline)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get queue stats: %w", err)
 	}
-	defer cursor.Close(q.ctx)
+	defer cursor.Close(ctx)
 
-	for cursor.Next(q.ctx) {
+	for cursor.Next(ctx) {
 		var result struct {
 			Status string `bson:"_id"`
 			Count  int64  `bson:"count"`
 		}
 		if err := cursor.Decode(&result); err != nil {
-			continue
+			return nil, fmt.Errorf("failed to decode queue stats: %w", err)
 		}
 
 		switch result.Status {
@@ -237,6 +692,17 @@ func (q *MongoQueue) GetQueueStats() (*models.EmailStats, error) {
 		}
 	}
 
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read queue stats: %w", err)
+	}
+
+	if err := q.pendingStats(ctx, collection, stats); err != nil {
+		return nil, err
+	}
+	if err := q.providerStats(ctx, collection, stats); err != nil {
+		return nil, err
+	}
+
 	// Total queued (pending + processing)
 	stats.TotalQueued = stats.PendingCount + stats.ProcessingCount
 	stats.QueueSize = stats.PendingCount
@@ -244,17 +710,174 @@ func (q *MongoQueue) GetQueueStats() (*models.EmailStats, error) {
 	return stats, nil
 }
 
-// CleanupOldJobs removes old completed/failed jobs
-func (q *MongoQueue) CleanupOldJobs(olderThan time.Duration) error {
+// CountByCampaign counts the jobs of a fan-out campaign by status
+func (q *MongoQueue) CountByCampaign(ctx context.Context, campaignID string) (map[string]int64, error) {
+	ctx, cancel := database.OpContext(ctx)
+	defer cancel()
+
+	collection, err := q.getCollection()
+	if err != nil {
+		return nil, err
+	}
+
+	pipeline := []bson.M{
+		{"$match": bson.M{"campaign_id": campaignID}},
+		{"$group": bson.M{"_id": "$status", "count": bson.M{"$sum": 1}}},
+	}
+
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count campaign jobs: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	counts := map[string]int64{}
+	for cursor.Next(ctx) {
+		var result struct {
+			Status string `bson:"_id"`
+			Count  int64  `bson:"count"`
+		}
+		if err := cursor.Decode(&result); err != nil {
+			return nil, fmt.Errorf("failed to decode campaign counts: %w", err)
+		}
+		counts[result.Status] = result.Count
+	}
+
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read campaign counts: %w", err)
+	}
+
+	return counts, nil
+}
+
+// pendingStats fills in the per-priority breakdown and the age of the oldest due pending job
+func (q *MongoQueue) pendingStats(ctx context.Context, collection *mongo.Collection, stats *models.EmailStats) error {
+	now := time.Now()
+	pipeline := []bson.M{
+		{"$match": bson.M{"status": models.StatusPending}},
+		{
+			"$group": bson.M{
+				"_id":   "$priority",
+				"count": bson.M{"$sum": 1},
+				// Jobs scheduled in the future are not waiting yet; $min skips the nulls
+				"oldest": bson.M{"$min": bson.M{
+					"$cond": []interface{}{bson.M{"$lte": []interface{}{"$scheduled_at", now}}, "$scheduled_at", nil},
+				}},
+			},
+		},
+	}
+
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return fmt.Errorf("failed to get pending stats: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var result struct {
+			Priority int        `bson:"_id"`
+			Count    int64      `bson:"count"`
+			Oldest   *time.Time `bson:"oldest"`
+		}
+		if err := cursor.Decode(&result); err != nil {
+			return fmt.Errorf("failed to decode pending stats: %w", err)
+		}
+
+		if stats.PendingByPriority == nil {
+			stats.PendingByPriority = map[int]int64{}
+		}
+		stats.PendingByPriority[result.Priority] = result.Count
+		if result.Oldest != nil {
+			if age := now.Sub(*result.Oldest); age > stats.OldestPendingAge {
+				stats.OldestPendingAge = age
+			}
+		}
+	}
+
+	if err := cursor.Err(); err != nil {
+		return fmt.Errorf("failed to read pending stats: %w", err)
+	}
+
+	return nil
+}
+
+// providerStats fills in the per-provider breakdown of sent and failed jobs
+func (q *MongoQueue) providerStats(ctx context.Context, collection *mongo.Collection, stats *models.EmailStats) error {
+	accepted := bson.M{"$in": []string{"$status", models.StatusSent, models.StatusBounced, models.StatusComplained}}
+	pipeline := []bson.M{
+		{"$match": bson.M{"provider": bson.M{"$nin": []interface{}{nil, ""}}}},
+		{
+			"$group": bson.M{
+				"_id":    "$provider",
+				"sent":   bson.M{"$sum": bson.M{"$cond": []interface{}{accepted, 1, 0}}},
+				"failed": bson.M{"$sum": bson.M{"$cond": []interface{}{bson.M{"$eq": []string{"$status", models.StatusFailed}}, 1, 0}}},
+				// Milliseconds from due to accepted; $avg skips the nulls of unsent jobs
+				"latency": bson.M{"$avg": bson.M{
+					"$cond": []interface{}{accepted, bson.M{"$max": []interface{}{0, bson.M{"$subtract": []string{"$processed_at", "$scheduled_at"}}}}, nil},
+				}},
+			},
+		},
+	}
+
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return fmt.Errorf("failed to get provider stats: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var result struct {
+			Provider string   `bson:"_id"`
+			Sent     int64    `bson:"sent"`
+			Failed   int64    `bson:"failed"`
+			Latency  *float64 `bson:"latency"`
+		}
+		if err := cursor.Decode(&result); err != nil {
+			return fmt.Errorf("failed to decode provider stats: %w", err)
+		}
+
+		if stats.ByProvider == nil {
+			stats.ByProvider = map[string]models.ProviderStats{}
+		}
+		providerStats := models.ProviderStats{Sent: result.Sent, Failed: result.Failed}
+		if result.Latency != nil {
+			providerStats.AvgLatency = time.Duration(*result.Latency * float64(time.Millisecond))
+		}
+		stats.ByProvider[result.Provider] = providerStats
+	}
+
+	if err := cursor.Err(); err != nil {
+		return fmt.Errorf("failed to read provider stats: %w", err)
+	}
+
+	return nil
+}
+
+// CleanupOldJobs removes terminal jobs processed more than olderThan ago
+func (q *MongoQueue) CleanupOldJobs(ctx context.Context, olderThan time.Duration) error {
+	ctx, cancel := database.OpContext(ctx)
+	defer cancel()
+
+	collection, err := q.getCollection()
+	if err != nil {
+		return err
+	}
+
 	cutoff := time.Now().Add(-olderThan)
 
-	// Delete old completed/failed jobs
+	// Delete old delivered jobs and failed jobs without attempts left
 	filter := bson.M{
-		"status":       bson.M{"$in": []string{models.StatusSent, models.StatusFailed}},
+		"$or": []bson.M{
+			{"status": bson.M{"$in": []string{models.StatusSent, models.StatusBounced, models.StatusComplained}}},
+			{
+				"status": models.StatusFailed,
+				"$expr":  bson.M{"$gte": []string{"$attempts", "$max_attempts"}},
+			},
+		},
 		"processed_at": bson.M{"$lt": cutoff},
 	}
 
-	_, err := q.collection.DeleteMany(q.ctx, filter)
+	_, err = collection.DeleteMany(ctx, filter)
 	if err != nil {
 		return fmt.Errorf("failed to cleanup old jobs: %w", err)
 	}
@@ -262,9 +885,73 @@ func (q *MongoQueue) CleanupOldJobs(olderThan time.Duration) error {
 	return nil
 }
 
+// PurgeJobs removes terminal jobs matching filter
+func (q *MongoQueue) PurgeJobs(ctx context.Context, filter PurgeFilter) (int64, error) {
+	ctx, cancel := database.OpContext(ctx)
+	defer cancel()
+
+	collection, err := q.getCollection()
+	if err != nil {
+		return 0, err
+	}
+
+	query := bson.M{
+		"status":       filter.Status,
+		"processed_at": bson.M{"$lt": filter.Before},
+	}
+	// Failed jobs still waiting for a retry are not dead-lettered yet
+	if filter.Status == models.StatusFailed {
+		query["$expr"] = bson.M{"$gte": []string{"$attempts", "$max_attempts"}}
+	}
+
+	result, err := collection.DeleteMany(ctx, query)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge jobs: %w", err)
+	}
+
+	return result.DeletedCount, nil
+}
+
+// PromoteAgedJobs raises due jobs waiting longer than olderThan to high priority
+func (q *MongoQueue) PromoteAgedJobs(ctx context.Context, olderThan time.Duration) (int64, error) {
+	ctx, cancel := database.OpContext(ctx)
+	defer cancel()
+
+	collection, err := q.getCollection()
+	if err != nil {
+		return 0, err
+	}
+
+	filter := bson.M{
+		"$or": []bson.M{
+			{"status": models.StatusPending},
+			{
+				"status": models.StatusFailed,
+				"$expr":  bson.M{"$lt": []string{"$attempts", "$max_attempts"}},
+			},
+		},
+		"priority":     bson.M{"$gt": models.PriorityHigh},
+		"scheduled_at": bson.M{"$lte": time.Now().Add(-olderThan)},
+	}
+
+	result, err := collection.UpdateMany(ctx, filter, bson.M{"$set": bson.M{"priority": models.PriorityHigh}})
+	if err != nil {
+		return 0, fmt.Errorf("failed to promote aged jobs: %w", err)
+	}
+	return result.ModifiedCount, nil
+}
+
 // GetPendingJobsCount returns the count of pending jobs
-func (q *MongoQueue) GetPendingJobsCount() (int64, error) {
-	count, err := q.collection.CountDocuments(q.ctx, bson.M{"status": models.StatusPending})
+func (q *MongoQueue) GetPendingJobsCount(ctx context.Context) (int64, error) {
+	ctx, cancel := database.OpContext(ctx)
+	defer cancel()
+
+	collection, err := q.getCollection()
+	if err != nil {
+		return 0, err
+	}
+
+	count, err := collection.CountDocuments(ctx, bson.M{"status": models.StatusPending})
 	if err != nil {
 		return 0, fmt.Errorf("failed to count pending jobs: %w", err)
 	}
@@ -0,0 +1,109 @@
+package queue
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/thenasky/go-framework/internal/database"
+	"github.com/thenasky/go-framework/modules/email/models"
+)
+
+// newTestMongoQueue connects to MONGODB_URI and returns a queue on a fresh
+// collection that is dropped after the test. The test is skipped without MongoDB.
+func newTestMongoQueue(t *testing.T) *MongoQueue {
+	t.Helper()
+	if os.Getenv("MONGODB_URI") == "" {
+		t.Skip("MONGODB_URI not set")
+	}
+
+	database.ConnectMongoDB()
+	if database.MongoDB() == nil {
+		t.Fatal("could not connect to MONGODB_URI")
+	}
+	t.Cleanup(database.DisconnectMongoDB)
+
+	q, err := NewMongoQueue("", "emails_queue_test_"+primitive.NewObjectID().Hex(), 24*time.Hour)
+	if err != nil {
+		t.Fatalf("NewMongoQueue: %v", err)
+	}
+	t.Cleanup(func() {
+		if collection, err := q.getCollection(); err == nil {
+			collection.Drop(context.Background())
+		}
+	})
+	return q
+}
+
+// TestMongoQueueKeepsFarFutureJobs inserts a job created two days ago and
+// scheduled 25 hours ahead: neither the TTL index nor CleanupOldJobs may remove it
+func TestMongoQueueKeepsFarFutureJobs(t *testing.T) {
+	q := newTestMongoQueue(t)
+	ctx := context.Background()
+
+	job := &models.EmailJob{
+		To:          "user@example.com",
+		From:        "noreply@example.com",
+		Subject:     "Later",
+		CreatedAt:   time.Now().Add(-48 * time.Hour),
+		ScheduledAt: time.Now().Add(25 * time.Hour),
+	}
+	if err := q.Enqueue(ctx, job); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+
+	// Every TTL index must leave the pending job alone
+	collection, err := q.getCollection()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cursor, err := collection.Indexes().List(ctx)
+	if err != nil {
+		t.Fatalf("listing indexes: %v", err)
+	}
+	var indexes []bson.M
+	if err := cursor.All(ctx, &indexes); err != nil {
+		t.Fatalf("listing indexes: %v", err)
+	}
+	for _, index := range indexes {
+		if _, ttl := index["expireAfterSeconds"]; !ttl {
+			continue
+		}
+		if index["name"] != ttlIndexName {
+			t.Errorf("unexpected TTL index %v", index["name"])
+			continue
+		}
+		covered, err := collection.CountDocuments(ctx, bson.M{
+			"$and": []interface{}{bson.M{"_id": job.ID}, index["partialFilterExpression"]},
+		})
+		if err != nil {
+			t.Fatalf("matching TTL filter: %v", err)
+		}
+		if covered != 0 {
+			t.Errorf("pending job is covered by TTL index %s", ttlIndexName)
+		}
+	}
+
+	if err := q.CleanupOldJobs(ctx, 24*time.Hour); err != nil {
+		t.Fatalf("CleanupOldJobs: %v", err)
+	}
+	if stored, err := q.GetJobByID(ctx, job.ID); err != nil || stored == nil {
+		t.Fatalf("scheduled job removed by cleanup: %v", err)
+	}
+}
+
+func TestMongoQueuePromotesAgedJobs(t *testing.T) {
+	testPromoteAgedJobs(t, newTestMongoQueue(t))
+}
+
+func TestMongoQueueDequeueOrder(t *testing.T) {
+	testDequeueOrder(t, newTestMongoQueue(t))
+}
+
+func TestMongoQueueIdempotentEnqueue(t *testing.T) {
+	testIdempotentEnqueue(t, newTestMongoQueue(t))
+}
@@ -0,0 +1,259 @@
+package queue
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/thenasky/go-framework/modules/email/models"
+)
+
+// Queue is the storage backend for email jobs
+type Queue interface {
+	// Every operation takes the caller's context, so request deadlines and
+	// cancellation reach the backend
+	// Enqueue adds a job; a duplicate idempotency key returns ErrDuplicateJob
+	// with job replaced by the existing one
+	Enqueue(ctx context.Context, job *models.EmailJob) error
+	// EnqueueBatch adds several jobs at once; batched jobs must not carry idempotency keys
+	EnqueueBatch(ctx context.Context, jobs []*models.EmailJob) error
+	// Dequeue claims the next due job, or returns nil when none is available
+	Dequeue(ctx context.Context) (*models.EmailJob, error)
+	MarkComplete(ctx context.Context, jobID primitive.ObjectID, provider, providerMsgID string) error
+	// MarkFailed and MarkDead record provider as the provider that failed, when one was tried
+	MarkFailed(ctx context.Context, jobID primitive.ObjectID, provider, errorMessage string, retryAt time.Time) error
+	// RecordDelivered adds recipients that accepted a partially delivered job, so
+	// retries skip them
+	RecordDelivered(ctx context.Context, jobID primitive.ObjectID, recipients []string) error
+	// MarkDead marks a job as failed without any attempts left, so it is never retried
+	MarkDead(ctx context.Context, jobID primitive.ObjectID, provider, errorMessage string) error
+	// MarkDeliveryEvent applies a bounce or complaint to the job sent as providerMsgID
+	// and returns its ID, or primitive.NilObjectID when no job matches
+	MarkDeliveryEvent(ctx context.Context, providerMsgID, status, reason string) (primitive.ObjectID, error)
+	Requeue(ctx context.Context, jobID primitive.ObjectID) error
+	// Reschedule puts a claimed job back into pending until at, without using up an attempt
+	Reschedule(ctx context.Context, jobID primitive.ObjectID, at time.Time) error
+	GetJobByID(ctx context.Context, jobID primitive.ObjectID) (*models.EmailJob, error)
+	ListJobs(ctx context.Context, filter ListFilter) ([]models.EmailJob, int64, error)
+	GetQueueStats(ctx context.Context) (*models.EmailStats, error)
+	// CountByCampaign counts the jobs of a fan-out campaign by status
+	CountByCampaign(ctx context.Context, campaignID string) (map[string]int64, error)
+	// CleanupOldJobs removes terminal jobs processed more than olderThan ago;
+	// pending, scheduled and retrying jobs are never removed
+	CleanupOldJobs(ctx context.Context, olderThan time.Duration) error
+	// PurgeJobs removes terminal jobs matching filter on demand, returning how many were removed
+	PurgeJobs(ctx context.Context, filter PurgeFilter) (int64, error)
+	// PromoteAgedJobs raises due jobs that have waited longer than olderThan to high
+	// priority, so a steady stream of high-priority jobs cannot starve them. It
+	// returns how many jobs were promoted.
+	PromoteAgedJobs(ctx context.Context, olderThan time.Duration) (int64, error)
+	GetPendingJobsCount(ctx context.Context) (int64, error)
+
+	// NewJobs returns a channel that is closed the next time a job is enqueued
+	NewJobs() <-chan struct{}
+	// Watch relays jobs enqueued by other nodes to NewJobs until ctx is cancelled
+	Watch(ctx context.Context) error
+}
+
+// ListFilter selects and pages jobs for ListJobs
+type ListFilter struct {
+	Status        string
+	To            string
+	From          string
+	CampaignID    string
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	Page          int // 1-based
+	PageSize      int
+	SortBy        string // created_at, scheduled_at, processed_at, priority, status
+	SortDesc      bool
+}
+
+// PurgeFilter selects the jobs removed by PurgeJobs. Only terminal jobs with
+// the given status that were processed before Before are removed.
+type PurgeFilter struct {
+	Status string
+	Before time.Time
+}
+
+// matches reports whether job is removed by the purge
+func (f PurgeFilter) matches(job *models.EmailJob) bool {
+	return isTerminal(job) && job.Status == f.Status &&
+		job.ProcessedAt != nil && job.ProcessedAt.Before(f.Before)
+}
+
+// normalize applies the default page, page size and sort field
+func (f *ListFilter) normalize() {
+	if f.Page < 1 {
+		f.Page = 1
+	}
+	if f.PageSize < 1 {
+		f.PageSize = 20
+	}
+	if f.SortBy == "" {
+		f.SortBy = "created_at"
+	}
+}
+
+// pageJobs sorts jobs matching a normalized filter and returns the requested
+// page along with the total match count
+func pageJobs(matches []models.EmailJob, filter ListFilter) ([]models.EmailJob, int64) {
+	sort.Slice(matches, func(i, j int) bool {
+		a, b := &matches[i], &matches[j]
+		if filter.SortDesc {
+			a, b = b, a
+		}
+		// Ties are broken by ID, as in MongoQueue
+		if less, equal := compareJobs(a, b, filter.SortBy); !equal {
+			return less
+		}
+		return a.ID.Hex() < b.ID.Hex()
+	})
+
+	total := int64(len(matches))
+	start := (filter.Page - 1) * filter.PageSize
+	if start > len(matches) {
+		start = len(matches)
+	}
+	end := start + filter.PageSize
+	if end > len(matches) {
+		end = len(matches)
+	}
+
+	jobs := make([]models.EmailJob, 0, end-start)
+	jobs = append(jobs, matches[start:end]...)
+	return jobs, total
+}
+
+// matchesFilter reports whether job satisfies the filter's conditions
+func matchesFilter(job *models.EmailJob, filter ListFilter) bool {
+	return (filter.Status == "" || job.Status == filter.Status) &&
+		(filter.To == "" || job.To == filter.To) &&
+		(filter.From == "" || job.From == filter.From) &&
+		(filter.CampaignID == "" || job.CampaignID == filter.CampaignID) &&
+		(filter.CreatedAfter == nil || !job.CreatedAt.Before(*filter.CreatedAfter)) &&
+		(filter.CreatedBefore == nil || !job.CreatedAt.After(*filter.CreatedBefore))
+}
+
+// compareJobs orders two jobs by a ListFilter sort field
+func compareJobs(a, b *models.EmailJob, field string) (less, equal bool) {
+	switch field {
+	case "scheduled_at":
+		return a.ScheduledAt.Before(b.ScheduledAt), a.ScheduledAt.Equal(b.ScheduledAt)
+	case "processed_at":
+		at, bt := processedAt(a), processedAt(b)
+		return at.Before(bt), at.Equal(bt)
+	case "priority":
+		return a.Priority < b.Priority, a.Priority == b.Priority
+	case "status":
+		return a.Status < b.Status, a.Status == b.Status
+	default:
+		return a.CreatedAt.Before(b.CreatedAt), a.CreatedAt.Equal(b.CreatedAt)
+	}
+}
+
+// addPending counts a pending job in the per-priority breakdown and the oldest
+// pending age. Age is measured from scheduled_at so future jobs only count once due.
+func addPending(stats *models.EmailStats, job *models.EmailJob, now time.Time) {
+	if stats.PendingByPriority == nil {
+		stats.PendingByPriority = map[int]int64{}
+	}
+	stats.PendingByPriority[job.Priority]++
+
+	if age := now.Sub(job.ScheduledAt); age > stats.OldestPendingAge {
+		stats.OldestPendingAge = age
+	}
+}
+
+// addProviderStats counts a job in the per-provider breakdown. Jobs only keep
+// the outcome of their latest attempt, so an email that failed over is counted
+// once, for the provider that handled it last.
+func addProviderStats(stats *models.EmailStats, job *models.EmailJob) {
+	if job.Provider == "" {
+		return
+	}
+	if stats.ByProvider == nil {
+		stats.ByProvider = map[string]models.ProviderStats{}
+	}
+
+	providerStats := stats.ByProvider[job.Provider]
+	switch job.Status {
+	case models.StatusSent, models.StatusBounced, models.StatusComplained:
+		providerStats.Sent++
+		if job.ProcessedAt != nil {
+			latency := job.ProcessedAt.Sub(job.ScheduledAt)
+			if latency < 0 {
+				latency = 0
+			}
+			// Running mean over the sent jobs counted so far
+			providerStats.AvgLatency += (latency - providerStats.AvgLatency) / time.Duration(providerStats.Sent)
+		}
+	case models.StatusFailed:
+		providerStats.Failed++
+	}
+	stats.ByProvider[job.Provider] = providerStats
+}
+
+// isTerminal reports whether a job will not be sent again: delivered, reported
+// by the provider, or failed without attempts left
+func isTerminal(job *models.EmailJob) bool {
+	switch job.Status {
+	case models.StatusSent, models.StatusBounced, models.StatusComplained:
+		return true
+	case models.StatusFailed:
+		return job.Attempts >= job.MaxAttempts
+	default:
+		return false
+	}
+}
+
+// mergeRecipients adds the recipients missing from delivered
+func mergeRecipients(delivered, recipients []string) []string {
+	seen := make(map[string]bool, len(delivered))
+	for _, recipient := range delivered {
+		seen[recipient] = true
+	}
+	for _, recipient := range recipients {
+		if !seen[recipient] {
+			seen[recipient] = true
+			delivered = append(delivered, recipient)
+		}
+	}
+	return delivered
+}
+
+// processedAt returns when a job was processed, or the zero time
+func processedAt(job *models.EmailJob) time.Time {
+	if job.ProcessedAt == nil {
+		return time.Time{}
+	}
+	return *job.ProcessedAt
+}
+
+// Ensure every backend implements Queue
+var (
+	_ Queue = (*MongoQueue)(nil)
+	_ Queue = (*MemoryQueue)(nil)
+	_ Queue = (*RedisQueue)(nil)
+)
+
+// applyDefaults fills in the fields Enqueue defaults when unset
+func applyDefaults(job *models.EmailJob) {
+	if job.CreatedAt.IsZero() {
+		job.CreatedAt = time.Now()
+	}
+	if job.ScheduledAt.IsZero() {
+		job.ScheduledAt = time.Now()
+	}
+	if job.Status == "" {
+		job.Status = models.StatusPending
+	}
+	if job.Priority == 0 {
+		job.Priority = models.PriorityNormal
+	}
+	if job.MaxAttempts == 0 {
+		job.MaxAttempts = 3
+	}
+}
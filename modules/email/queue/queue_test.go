@@ -0,0 +1,110 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/thenasky/go-framework/modules/email/models"
+)
+
+// testPromoteAgedJobs queues a low-priority job that has waited ten minutes and
+// a fresh high-priority one: after promotion the aged job is dequeued first
+func testPromoteAgedJobs(t *testing.T, q Queue) {
+	t.Helper()
+	ctx := context.Background()
+
+	aged := &models.EmailJob{
+		To:          "aged@example.com",
+		Priority:    models.PriorityLow,
+		CreatedAt:   time.Now().Add(-10 * time.Minute),
+		ScheduledAt: time.Now().Add(-10 * time.Minute),
+	}
+	fresh := &models.EmailJob{To: "fresh@example.com", Priority: models.PriorityHigh}
+	for _, job := range []*models.EmailJob{aged, fresh} {
+		if err := q.Enqueue(ctx, job); err != nil {
+			t.Fatalf("Enqueue: %v", err)
+		}
+	}
+
+	promoted, err := q.PromoteAgedJobs(ctx, 5*time.Minute)
+	if err != nil {
+		t.Fatalf("PromoteAgedJobs: %v", err)
+	}
+	if promoted != 1 {
+		t.Fatalf("promoted %d jobs, want 1", promoted)
+	}
+
+	for _, want := range []*models.EmailJob{aged, fresh} {
+		job, err := q.Dequeue(ctx)
+		if err != nil {
+			t.Fatalf("Dequeue: %v", err)
+		}
+		if job == nil || job.ID != want.ID {
+			t.Fatalf("dequeued %v, want %s", job, want.To)
+		}
+	}
+}
+
+// testDequeueOrder queues due jobs of every priority and one scheduled later:
+// the due ones are dequeued by priority and the later one not at all
+func testDequeueOrder(t *testing.T, q Queue) {
+	t.Helper()
+	ctx := context.Background()
+
+	due := time.Now().Add(-time.Minute)
+	low := &models.EmailJob{To: "low@example.com", Priority: models.PriorityLow, ScheduledAt: due}
+	high := &models.EmailJob{To: "high@example.com", Priority: models.PriorityHigh, ScheduledAt: due}
+	normal := &models.EmailJob{To: "normal@example.com", Priority: models.PriorityNormal, ScheduledAt: due}
+	later := &models.EmailJob{To: "later@example.com", Priority: models.PriorityHigh, ScheduledAt: time.Now().Add(time.Hour)}
+	for _, job := range []*models.EmailJob{low, high, later, normal} {
+		if err := q.Enqueue(ctx, job); err != nil {
+			t.Fatalf("Enqueue: %v", err)
+		}
+	}
+
+	for _, want := range []*models.EmailJob{high, normal, low} {
+		job, err := q.Dequeue(ctx)
+		if err != nil {
+			t.Fatalf("Dequeue: %v", err)
+		}
+		if job == nil || job.ID != want.ID {
+			t.Fatalf("dequeued %v, want %s", job, want.To)
+		}
+		if job.Status != models.StatusProcessing || job.Attempts != 1 {
+			t.Errorf("dequeued job is %s after %d attempts, want processing after 1", job.Status, job.Attempts)
+		}
+	}
+
+	if job, err := q.Dequeue(ctx); err != nil || job != nil {
+		t.Fatalf("Dequeue = %v, %v; want no due job", job, err)
+	}
+}
+
+// testIdempotentEnqueue queues two jobs with the same idempotency key: the
+// second is reported as a duplicate of the first
+func testIdempotentEnqueue(t *testing.T, q Queue) {
+	t.Helper()
+	ctx := context.Background()
+
+	first := &models.EmailJob{To: "user@example.com", IdempotencyKey: "order-42"}
+	if err := q.Enqueue(ctx, first); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+
+	repeated := &models.EmailJob{To: "other@example.com", IdempotencyKey: "order-42"}
+	if err := q.Enqueue(ctx, repeated); !errors.Is(err, ErrDuplicateJob) {
+		t.Fatalf("repeated Enqueue = %v, want ErrDuplicateJob", err)
+	}
+	if repeated.ID != first.ID || repeated.To != first.To {
+		t.Errorf("repeated Enqueue returned job %s to %s, want the first one (%s)", repeated.ID.Hex(), repeated.To, first.ID.Hex())
+	}
+
+	if job, err := q.Dequeue(ctx); err != nil || job == nil || job.ID != first.ID {
+		t.Fatalf("Dequeue = %v, %v; want the first job", job, err)
+	}
+	if job, err := q.Dequeue(ctx); err != nil || job != nil {
+		t.Fatalf("Dequeue = %v, %v; want the duplicate not queued", job, err)
+	}
+}
@@ -0,0 +1,855 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/thenasky/go-framework/internal/jobs"
+	"github.com/thenasky/go-framework/modules/email/models"
+)
+
+// Redis keys used by the queue
+const (
+	redisKeyPrefix     = "emails_queue:"
+	redisReadyKey      = redisKeyPrefix + "ready"      // ZSET of due job IDs scored by scheduled_at*1000+priority
+	redisProcessingKey = redisKeyPrefix + "processing" // ZSET of claimed job IDs scored by visibility deadline
+	redisAllKey        = redisKeyPrefix + "all"        // ZSET of every job ID scored by created_at
+	redisNewJobsTopic  = redisKeyPrefix + "new"        // Pub/sub channel announcing enqueued jobs
+)
+
+// DefaultVisibilityTimeout is how long a claimed job may stay in processing before
+// it is handed to another worker (e.g. after the claiming node crashed)
+const DefaultVisibilityTimeout = 5 * time.Minute
+
+// claimScript returns expired claims to the ready set, then atomically moves the
+// first due job from the ready set to the processing set
+var claimScript = redis.NewScript(`
+local expired = redis.call('ZRANGEBYSCORE', KEYS[2], '-inf', ARGV[2])
+for _, id in ipairs(expired) do
+	redis.call('ZREM', KEYS[2], id)
+	redis.call('ZADD', KEYS[1], 0, id)
+end
+
+local ids = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, 1)
+if #ids == 0 then
+	return false
+end
+
+redis.call('ZREM', KEYS[1], ids[1])
+redis.call('ZADD', KEYS[2], ARGV[3], ids[1])
+return ids[1]
+`)
+
+// releaseScript deletes an idempotency key only while it still maps to the
+// job that reserved it
+var releaseScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// RedisQueue implements the email queue using Redis sorted sets. Jobs are stored
+// as JSON without expiry until they are finished, then expire after the retention
+// period like the MongoDB queue.
+type RedisQueue struct {
+	client            *redis.Client
+	ownsClient        bool // Close closes the client only when the queue opened it
+	newJobs           *jobs.Notifier
+	visibilityTimeout time.Duration
+	retention         time.Duration
+}
+
+// NewRedisQueue creates a new Redis-based email queue from a redis:// URL that keeps finished jobs for retention
+func NewRedisQueue(url string, retention time.Duration) (*RedisQueue, error) {
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Redis URL: %w", err)
+	}
+
+	client := redis.NewClient(opts)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
+	}
+
+	q := NewRedisQueueWithClient(client, retention)
+	q.ownsClient = true
+	return q, nil
+}
+
+// NewRedisQueueWithClient creates a Redis-based email queue on an existing
+// client, such as the shared one from internal/redis. Close leaves the client open.
+func NewRedisQueueWithClient(client *redis.Client, retention time.Duration) *RedisQueue {
+	return &RedisQueue{
+		client:            client,
+		newJobs:           jobs.NewNotifier(),
+		visibilityTimeout: DefaultVisibilityTimeout,
+		retention:         retention,
+	}
+}
+
+// jobKey returns the key holding a job's JSON
+func jobKey(id string) string {
+	return redisKeyPrefix + "job:" + id
+}
+
+// statusKey returns the key of the set holding the IDs of jobs in a status
+func statusKey(status string) string {
+	return redisKeyPrefix + "status:" + status
+}
+
+// idempotencyKey returns the key mapping an idempotency key to a job ID
+func idempotencyKey(key string) string {
+	return redisKeyPrefix + "idempotency:" + key
+}
+
+// campaignKey returns the key of the set holding the IDs of a campaign's jobs
+func campaignKey(campaignID string) string {
+	return redisKeyPrefix + "campaign:" + campaignID
+}
+
+// providerMsgKey returns the key mapping a provider message ID to a job ID
+func providerMsgKey(providerMsgID string) string {
+	return redisKeyPrefix + "provider_msg:" + providerMsgID
+}
+
+// readyScore orders due jobs by scheduled time, then priority
+func readyScore(job *models.EmailJob) float64 {
+	return float64(job.ScheduledAt.Unix()*1000 + int64(job.Priority))
+}
+
+// Enqueue adds an email job to the queue
+func (q *RedisQueue) Enqueue(ctx context.Context, job *models.EmailJob) error {
+	// Set default values
+	applyDefaults(job)
+	job.ID = primitive.NewObjectID()
+	id := job.ID.Hex()
+
+	data, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("failed to encode email job: %w", err)
+	}
+
+	// Reserve the idempotency key first so concurrent duplicates lose the race
+	if job.IdempotencyKey != "" {
+		reserved, err := q.client.SetNX(ctx, idempotencyKey(job.IdempotencyKey), id, 0).Result()
+		if err != nil {
+			return fmt.Errorf("failed to enqueue email: %w", err)
+		}
+		if !reserved {
+			return q.loadByIdempotencyKey(ctx, job)
+		}
+	}
+
+	_, err = q.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		// No expiry while pending: a job may be scheduled far in the future
+		pipe.Set(ctx, jobKey(id), data, 0)
+		pipe.SAdd(ctx, statusKey(job.Status), id)
+		pipe.ZAdd(ctx, redisAllKey, &redis.Z{Score: float64(job.CreatedAt.UnixMilli()), Member: id})
+		pipe.ZAdd(ctx, redisReadyKey, &redis.Z{Score: readyScore(job), Member: id})
+		pipe.Publish(ctx, redisNewJobsTopic, id)
+		return nil
+	})
+	if err != nil {
+		// Free the reservation so retrying the request isn't reported as a duplicate
+		if job.IdempotencyKey != "" {
+			releaseCtx := context.WithoutCancel(ctx)
+			if releaseErr := releaseScript.Run(releaseCtx, q.client, []string{idempotencyKey(job.IdempotencyKey)}, id).Err(); releaseErr != nil {
+				log.Printf("Failed to release idempotency key %q: %v", job.IdempotencyKey, releaseErr)
+			}
+		}
+		return fmt.Errorf("failed to enqueue email: %w", err)
+	}
+
+	// Wake up idle workers in this process
+	q.newJobs.Broadcast()
+
+	return nil
+}
+
+// EnqueueBatch adds several email jobs to the queue in one transaction
+func (q *RedisQueue) EnqueueBatch(ctx context.Context, jobs []*models.EmailJob) error {
+	if len(jobs) == 0 {
+		return nil
+	}
+
+	data := make([][]byte, len(jobs))
+	for i, job := range jobs {
+		applyDefaults(job)
+		job.ID = primitive.NewObjectID()
+
+		encoded, err := json.Marshal(job)
+		if err != nil {
+			return fmt.Errorf("failed to encode email job: %w", err)
+		}
+		data[i] = encoded
+	}
+
+	_, err := q.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, job := range jobs {
+			id := job.ID.Hex()
+			pipe.Set(ctx, jobKey(id), data[i], 0)
+			pipe.SAdd(ctx, statusKey(job.Status), id)
+			pipe.ZAdd(ctx, redisAllKey, &redis.Z{Score: float64(job.CreatedAt.UnixMilli()), Member: id})
+			pipe.ZAdd(ctx, redisReadyKey, &redis.Z{Score: readyScore(job), Member: id})
+			if job.CampaignID != "" {
+				pipe.SAdd(ctx, campaignKey(job.CampaignID), id)
+			}
+		}
+		pipe.Publish(ctx, redisNewJobsTopic, "batch")
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to enqueue emails: %w", err)
+	}
+
+	// Wake up idle workers in this process
+	q.newJobs.Broadcast()
+
+	return nil
+}
+
+// loadByIdempotencyKey replaces job with the stored job sharing its idempotency key
+func (q *RedisQueue) loadByIdempotencyKey(ctx context.Context, job *models.EmailJob) error {
+	id, err := q.client.Get(ctx, idempotencyKey(job.IdempotencyKey)).Result()
+	if err != nil {
+		return fmt.Errorf("failed to load job for idempotency key: %w", err)
+	}
+
+	existing, err := q.loadJob(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to load job for idempotency key: %w", err)
+	}
+	if existing == nil {
+		return fmt.Errorf("failed to load job for idempotency key: job %s expired", id)
+	}
+
+	*job = *existing
+	return ErrDuplicateJob
+}
+
+// loadJob reads a job by ID, returning nil if it does not exist (or has expired)
+func (q *RedisQueue) loadJob(ctx context.Context, id string) (*models.EmailJob, error) {
+	data, err := q.client.Get(ctx, jobKey(id)).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var job models.EmailJob
+	if err := json.Unmarshal(data, &job); err != nil {
+		return nil, fmt.Errorf("failed to decode job %s: %w", id, err)
+	}
+	return &job, nil
+}
+
+// saveJob stores a job, moving it between status sets when its status changed
+func (q *RedisQueue) saveJob(ctx context.Context, job *models.EmailJob, previousStatus string, extra func(pipe redis.Pipeliner)) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("failed to encode email job: %w", err)
+	}
+
+	id := job.ID.Hex()
+	_, err = q.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, jobKey(id), data, redis.KeepTTL)
+		if previousStatus != job.Status {
+			pipe.SMove(ctx, statusKey(previousStatus), statusKey(job.Status), id)
+		}
+		// Finished jobs start expiring once they won't be sent again
+		if isTerminal(job) {
+			pipe.Expire(ctx, jobKey(id), q.retention)
+			if job.IdempotencyKey != "" {
+				pipe.Expire(ctx, idempotencyKey(job.IdempotencyKey), q.retention)
+			}
+			// The campaign index outlives its last finished job by the retention period
+			if job.CampaignID != "" {
+				pipe.Expire(ctx, campaignKey(job.CampaignID), q.retention)
+			}
+		}
+		if extra != nil {
+			extra(pipe)
+		}
+		return nil
+	})
+	return err
+}
+
+// NewJobs returns a channel that is closed the next time a job is enqueued,
+// either in this process or (while Watch is running) by any other node
+func (q *RedisQueue) NewJobs() <-chan struct{} {
+	return q.newJobs.Wait()
+}
+
+// Watch subscribes to enqueue announcements from every node and wakes up
+// waiting workers for each one. It blocks until ctx is cancelled or the
+// subscription fails.
+func (q *RedisQueue) Watch(ctx context.Context) error {
+	pubsub := q.client.Subscribe(ctx, redisNewJobsTopic)
+	defer pubsub.Close()
+
+	if _, err := pubsub.Receive(ctx); err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return fmt.Errorf("failed to subscribe to new jobs: %w", err)
+	}
+
+	messages := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case _, ok := <-messages:
+			if !ok {
+				return fmt.Errorf("new job subscription closed")
+			}
+			q.newJobs.Broadcast()
+		}
+	}
+}
+
+// Dequeue gets the next available job from the queue
+func (q *RedisQueue) Dequeue(ctx context.Context) (*models.EmailJob, error) {
+	for {
+		now := time.Now()
+		maxScore := fmt.Sprintf("%d", now.Unix()*1000+999)
+		deadline := now.Add(q.visibilityTimeout).UnixMilli()
+
+		id, err := claimScript.Run(ctx, q.client,
+			[]string{redisReadyKey, redisProcessingKey},
+			maxScore, now.UnixMilli(), deadline,
+		).Text()
+		if err != nil {
+			if errors.Is(err, redis.Nil) {
+				return nil, nil // No jobs available
+			}
+			return nil, fmt.Errorf("failed to dequeue job: %w", err)
+		}
+
+		job, err := q.loadJob(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to dequeue job: %w", err)
+		}
+		if job == nil {
+			// The job expired while queued; drop the stale claim and try the next one
+			q.client.ZRem(ctx, redisProcessingKey, id)
+			continue
+		}
+
+		previousStatus := job.Status
+		job.Status = models.StatusProcessing
+		job.Attempts++
+
+		if err := q.saveJob(ctx, job, previousStatus, nil); err != nil {
+			return nil, fmt.Errorf("failed to dequeue job: %w", err)
+		}
+
+		return job, nil
+	}
+}
+
+// MarkComplete marks a job as successfully completed
+func (q *RedisQueue) MarkComplete(ctx context.Context, jobID primitive.ObjectID, provider, providerMsgID string) error {
+	id := jobID.Hex()
+	job, err := q.loadJob(ctx, id)
+	if err != nil || job == nil {
+		if err == nil {
+			err = fmt.Errorf("job %s not found", id)
+		}
+		return fmt.Errorf("failed to mark job complete: %w", err)
+	}
+
+	now := time.Now()
+	previousStatus := job.Status
+	job.Status = models.StatusSent
+	job.ProcessedAt = &now
+	job.Provider = provider
+	job.ProviderMsgID = providerMsgID
+
+	err = q.saveJob(ctx, job, previousStatus, func(pipe redis.Pipeliner) {
+		pipe.ZRem(ctx, redisProcessingKey, id)
+		if providerMsgID != "" {
+			pipe.Set(ctx, providerMsgKey(providerMsgID), id, q.retention)
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("failed to mark job complete: %w", err)
+	}
+
+	return nil
+}
+
+// MarkFailed marks a job as failed. Jobs with attempts left are retried no earlier than retryAt.
+func (q *RedisQueue) MarkFailed(ctx context.Context, jobID primitive.ObjectID, provider, errorMessage string, retryAt time.Time) error {
+	id := jobID.Hex()
+	job, err := q.loadJob(ctx, id)
+	if err != nil || job == nil {
+		if err == nil {
+			err = fmt.Errorf("job %s not found", id)
+		}
+		return fmt.Errorf("failed to mark job failed: %w", err)
+	}
+
+	now := time.Now()
+	previousStatus := job.Status
+	job.Status = models.StatusFailed
+	job.ErrorMessage = &errorMessage
+	job.ScheduledAt = retryAt
+	job.ProcessedAt = &now
+	if provider != "" {
+		job.Provider = provider
+	}
+
+	err = q.saveJob(ctx, job, previousStatus, func(pipe redis.Pipeliner) {
+		pipe.ZRem(ctx, redisProcessingKey, id)
+		// Failed jobs are only picked up again while they have attempts left
+		if job.Attempts < job.MaxAttempts {
+			pipe.ZAdd(ctx, redisReadyKey, &redis.Z{Score: readyScore(job), Member: id})
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("failed to mark job failed: %w", err)
+	}
+
+	return nil
+}
+
+// MarkDead marks a job as failed without any attempts left, so it is never retried
+func (q *RedisQueue) MarkDead(ctx context.Context, jobID primitive.ObjectID, provider, errorMessage string) error {
+	id := jobID.Hex()
+	job, err := q.loadJob(ctx, id)
+	if err != nil || job == nil {
+		if err == nil {
+			err = fmt.Errorf("job %s not found", id)
+		}
+		return fmt.Errorf("failed to mark job dead: %w", err)
+	}
+
+	now := time.Now()
+	previousStatus := job.Status
+	job.Status = models.StatusFailed
+	job.ErrorMessage = &errorMessage
+	job.ProcessedAt = &now
+	job.MaxAttempts = job.Attempts
+	if provider != "" {
+		job.Provider = provider
+	}
+
+	err = q.saveJob(ctx, job, previousStatus, func(pipe redis.Pipeliner) {
+		pipe.ZRem(ctx, redisProcessingKey, id)
+		pipe.ZRem(ctx, redisReadyKey, id)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to mark job dead: %w", err)
+	}
+
+	return nil
+}
+
+// RecordDelivered adds recipients that accepted a partially delivered job
+func (q *RedisQueue) RecordDelivered(ctx context.Context, jobID primitive.ObjectID, recipients []string) error {
+	job, err := q.loadJob(ctx, jobID.Hex())
+	if err != nil {
+		return fmt.Errorf("failed to record delivered recipients: %w", err)
+	}
+	if job == nil {
+		return nil
+	}
+
+	job.Delivered = mergeRecipients(job.Delivered, recipients)
+
+	if err := q.saveJob(ctx, job, job.Status, nil); err != nil {
+		return fmt.Errorf("failed to record delivered recipients: %w", err)
+	}
+
+	return nil
+}
+
+// MarkDeliveryEvent records a provider-reported delivery outcome (bounce, complaint)
+// on the job sent with the given provider message ID and returns the job's ID.
+func (q *RedisQueue) MarkDeliveryEvent(ctx context.Context, providerMsgID, status, reason string) (primitive.ObjectID, error) {
+	id, err := q.client.Get(ctx, providerMsgKey(providerMsgID)).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return primitive.NilObjectID, nil
+		}
+		return primitive.NilObjectID, fmt.Errorf("failed to record delivery event: %w", err)
+	}
+
+	job, err := q.loadJob(ctx, id)
+	if err != nil {
+		return primitive.NilObjectID, fmt.Errorf("failed to record delivery event: %w", err)
+	}
+	if job == nil {
+		return primitive.NilObjectID, nil
+	}
+
+	previousStatus := job.Status
+	job.Status = status
+	job.DeliveryReason = reason
+
+	if err := q.saveJob(ctx, job, previousStatus, nil); err != nil {
+		return primitive.NilObjectID, fmt.Errorf("failed to record delivery event: %w", err)
+	}
+
+	return job.ID, nil
+}
+
+// Requeue puts a job that could not be processed back into the pending state
+func (q *RedisQueue) Requeue(ctx context.Context, jobID primitive.ObjectID) error {
+	id := jobID.Hex()
+	job, err := q.loadJob(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to requeue job: %w", err)
+	}
+	if job == nil || job.Status != models.StatusProcessing {
+		return nil
+	}
+
+	job.Status = models.StatusPending
+
+	err = q.saveJob(ctx, job, models.StatusProcessing, func(pipe redis.Pipeliner) {
+		pipe.ZRem(ctx, redisProcessingKey, id)
+		pipe.ZAdd(ctx, redisReadyKey, &redis.Z{Score: readyScore(job), Member: id})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to requeue job: %w", err)
+	}
+
+	return nil
+}
+
+// Reschedule puts a claimed job back into pending until at, without using up an attempt
+func (q *RedisQueue) Reschedule(ctx context.Context, jobID primitive.ObjectID, at time.Time) error {
+	id := jobID.Hex()
+	job, err := q.loadJob(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to reschedule job: %w", err)
+	}
+	if job == nil || job.Status != models.StatusProcessing {
+		return nil
+	}
+
+	job.Status = models.StatusPending
+	job.ScheduledAt = at
+	job.Attempts--
+
+	err = q.saveJob(ctx, job, models.StatusProcessing, func(pipe redis.Pipeliner) {
+		pipe.ZRem(ctx, redisProcessingKey, id)
+		pipe.ZAdd(ctx, redisReadyKey, &redis.Z{Score: readyScore(job), Member: id})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to reschedule job: %w", err)
+	}
+
+	return nil
+}
+
+// GetJobByID retrieves a job by its ID
+func (q *RedisQueue) GetJobByID(ctx context.Context, jobID primitive.ObjectID) (*models.EmailJob, error) {
+	job, err := q.loadJob(ctx, jobID.Hex())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job: %w", err)
+	}
+	return job, nil
+}
+
+// ListJobs returns the jobs matching filter for the requested page, plus the total match count.
+// Matching happens client-side, so listing reads every candidate job.
+func (q *RedisQueue) ListJobs(ctx context.Context, filter ListFilter) ([]models.EmailJob, int64, error) {
+	filter.normalize()
+
+	var ids []string
+	var err error
+	if filter.Status != "" {
+		ids, err = q.client.SMembers(ctx, statusKey(filter.Status)).Result()
+	} else {
+		ids, err = q.client.ZRange(ctx, redisAllKey, 0, -1).Result()
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list jobs: %w", err)
+	}
+
+	jobs, err := q.loadJobs(ctx, ids)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list jobs: %w", err)
+	}
+
+	matches := make([]models.EmailJob, 0, len(jobs))
+	for i := range jobs {
+		if matchesFilter(&jobs[i], filter) {
+			matches = append(matches, jobs[i])
+		}
+	}
+
+	page, total := pageJobs(matches, filter)
+	return page, total, nil
+}
+
+// loadJobs reads the given jobs in batches, skipping expired ones
+func (q *RedisQueue) loadJobs(ctx context.Context, ids []string) ([]models.EmailJob, error) {
+	const batchSize = 500
+
+	jobs := make([]models.EmailJob, 0, len(ids))
+	for start := 0; start < len(ids); start += batchSize {
+		end := start + batchSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+
+		keys := make([]string, 0, end-start)
+		for _, id := range ids[start:end] {
+			keys = append(keys, jobKey(id))
+		}
+
+		values, err := q.client.MGet(ctx, keys...).Result()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, value := range values {
+			data, ok := value.(string)
+			if !ok {
+				continue // Expired
+			}
+			var job models.EmailJob
+			if err := json.Unmarshal([]byte(data), &job); err != nil {
+				return nil, fmt.Errorf("failed to decode job: %w", err)
+			}
+			jobs = append(jobs, job)
+		}
+	}
+
+	return jobs, nil
+}
+
+// GetQueueStats returns queue statistics
+func (q *RedisQueue) GetQueueStats(ctx context.Context) (*models.EmailStats, error) {
+	statuses := []string{models.StatusPending, models.StatusProcessing, models.StatusSent, models.StatusFailed}
+
+	counts := make(map[string]*redis.IntCmd, len(statuses))
+	_, err := q.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, status := range statuses {
+			counts[status] = pipe.SCard(ctx, statusKey(status))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get queue stats: %w", err)
+	}
+
+	stats := &models.EmailStats{
+		PendingCount:    counts[models.StatusPending].Val(),
+		ProcessingCount: counts[models.StatusProcessing].Val(),
+		TotalSent:       counts[models.StatusSent].Val(),
+		TotalFailed:     counts[models.StatusFailed].Val(),
+	}
+
+	if err := q.pendingStats(ctx, stats); err != nil {
+		return nil, err
+	}
+	if err := q.providerStats(ctx, stats); err != nil {
+		return nil, err
+	}
+
+	// Total queued (pending + processing)
+	stats.TotalQueued = stats.PendingCount + stats.ProcessingCount
+	stats.QueueSize = stats.PendingCount
+
+	return stats, nil
+}
+
+// CountByCampaign counts the jobs of a fan-out campaign by status
+func (q *RedisQueue) CountByCampaign(ctx context.Context, campaignID string) (map[string]int64, error) {
+	ids, err := q.client.SMembers(ctx, campaignKey(campaignID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to count campaign jobs: %w", err)
+	}
+
+	jobs, err := q.loadJobs(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count campaign jobs: %w", err)
+	}
+
+	counts := map[string]int64{}
+	for i := range jobs {
+		counts[jobs[i].Status]++
+	}
+	return counts, nil
+}
+
+// pendingStats fills in the per-priority breakdown and the age of the oldest due pending job
+func (q *RedisQueue) pendingStats(ctx context.Context, stats *models.EmailStats) error {
+	ids, err := q.client.SMembers(ctx, statusKey(models.StatusPending)).Result()
+	if err != nil {
+		return fmt.Errorf("failed to get pending stats: %w", err)
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = jobKey(id)
+	}
+	values, err := q.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return fmt.Errorf("failed to get pending stats: %w", err)
+	}
+
+	now := time.Now()
+	for _, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			continue // Removed since SMEMBERS
+		}
+		var job models.EmailJob
+		if err := json.Unmarshal([]byte(data), &job); err != nil {
+			return fmt.Errorf("failed to decode pending stats: %w", err)
+		}
+		addPending(stats, &job, now)
+	}
+
+	return nil
+}
+
+// providerStats fills in the per-provider breakdown from the jobs that were attempted
+func (q *RedisQueue) providerStats(ctx context.Context, stats *models.EmailStats) error {
+	var ids []string
+	for _, status := range []string{models.StatusSent, models.StatusBounced, models.StatusComplained, models.StatusFailed} {
+		members, err := q.client.SMembers(ctx, statusKey(status)).Result()
+		if err != nil {
+			return fmt.Errorf("failed to get provider stats: %w", err)
+		}
+		ids = append(ids, members...)
+	}
+
+	jobs, err := q.loadJobs(ctx, ids)
+	if err != nil {
+		return fmt.Errorf("failed to get provider stats: %w", err)
+	}
+	for i := range jobs {
+		addProviderStats(stats, &jobs[i])
+	}
+
+	return nil
+}
+
+// CleanupOldJobs removes terminal jobs processed more than olderThan ago, and drops index entries of
+// jobs that expired through the TTL
+func (q *RedisQueue) CleanupOldJobs(ctx context.Context, olderThan time.Duration) error {
+	cutoff := time.Now().Add(-olderThan)
+
+	ids, err := q.client.ZRange(ctx, redisAllKey, 0, -1).Result()
+	if err != nil {
+		return fmt.Errorf("failed to cleanup old jobs: %w", err)
+	}
+
+	for _, id := range ids {
+		job, err := q.loadJob(ctx, id)
+		if err != nil {
+			return fmt.Errorf("failed to cleanup old jobs: %w", err)
+		}
+
+		finished := job != nil && isTerminal(job) &&
+			job.ProcessedAt != nil && job.ProcessedAt.Before(cutoff)
+		if job != nil && !finished {
+			continue
+		}
+
+		if err := q.deleteJob(ctx, id, job); err != nil {
+			return fmt.Errorf("failed to cleanup old jobs: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// PurgeJobs removes terminal jobs matching filter
+func (q *RedisQueue) PurgeJobs(ctx context.Context, filter PurgeFilter) (int64, error) {
+	ids, err := q.client.SMembers(ctx, statusKey(filter.Status)).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge jobs: %w", err)
+	}
+
+	var removed int64
+	for _, id := range ids {
+		job, err := q.loadJob(ctx, id)
+		if err != nil {
+			return removed, fmt.Errorf("failed to purge jobs: %w", err)
+		}
+		if job == nil || !filter.matches(job) {
+			continue
+		}
+
+		if err := q.deleteJob(ctx, id, job); err != nil {
+			return removed, fmt.Errorf("failed to purge jobs: %w", err)
+		}
+		removed++
+	}
+
+	return removed, nil
+}
+
+// deleteJob removes a job and all of its index entries; job may be nil when
+// only stale index entries are left
+func (q *RedisQueue) deleteJob(ctx context.Context, id string, job *models.EmailJob) error {
+	_, err := q.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, jobKey(id))
+		pipe.ZRem(ctx, redisAllKey, id)
+		pipe.ZRem(ctx, redisReadyKey, id)
+		pipe.ZRem(ctx, redisProcessingKey, id)
+		for _, status := range []string{models.StatusPending, models.StatusProcessing, models.StatusSent,
+			models.StatusFailed, models.StatusBounced, models.StatusComplained} {
+			pipe.SRem(ctx, statusKey(status), id)
+		}
+		if job != nil && job.ProviderMsgID != "" {
+			pipe.Del(ctx, providerMsgKey(job.ProviderMsgID))
+		}
+		if job != nil && job.CampaignID != "" {
+			pipe.SRem(ctx, campaignKey(job.CampaignID), id)
+		}
+		if job != nil && job.IdempotencyKey != "" {
+			pipe.Del(ctx, idempotencyKey(job.IdempotencyKey))
+		}
+		return nil
+	})
+	return err
+}
+
+// PromoteAgedJobs is a no-op: the ready set is ordered by scheduled time first
+// and priority only breaks ties, so waiting jobs cannot be starved
+func (q *RedisQueue) PromoteAgedJobs(ctx context.Context, olderThan time.Duration) (int64, error) {
+	return 0, nil
+}
+
+// GetPendingJobsCount returns the count of pending jobs
+func (q *RedisQueue) GetPendingJobsCount(ctx context.Context) (int64, error) {
+	count, err := q.client.SCard(ctx, statusKey(models.StatusPending)).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to count pending jobs: %w", err)
+	}
+	return count, nil
+}
+
+// Close releases the Redis connection when the queue opened it
+func (q *RedisQueue) Close() error {
+	if !q.ownsClient {
+		return nil
+	}
+	return q.client.Close()
+}
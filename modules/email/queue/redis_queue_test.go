@@ -0,0 +1,106 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/thenasky/go-framework/modules/email/models"
+)
+
+// newTestRedisQueue returns a queue on REDIS_URL, skipping the test when it is
+// unset. The queue's keys have a fixed prefix, so they are deleted before and
+// after the test: don't point REDIS_URL at a database holding a real queue.
+func newTestRedisQueue(t *testing.T) (*RedisQueue, *redis.Client) {
+	t.Helper()
+	url := os.Getenv("REDIS_URL")
+	if url == "" {
+		t.Skip("REDIS_URL not set")
+	}
+
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		t.Fatalf("invalid REDIS_URL: %v", err)
+	}
+	client := redis.NewClient(opts)
+	clearKeys := func() {
+		ctx := context.Background()
+		iter := client.Scan(ctx, 0, redisKeyPrefix+"*", 100).Iterator()
+		for iter.Next(ctx) {
+			client.Del(ctx, iter.Val())
+		}
+		if err := iter.Err(); err != nil {
+			t.Fatalf("clearing queue keys: %v", err)
+		}
+	}
+	clearKeys()
+	t.Cleanup(func() {
+		clearKeys()
+		client.Close()
+	})
+
+	return NewRedisQueueWithClient(client, 24*time.Hour), client
+}
+
+func TestRedisQueueDequeueOrder(t *testing.T) {
+	q, _ := newTestRedisQueue(t)
+	testDequeueOrder(t, q)
+}
+
+func TestRedisQueueIdempotentEnqueue(t *testing.T) {
+	q, _ := newTestRedisQueue(t)
+	testIdempotentEnqueue(t, q)
+}
+
+// failingTxHook fails every pipeline, and so every transaction, while fail is set
+type failingTxHook struct {
+	fail atomic.Bool
+}
+
+func (h *failingTxHook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	return ctx, nil
+}
+
+func (h *failingTxHook) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	return nil
+}
+
+func (h *failingTxHook) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	if h.fail.Load() {
+		return ctx, errors.New("transaction failed")
+	}
+	return ctx, nil
+}
+
+func (h *failingTxHook) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	return nil
+}
+
+// TestRedisQueueReleasesIdempotencyKeyOnFailure fails the transaction storing
+// a job: retrying with the same idempotency key must queue it, not report a
+// duplicate of a job that was never stored
+func TestRedisQueueReleasesIdempotencyKeyOnFailure(t *testing.T) {
+	q, client := newTestRedisQueue(t)
+	hook := &failingTxHook{}
+	client.AddHook(hook)
+	ctx := context.Background()
+
+	hook.fail.Store(true)
+	if err := q.Enqueue(ctx, &models.EmailJob{To: "user@example.com", IdempotencyKey: "order-42"}); err == nil {
+		t.Fatal("Enqueue succeeded despite the failing transaction")
+	}
+	hook.fail.Store(false)
+
+	retried := &models.EmailJob{To: "user@example.com", IdempotencyKey: "order-42"}
+	if err := q.Enqueue(ctx, retried); err != nil {
+		t.Fatalf("retried Enqueue: %v", err)
+	}
+	if job, err := q.Dequeue(ctx); err != nil || job == nil || job.ID != retried.ID {
+		t.Fatalf("Dequeue = %v, %v; want the retried job", job, err)
+	}
+}
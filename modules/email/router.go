@@ -1,7 +1,13 @@
 package email
 
 import (
+	"context"
+	"time"
+
 	"github.com/thenasky/go-framework/internal/core"
+	"github.com/thenasky/go-framework/internal/database"
+	"github.com/thenasky/go-framework/internal/metrics"
+	"github.com/thenasky/go-framework/internal/middleware"
 	"github.com/thenasky/go-framework/internal/router"
 
 	"github.com/gorilla/mux"
@@ -25,10 +31,63 @@ func (m *Module) RegisterRoutes(r *mux.Router) {
 	router.Router(r, "/api/v1/emails").
 		// Main email sending endpoint
 		Post("/send", m.controller.SendEmail).
+		// One email to many recipients, optionally fanned out into a campaign
+		Post("/bulk", m.controller.SendBulkEmail).
+		Get("/campaigns/{id}", m.controller.GetCampaignStatus).
+		// Render an email without sending it
+		Post("/preview", m.controller.PreviewEmail).
 		// Email status and management
-		Get("/{id}/status", m.controller.GetEmailStatus).
+		Get("", m.controller.ListEmails).
+		Get("/{id}/events", m.controller.StreamEmailStatus).
+		// Append-only lifecycle history, kept after the email is cleaned up
+		Get("/{id}/history", m.controller.GetEmailHistory).
 		Get("/stats", m.controller.GetStats).
+		Get("/config", m.controller.GetConfig).
+		Get("/errors", m.controller.GetErrors).
+		Get("/validate", m.controller.ValidateAddress).
+		// One-click unsubscribe from List-Unsubscribe links
+		Get("/unsubscribe", m.controller.Unsubscribe).
+		Post("/unsubscribe", m.controller.Unsubscribe).
+		// Provider delivery events (bounces, complaints)
+		Post("/webhooks/{provider}", m.controller.HandleWebhook).
 		Get("/health", m.controller.Health)
+
+	// Status is polled by dashboards: identical concurrent polls share one lookup,
+	// and the result is reused for EMAIL_STATUS_CACHE_MS
+	router.Router(r, "/api/v1/emails").
+		Use(middleware.CoalesceMiddleware(statusCacheTTL())).
+		Get("/{id}/status", m.controller.GetEmailStatus)
+
+	// Admin-only operations, guarded by ADMIN_API_KEYS
+	router.Router(r, "/api/v1/emails").
+		Use(core.AdminMiddleware()).
+		Delete("", m.controller.PurgeEmails)
+}
+
+// statusCacheTTL returns how long email status responses are reused (EMAIL_STATUS_CACHE_MS)
+func statusCacheTTL() time.Duration {
+	if ms := getEnvInt("EMAIL_STATUS_CACHE_MS", 0); ms > 0 {
+		return time.Duration(ms) * time.Millisecond
+	}
+	return 0
+}
+
+// Shutdown implements the core.ModuleShutdowner interface
+func (m *Module) Shutdown(ctx context.Context) error {
+	// Drain the workers before the connection they depend on goes away
+	err := m.controller.service.Stop(ctx)
+	database.DisconnectMongoDB()
+	return err
+}
+
+// Setup implements the core.ModuleSetup interface. The health check starts the
+// email worker, so it is only registered when the module is enabled.
+func (m *Module) Setup() {
+	service := m.controller.service
+	core.RegisterHealthCheck("email_worker", service.HealthCheck)
+	metrics.NewGaugeFunc("email_queue_depth", "Number of emails waiting in the queue.", service.QueueDepth)
+	metrics.NewGaugeFunc("email_queue_oldest_pending_age_seconds", "Seconds the oldest due pending email has been waiting.", service.OldestPendingAge)
+	metrics.NewGaugeVecFunc("email_queue_pending", "Number of pending emails, by priority.", "priority", service.PendingByPriority)
 }
 
 // init automatically registers this module when the package is imported
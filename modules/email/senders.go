@@ -0,0 +1,90 @@
+package email
+
+import (
+	"fmt"
+	"net/mail"
+	"os"
+	"strings"
+)
+
+// senderAllowList restricts which addresses may be used as the sender of an email
+type senderAllowList struct {
+	addresses map[string]bool
+	domains   map[string]bool
+	fallback  string
+}
+
+// loadSenderAllowList reads EMAIL_ALLOWED_SENDERS, a comma-separated list of
+// addresses (noreply@example.com) and domains (example.com or @example.com).
+// An invalid entry is an error rather than being skipped, since a list that
+// ends up empty would allow every sender.
+func loadSenderAllowList() (*senderAllowList, error) {
+	list := &senderAllowList{
+		addresses: map[string]bool{},
+		domains:   map[string]bool{},
+	}
+
+	var addresses []string
+	for _, entry := range strings.Split(os.Getenv("EMAIL_ALLOWED_SENDERS"), ",") {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		switch {
+		case entry == "":
+		case strings.HasPrefix(entry, "@"):
+			if !validSenderDomain(entry[1:]) {
+				return nil, fmt.Errorf("invalid domain %q in EMAIL_ALLOWED_SENDERS", entry)
+			}
+			list.domains[entry[1:]] = true
+		case strings.Contains(entry, "@"):
+			if parsed, err := mail.ParseAddress(entry); err != nil || parsed.Address != entry {
+				return nil, fmt.Errorf("invalid address %q in EMAIL_ALLOWED_SENDERS", entry)
+			}
+			list.addresses[entry] = true
+			addresses = append(addresses, entry)
+		default:
+			if !validSenderDomain(entry) {
+				return nil, fmt.Errorf("invalid domain %q in EMAIL_ALLOWED_SENDERS", entry)
+			}
+			list.domains[entry] = true
+		}
+	}
+
+	// A single verified address doubles as the default sender
+	if len(addresses) == 1 && len(list.domains) == 0 {
+		list.fallback = addresses[0]
+	}
+
+	return list, nil
+}
+
+// validSenderDomain reports whether domain can be a domain of the allow list
+func validSenderDomain(domain string) bool {
+	return domain != "" && !strings.ContainsAny(domain, "@ \t<>")
+}
+
+// Enabled reports whether any senders are configured; an empty list allows every sender
+func (l *senderAllowList) Enabled() bool {
+	return len(l.addresses) > 0 || len(l.domains) > 0
+}
+
+// Allows reports whether from may be used as the sender
+func (l *senderAllowList) Allows(from string) bool {
+	if !l.Enabled() {
+		return true
+	}
+
+	address := strings.ToLower(strings.TrimSpace(from))
+	if parsed, err := mail.ParseAddress(from); err == nil {
+		address = strings.ToLower(parsed.Address)
+	}
+
+	if l.addresses[address] {
+		return true
+	}
+	at := strings.LastIndex(address, "@")
+	return at >= 0 && l.domains[address[at+1:]]
+}
+
+// DefaultSender returns the sender to use when a request has none, or "" when there is no single verified sender
+func (l *senderAllowList) DefaultSender() string {
+	return l.fallback
+}
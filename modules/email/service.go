@@ -1,37 +1,162 @@
 package email
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"net/url"
 	"os"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 
 	"github.com/thenasky/go-framework/internal/database"
+	"github.com/thenasky/go-framework/internal/logger"
+	redisclient "github.com/thenasky/go-framework/internal/redis"
+	"github.com/thenasky/go-framework/internal/router"
+	"github.com/thenasky/go-framework/modules/email/audit"
 	"github.com/thenasky/go-framework/modules/email/models"
 	"github.com/thenasky/go-framework/modules/email/providers"
 	"github.com/thenasky/go-framework/modules/email/queue"
+	"github.com/thenasky/go-framework/modules/email/suppression"
+	"github.com/thenasky/go-framework/modules/email/webhooks"
 	"github.com/thenasky/go-framework/modules/email/workers"
 )
 
 // EmailService handles email business logic
 type EmailService struct {
-	queue       *queue.MongoQueue
-	worker      *workers.EmailWorker
-	providers   []providers.EmailProvider
-	initialized bool
-	mu          sync.Mutex
+	queue        queue.Queue
+	suppressions suppression.List
+	auditTrail   audit.Trail
+	worker       *workers.EmailWorker
+	providers    []providers.EmailProvider
+	workerConfig *workers.WorkerConfig
+	senders      *senderAllowList
+	workerPool   string // Key claimed in activeWorkerPools, released on Stop
+	autoStart    bool   // Whether the worker starts with the service (EMAIL_WORKER_ENABLED)
+	initialized  bool
+	mu           sync.Mutex
 }
 
-// NewEmailService creates a new email service
+var (
+	defaultService     *EmailService
+	defaultServiceOnce sync.Once
+
+	// activeWorkerPools records the queues a worker pool is processing in this
+	// process, so two services never compete for the same jobs
+	activeWorkerPools   = make(map[string]bool)
+	activeWorkerPoolsMu sync.Mutex
+)
+
+// NewEmailService creates a new email service. Most callers should share
+// GetDefaultService instead: only one service per queue can start workers.
+// The configuration is read from the environment on first use, since the
+// default service is created before .env is loaded.
 func NewEmailService() *EmailService {
 	return &EmailService{
 		initialized: false,
 	}
 }
 
+// GetDefaultService returns the process-wide email service used by the HTTP
+// controller, for other modules that send email
+func GetDefaultService() *EmailService {
+	defaultServiceOnce.Do(func() {
+		defaultService = NewEmailService()
+	})
+	return defaultService
+}
+
+// workerPoolKey identifies the queue a worker pool processes. Memory queues are
+// private to their service and need no guard.
+func workerPoolKey() string {
+	switch backend := os.Getenv("EMAIL_QUEUE_BACKEND"); backend {
+	case "memory":
+		return ""
+	case "redis":
+		return "redis:" + os.Getenv("REDIS_URL")
+	default:
+		dbName := os.Getenv("EMAIL_QUEUE_DB")
+		if dbName == "" {
+			db := database.MongoDB()
+			if db == nil {
+				return "mongo"
+			}
+			dbName = db.Name()
+		}
+		// Resolve the collection like NewMongoQueue so the default matches its explicit name
+		collName := os.Getenv("EMAIL_QUEUE_COLLECTION")
+		if collName == "" {
+			collName = queue.DefaultCollectionName
+		}
+		return "mongo:" + dbName + "/" + collName
+	}
+}
+
+// claimWorkerPool reserves key for a single worker pool, failing when another
+// service in this process already processes that queue
+func claimWorkerPool(key string) error {
+	if key == "" {
+		return nil
+	}
+
+	activeWorkerPoolsMu.Lock()
+	defer activeWorkerPoolsMu.Unlock()
+
+	if activeWorkerPools[key] {
+		return fmt.Errorf("an email worker pool is already processing %s in this process; use GetDefaultService", key)
+	}
+	activeWorkerPools[key] = true
+	return nil
+}
+
+// releaseWorkerPool frees a key reserved by claimWorkerPool
+func releaseWorkerPool(key string) {
+	activeWorkerPoolsMu.Lock()
+	defer activeWorkerPoolsMu.Unlock()
+	delete(activeWorkerPools, key)
+}
+
+// loadWorkerConfig builds the worker configuration from the environment
+func loadWorkerConfig() *workers.WorkerConfig {
+	config := workers.DefaultWorkerConfig()
+
+	if count := getEnvInt("EMAIL_WORKER_COUNT", config.WorkerCount); count > 0 {
+		config.WorkerCount = count
+	}
+	if delay := getEnvInt("EMAIL_PROCESSING_DELAY_MS", int(config.ProcessingDelay/time.Millisecond)); delay >= 0 {
+		config.ProcessingDelay = time.Duration(delay) * time.Millisecond
+	}
+	if retries := getEnvInt("EMAIL_MAX_RETRIES", config.MaxRetries); retries > 0 {
+		config.MaxRetries = retries
+	}
+	if delay := getEnvInt("EMAIL_RETRY_DELAY_MS", int(config.RetryDelay/time.Millisecond)); delay >= 0 {
+		config.RetryDelay = time.Duration(delay) * time.Millisecond
+	}
+
+	if hours := getEnvInt("EMAIL_RETENTION_HOURS", int(config.Retention/time.Hour)); hours > 0 {
+		config.Retention = time.Duration(hours) * time.Hour
+	}
+
+	if aging := getEnvInt("EMAIL_PRIORITY_AGING_MS", int(config.PriorityAging/time.Millisecond)); aging >= 0 {
+		config.PriorityAging = time.Duration(aging) * time.Millisecond
+	}
+
+	config.DomainRateLimits = parseDomainRateLimits(os.Getenv("EMAIL_DOMAIN_RATE_LIMITS"))
+	if limit := getEnvInt("EMAIL_DEFAULT_DOMAIN_RATE_LIMIT", config.DefaultDomainRateLimit); limit >= 0 {
+		config.DefaultDomainRateLimit = limit
+	}
+
+	config.UseChangeStream = os.Getenv("EMAIL_QUEUE_CHANGE_STREAM") == "true"
+	config.DryRun = os.Getenv("EMAIL_DRY_RUN") == "true"
+
+	return config
+}
+
 // ensureInitialized ensures the service is initialized
 func (s *EmailService) ensureInitialized() error {
 	s.mu.Lock()
@@ -41,35 +166,159 @@ func (s *EmailService) ensureInitialized() error {
 		return nil
 	}
 
-	// Check if MongoDB is connected
-	if database.MongoDB == nil {
-		return fmt.Errorf("MongoDB not connected")
+	// Nothing is stored on the service until every step succeeded, so a
+	// failed initialization is retried on the next request
+	poolKey := workerPoolKey()
+	if err := claimWorkerPool(poolKey); err != nil {
+		return err
 	}
 
-	// Create queue
-	queue := queue.NewMongoQueue()
+	// A sender allow list that can't be read fails every send instead of
+	// allowing any sender
+	senders, err := loadSenderAllowList()
+	if err != nil {
+		releaseWorkerPool(poolKey)
+		logger.LogError("Email service disabled: " + err.Error())
+		return err
+	}
+
+	workerConfig := loadWorkerConfig()
+	queue, suppressions, err := createStores(workerConfig.Retention)
+	if err != nil {
+		releaseWorkerPool(poolKey)
+		return err
+	}
+
+	auditTrail, err := createAuditTrail()
+	if err != nil {
+		releaseWorkerPool(poolKey)
+		return err
+	}
 
 	// Create providers
 	providers := createProviders()
 
 	// Create worker
-	worker := workers.NewEmailWorker(queue, providers, nil)
+	worker := workers.NewEmailWorker(queue, providers, auditTrail, workerConfig)
 
-	// Start worker
-	worker.Start()
+	// API-only nodes leave the queue to a separate worker process
+	autoStart := os.Getenv("EMAIL_WORKER_ENABLED") != "false"
+	if autoStart {
+		worker.Start()
+	}
 
 	s.queue = queue
+	s.suppressions = suppressions
+	s.auditTrail = auditTrail
 	s.worker = worker
 	s.providers = providers
+	s.workerConfig = workerConfig
+	s.senders = senders
+	s.workerPool = poolKey
+	s.autoStart = autoStart
 	s.initialized = true
 
 	return nil
 }
 
+// Start initializes the service right away and starts the email worker, even
+// when EMAIL_WORKER_ENABLED is false. It is meant for processes that only run
+// workers and never receive the request that would initialize the service lazily.
+func (s *EmailService) Start() error {
+	if err := s.ensureInitialized(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.autoStart = true
+	s.mu.Unlock()
+
+	if !s.worker.IsRunning() {
+		s.worker.Start()
+	}
+	return nil
+}
+
+// createStores creates the queue and suppression list for the configured backend.
+// EMAIL_QUEUE_BACKEND can be 'mongo' (default), 'redis' (REDIS_URL) or 'memory'
+// (single node, no persistence).
+func createStores(retention time.Duration) (queue.Queue, suppression.List, error) {
+	switch backend := os.Getenv("EMAIL_QUEUE_BACKEND"); backend {
+	case "memory":
+		return queue.NewMemoryQueue(), suppression.NewMemorySuppressionList(), nil
+	case "redis":
+		redisURL := os.Getenv("REDIS_URL")
+		if redisURL == "" {
+			return nil, nil, fmt.Errorf("REDIS_URL is required for the redis queue backend")
+		}
+
+		// Share the connection opened at startup; dial REDIS_URL only when it failed
+		var redisQueue *queue.RedisQueue
+		if redisclient.Client != nil {
+			redisQueue = queue.NewRedisQueueWithClient(redisclient.Client, retention)
+		} else {
+			var err error
+			if redisQueue, err = queue.NewRedisQueue(redisURL, retention); err != nil {
+				return nil, nil, err
+			}
+		}
+
+		// Suppressions stay in MongoDB when it is available
+		if database.MongoDB() == nil {
+			logger.LogWarn("MongoDB not connected, keeping the email suppression list in memory")
+			return redisQueue, suppression.NewMemorySuppressionList(), nil
+		}
+		suppressions, err := suppression.NewMongoSuppressionList()
+		if err != nil {
+			redisQueue.Close()
+			return nil, nil, fmt.Errorf("failed to create suppression list: %w", err)
+		}
+		return redisQueue, suppressions, nil
+	case "", "mongo":
+		// Check if MongoDB is connected
+		if database.MongoDB() == nil {
+			return nil, nil, fmt.Errorf("MongoDB not connected")
+		}
+
+		suppressions, err := suppression.NewMongoSuppressionList()
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create suppression list: %w", err)
+		}
+
+		mongoQueue, err := queue.NewMongoQueue(os.Getenv("EMAIL_QUEUE_DB"), os.Getenv("EMAIL_QUEUE_COLLECTION"), retention)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create email queue: %w", err)
+		}
+
+		return mongoQueue, suppressions, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown EMAIL_QUEUE_BACKEND %q", backend)
+	}
+}
+
+// createAuditTrail creates the email audit trail, kept in MongoDB whenever it is
+// connected so the history survives restarts whatever the queue backend
+func createAuditTrail() (audit.Trail, error) {
+	if database.MongoDB() == nil {
+		return audit.NewMemoryTrail(), nil
+	}
+
+	trail, err := audit.NewMongoTrail()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create email audit trail: %w", err)
+	}
+	return trail, nil
+}
+
 // createProviders creates and configures email providers
 func createProviders() []providers.EmailProvider {
 	var emailProviders []providers.EmailProvider
 
+	sendTimeout := providers.DefaultSendTimeout
+	if timeout := getEnvInt("EMAIL_SEND_TIMEOUT_MS", int(sendTimeout/time.Millisecond)); timeout > 0 {
+		sendTimeout = time.Duration(timeout) * time.Millisecond
+	}
+
 	// Add SMTP provider if configured
 	if smtpHost := os.Getenv("SMTP_HOST"); smtpHost != "" {
 		smtpPort := 587 // Default to 587
@@ -79,20 +328,63 @@ func createProviders() []providers.EmailProvider {
 			}
 		}
 
+		smtpEncryption := strings.ToLower(os.Getenv("SMTP_ENCRYPTION"))
+		if !providers.ValidSMTPEncryption(smtpEncryption) {
+			logger.LogWarn(fmt.Sprintf("Ignoring invalid SMTP_ENCRYPTION %q, using auto", smtpEncryption))
+			smtpEncryption = providers.SMTPEncryptionAuto
+		}
+
 		smtpConfig := &providers.ProviderConfig{
-			SMTPHost:         smtpHost,
-			SMTPPort:         smtpPort,
-			SMTPUsername:     os.Getenv("SMTP_USERNAME"),
-			SMTPPassword:     os.Getenv("SMTP_PASSWORD"),
-			SMTPFrom:         os.Getenv("SMTP_FROM"),
-			MaxEmailsPerHour: getEnvInt("SMTP_MAX_EMAILS_PER_HOUR", 1000),
-			MaxEmailsPerDay:  getEnvInt("SMTP_MAX_EMAILS_PER_DAY", 10000),
+			SMTPHost:              smtpHost,
+			SMTPPort:              smtpPort,
+			SMTPUsername:          os.Getenv("SMTP_USERNAME"),
+			SMTPPassword:          os.Getenv("SMTP_PASSWORD"),
+			SMTPFrom:              os.Getenv("SMTP_FROM"),
+			SMTPEncryption:        smtpEncryption,
+			SMTPAllowInsecureAuth: os.Getenv("SMTP_ALLOW_INSECURE_AUTH") == "true",
+			MaxEmailsPerHour:      getEnvInt("SMTP_MAX_EMAILS_PER_HOUR", 1000),
+			MaxEmailsPerDay:       getEnvInt("SMTP_MAX_EMAILS_PER_DAY", 10000),
+			SendTimeout:           sendTimeout,
 		}
 
 		smtpProvider := providers.NewSMTPProvider(smtpConfig)
 		emailProviders = append(emailProviders, smtpProvider)
 	}
 
+	// Add Amazon SES provider if configured
+	if sesFrom := os.Getenv("SES_FROM"); sesFrom != "" {
+		sesConfig := &providers.ProviderConfig{
+			SESRegion:        os.Getenv("SES_REGION"),
+			SESFrom:          sesFrom,
+			MaxEmailsPerHour: getEnvInt("SES_MAX_EMAILS_PER_HOUR", 10000),
+			MaxEmailsPerDay:  getEnvInt("SES_MAX_EMAILS_PER_DAY", 50000),
+			SendTimeout:      sendTimeout,
+		}
+
+		sesProvider, err := providers.NewSESProvider(sesConfig)
+		if err != nil {
+			logger.LogError("Failed to configure SES provider: " + err.Error())
+		} else {
+			emailProviders = append(emailProviders, sesProvider)
+		}
+	}
+
+	// Add Mailgun provider if configured
+	if mailgunDomain := os.Getenv("MAILGUN_DOMAIN"); mailgunDomain != "" && os.Getenv("MAILGUN_API_KEY") != "" {
+		mailgunConfig := &providers.ProviderConfig{
+			MailgunDomain:    mailgunDomain,
+			MailgunAPIKey:    os.Getenv("MAILGUN_API_KEY"),
+			MailgunFrom:      os.Getenv("MAILGUN_FROM"),
+			MailgunBaseURL:   os.Getenv("MAILGUN_BASE_URL"),
+			MaxEmailsPerHour: getEnvInt("MAILGUN_MAX_EMAILS_PER_HOUR", 10000),
+			MaxEmailsPerDay:  getEnvInt("MAILGUN_MAX_EMAILS_PER_DAY", 100000),
+			SendTimeout:      sendTimeout,
+		}
+
+		mailgunProvider := providers.NewMailgunProvider(mailgunConfig)
+		emailProviders = append(emailProviders, mailgunProvider)
+	}
+
 	// Add SendGrid provider if configured
 	if sendGridKey := os.Getenv("SENDGRID_API_KEY"); sendGridKey != "" {
 		_ = &providers.ProviderConfig{
@@ -113,9 +405,46 @@ func createProviders() []providers.EmailProvider {
 		emailProviders = append(emailProviders, dummyProvider)
 	}
 
+	// Guard every provider with a circuit breaker so a failing one is skipped quickly
+	breakerConfig := loadCircuitBreakerConfig()
+	for i, provider := range emailProviders {
+		emailProviders[i] = providers.NewCircuitBreaker(provider, breakerConfig)
+	}
+
 	return emailProviders
 }
 
+// loadCircuitBreakerConfig builds the provider circuit breaker configuration from the environment
+func loadCircuitBreakerConfig() *providers.CircuitBreakerConfig {
+	config := providers.DefaultCircuitBreakerConfig()
+
+	if threshold := getEnvInt("EMAIL_BREAKER_FAILURE_THRESHOLD", config.FailureThreshold); threshold > 0 {
+		config.FailureThreshold = threshold
+	}
+	if timeout := getEnvInt("EMAIL_BREAKER_OPEN_TIMEOUT_MS", int(config.OpenTimeout/time.Millisecond)); timeout > 0 {
+		config.OpenTimeout = time.Duration(timeout) * time.Millisecond
+	}
+
+	return config
+}
+
+// parseDomainRateLimits parses per-domain sends per minute, e.g. "gmail.com=60,outlook.com=30"
+func parseDomainRateLimits(value string) map[string]int {
+	limits := make(map[string]int)
+	for _, entry := range strings.Split(value, ",") {
+		domain, limit, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok {
+			continue
+		}
+		if n, err := strconv.Atoi(strings.TrimSpace(limit)); err == nil && n > 0 {
+			limits[strings.ToLower(strings.TrimSpace(domain))] = n
+		} else {
+			logger.LogWarn(fmt.Sprintf("Ignoring invalid EMAIL_DOMAIN_RATE_LIMITS entry %q", entry))
+		}
+	}
+	return limits
+}
+
 // getEnvInt gets an environment variable as integer with fallback
 func getEnvInt(key string, fallback int) int {
 	if value := os.Getenv(key); value != "" {
@@ -127,12 +456,93 @@ func getEnvInt(key string, fallback int) int {
 }
 
 // SendEmail queues an email for sending
-func (s *EmailService) SendEmail(req *models.SendEmailRequest) (*models.EmailResponse, error) {
+func (s *EmailService) SendEmail(ctx context.Context, req *models.SendEmailRequest) (*models.EmailResponse, error) {
 	// Ensure service is initialized
 	if err := s.ensureInitialized(); err != nil {
 		return nil, fmt.Errorf("service not ready: %w", err)
 	}
 
+	job, err := s.prepareJob(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Enqueue the job - a repeated idempotency key yields the original job
+	if err := s.queue.Enqueue(ctx, job); err != nil {
+		if errors.Is(err, queue.ErrDuplicateJob) {
+			response := newEmailResponse(job)
+			response.Replayed = true
+			return response, nil
+		}
+		s.worker.RecordError("enqueue", "", err)
+		return nil, fmt.Errorf("failed to enqueue email: %w", err)
+	}
+	s.recordAudit(ctx, job.ID, audit.EventQueued, "api", queuedDetails(ctx, job))
+
+	return newEmailResponse(job), nil
+}
+
+// ErrEmailNotDelivered is returned by SendEmailSync when the email permanently failed or bounced
+var ErrEmailNotDelivered = errors.New("email was not delivered")
+
+// SendEmailSync queues an email like SendEmail, then waits until it is sent or
+// permanently fails and returns its final status. Failed and bounced emails
+// return the status along with ErrEmailNotDelivered. When ctx ends first (e.g.
+// the HTTP client went away) a provider call in progress on this node is
+// aborted, the email stays queued to be retried, and the last known status is
+// returned with ctx's error.
+func (s *EmailService) SendEmailSync(ctx context.Context, req *models.SendEmailRequest) (*models.EmailStatus, error) {
+	response, err := s.SendEmail(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := s.WatchEmailStatus(ctx, response.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var status *models.EmailStatus
+	for event := range events {
+		status = event.(*models.EmailStatus)
+	}
+
+	// The watch also stops when ctx ends, before the email is final
+	if err := ctx.Err(); err != nil {
+		if id, parseErr := primitive.ObjectIDFromHex(response.ID); parseErr == nil && s.worker.CancelSend(id) {
+			logger.LogInfo(fmt.Sprintf("Aborted send of email %s: caller stopped waiting", response.ID))
+		}
+		return status, fmt.Errorf("waiting for email %s: %w", response.ID, err)
+	}
+
+	switch status.Status {
+	case models.StatusFailed, models.StatusBounced:
+		reason := status.DeliveryReason
+		if status.ErrorMessage != nil {
+			reason = *status.ErrorMessage
+		}
+		return status, fmt.Errorf("%w: %s", ErrEmailNotDelivered, reason)
+	}
+
+	return status, nil
+}
+
+// prepareJob renders, validates and rate limits a send request and builds its job
+func (s *EmailService) prepareJob(req *models.SendEmailRequest) (*models.EmailJob, error) {
+	// Fall back to the configured or single verified sender
+	if req.From == "" {
+		req.From = s.defaultSender()
+	}
+
+	// Store bare addresses, keeping display names separately
+	normalizeSendRequest(req)
+	normalizeAttachments(req)
+
+	// Render the template into the HTML body
+	if err := renderTemplate(req); err != nil {
+		return nil, err
+	}
+
 	// Validate request
 	if err := s.validateSendRequest(req); err != nil {
 		return nil, err
@@ -143,38 +553,249 @@ func (s *EmailService) SendEmail(req *models.SendEmailRequest) (*models.EmailRes
 		return nil, fmt.Errorf("rate limit exceeded: %w", err)
 	}
 
+	// Sign the recipient's unsubscribe token into the link
+	unsubscribeURL, err := buildUnsubscribeURL(req.UnsubscribeURL, req.To)
+	if err != nil {
+		return nil, err
+	}
+
+	// Requests may ask for more or fewer attempts than the configured default
+	maxAttempts := s.workerConfig.MaxRetries
+	if req.MaxAttempts > 0 {
+		maxAttempts = req.MaxAttempts
+	}
+
 	// Create email job
-	job := &models.EmailJob{
-		To:          req.To,
-		Subject:     req.Subject,
-		HTML:        req.HTML,
-		From:        req.From,
-		Priority:    req.Priority,
-		Status:      models.StatusPending,
-		CreatedAt:   time.Now(),
-		ScheduledAt: time.Now(),
-		MaxAttempts: 3,
-	}
-
-	// Enqueue the job
-	if err := s.queue.Enqueue(job); err != nil {
-		return nil, fmt.Errorf("failed to enqueue email: %w", err)
+	return &models.EmailJob{
+		To:             req.To,
+		Subject:        withSubjectPrefix(req.Subject),
+		HTML:           req.HTML,
+		From:           req.From,
+		Priority:       req.Priority,
+		Status:         models.StatusPending,
+		CreatedAt:      time.Now(),
+		ScheduledAt:    time.Now(),
+		MaxAttempts:    maxAttempts,
+		IdempotencyKey: req.IdempotencyKey,
+		UnsubscribeURL: unsubscribeURL,
+		DryRun:         req.DryRun || s.workerConfig.DryRun,
+		FromName:       req.FromName,
+		ToName:         req.ToName,
+		Cc:             req.Cc,
+		Bcc:            req.Bcc,
+		Attachments:    req.Attachments,
+	}, nil
+}
+
+// defaultSender returns the sender used when a request has none: EMAIL_DEFAULT_FROM,
+// or else the only address in EMAIL_ALLOWED_SENDERS
+func (s *EmailService) defaultSender() string {
+	if from := strings.TrimSpace(os.Getenv("EMAIL_DEFAULT_FROM")); from != "" {
+		return from
+	}
+	return s.senders.DefaultSender()
+}
+
+// withSubjectPrefix prepends EMAIL_SUBJECT_PREFIX (e.g. [STAGING]) to subject,
+// unless the subject already starts with it
+func withSubjectPrefix(subject string) string {
+	prefix := strings.TrimSpace(os.Getenv("EMAIL_SUBJECT_PREFIX"))
+	if prefix == "" || strings.HasPrefix(subject, prefix) {
+		return subject
+	}
+	return prefix + " " + subject
+}
+
+// maxAttemptsLimit caps the attempts a request may ask for
+const maxAttemptsLimit = 10
+
+// defaultMaxMessageBytes is the default cap on the size of an email, well below
+// MongoDB's 16MB document limit and the message size limits of the providers
+const defaultMaxMessageBytes = 10 * 1024 * 1024
+
+// maxMessageBytes returns the largest email accepted (EMAIL_MAX_MESSAGE_BYTES).
+// It is read per request since the service is created before .env is loaded.
+func maxMessageBytes() int {
+	if limit := getEnvInt("EMAIL_MAX_MESSAGE_BYTES", defaultMaxMessageBytes); limit > 0 {
+		return limit
+	}
+	return defaultMaxMessageBytes
+}
+
+// messageSize returns the size of an email's content: its subject, HTML body
+// and attachments
+func messageSize(req *models.SendEmailRequest) int {
+	size := len(req.Subject) + len(req.HTML)
+	for _, attachment := range req.Attachments {
+		size += len(attachment.Content)
+	}
+	return size
+}
+
+// maxCopyRecipients caps the cc and bcc recipients of a single email
+const maxCopyRecipients = 50
+
+// maxBulkRecipients caps the recipients of a single bulk send
+const maxBulkRecipients = 1000
+
+// ErrCampaignNotFound is returned for campaign IDs without any email
+var ErrCampaignNotFound = errors.New("campaign not found")
+
+// SendBulkEmail sends one email to many recipients. With FanOut every recipient
+// gets its own job under a shared campaign ID and the jobs are enqueued in one
+// batch; otherwise each recipient is queued like a separate /send. Invalid
+// recipients are reported in the results and never block the others.
+func (s *EmailService) SendBulkEmail(ctx context.Context, req *models.SendBulkEmailRequest) (*models.BulkEmailResponse, error) {
+	// Ensure service is initialized
+	if err := s.ensureInitialized(); err != nil {
+		return nil, fmt.Errorf("service not ready: %w", err)
+	}
+
+	if len(req.Recipients) == 0 {
+		return nil, router.NewValidationError("recipients", "At least one recipient is required")
 	}
+	if len(req.Recipients) > maxBulkRecipients {
+		return nil, router.NewValidationError("recipients", fmt.Sprintf("At most %d recipients are allowed", maxBulkRecipients))
+	}
+
+	// Render the template once for every recipient
+	base := models.SendEmailRequest{
+		Subject:        req.Subject,
+		HTML:           req.HTML,
+		From:           req.From,
+		Priority:       req.Priority,
+		UnsubscribeURL: req.UnsubscribeURL,
+		DryRun:         req.DryRun,
+		Template:       req.Template,
+		TemplateData:   req.TemplateData,
+		FromName:       req.FromName,
+		MaxAttempts:    req.MaxAttempts,
+		Attachments:    req.Attachments,
+	}
+	if err := renderTemplate(&base); err != nil {
+		return nil, err
+	}
+	base.Template = ""
+
+	response := &models.BulkEmailResponse{Results: make([]models.BulkEmailResult, len(req.Recipients))}
+	if req.FanOut {
+		response.CampaignID = primitive.NewObjectID().Hex()
+	}
+
+	var jobs []*models.EmailJob
+	var jobResults []int
+	for i, to := range req.Recipients {
+		response.Results[i].To = to
+		single := base
+		single.To = to
+
+		if !req.FanOut {
+			sent, err := s.SendEmail(ctx, &single)
+			if err != nil {
+				response.Results[i].Error = err.Error()
+				continue
+			}
+			response.Results[i].ID = sent.ID
+			continue
+		}
+
+		job, err := s.prepareJob(&single)
+		if err != nil {
+			response.Results[i].Error = err.Error()
+			continue
+		}
+		job.CampaignID = response.CampaignID
+		jobs = append(jobs, job)
+		jobResults = append(jobResults, i)
+	}
+
+	if len(jobs) > 0 {
+		if err := s.queue.EnqueueBatch(ctx, jobs); err != nil {
+			s.worker.RecordError("enqueue", "", err)
+			return nil, fmt.Errorf("failed to enqueue emails: %w", err)
+		}
+		for j, job := range jobs {
+			response.Results[jobResults[j]].ID = job.ID.Hex()
+			s.recordAudit(ctx, job.ID, audit.EventQueued, "api", queuedDetails(ctx, job))
+		}
+	}
+
+	for _, result := range response.Results {
+		if result.Error != "" {
+			response.Rejected++
+		} else {
+			response.Queued++
+		}
+	}
+
+	return response, nil
+}
+
+// GetCampaignStatus aggregates the status of the emails of a fan-out campaign
+func (s *EmailService) GetCampaignStatus(ctx context.Context, campaignID string) (*models.CampaignStatus, error) {
+	// Ensure service is initialized
+	if err := s.ensureInitialized(); err != nil {
+		return nil, fmt.Errorf("service not ready: %w", err)
+	}
+
+	counts, err := s.queue.CountByCampaign(ctx, campaignID)
+	if err != nil {
+		return nil, err
+	}
+	if len(counts) == 0 {
+		return nil, ErrCampaignNotFound
+	}
+
+	status := &models.CampaignStatus{ID: campaignID, ByStatus: counts}
+	for _, count := range counts {
+		status.Total += count
+	}
+	return status, nil
+}
+
+// PreviewEmail renders and validates a send request without enqueueing it
+func (s *EmailService) PreviewEmail(req *models.SendEmailRequest) (*models.EmailPreview, error) {
+	// Ensure service is initialized
+	if err := s.ensureInitialized(); err != nil {
+		return nil, fmt.Errorf("service not ready: %w", err)
+	}
+
+	if req.From == "" {
+		req.From = s.defaultSender()
+	}
+	normalizeSendRequest(req)
+	normalizeAttachments(req)
+
+	if err := renderTemplate(req); err != nil {
+		return nil, err
+	}
+
+	if err := s.validateSendRequest(req); err != nil {
+		return nil, err
+	}
+
+	return &models.EmailPreview{
+		To:      req.To,
+		From:    req.From,
+		Subject: withSubjectPrefix(req.Subject),
+		HTML:    req.HTML,
+		Text:    htmlToText(req.HTML),
+	}, nil
+}
 
-	// Create response
-	response := &models.EmailResponse{
+// newEmailResponse builds the API response for a queued job
+func newEmailResponse(job *models.EmailJob) *models.EmailResponse {
+	return &models.EmailResponse{
 		ID:                job.ID.Hex(),
 		Status:            "queued",
 		Message:           "Email queued successfully",
 		QueuedAt:          job.CreatedAt,
-		EstimatedDelivery: time.Now().Add(5 * time.Minute), // Estimate 5 minutes
+		EstimatedDelivery: job.CreatedAt.Add(5 * time.Minute), // Estimate 5 minutes
 	}
-
-	return response, nil
 }
 
 // GetEmailStatus returns the status of an email
-func (s *EmailService) GetEmailStatus(emailID string) (*models.EmailStatus, error) {
+func (s *EmailService) GetEmailStatus(ctx context.Context, emailID string) (*models.EmailStatus, error) {
 	// Ensure service is initialized
 	if err := s.ensureInitialized(); err != nil {
 		return nil, fmt.Errorf("service not ready: %w", err)
@@ -187,7 +808,7 @@ func (s *EmailService) GetEmailStatus(emailID string) (*models.EmailStatus, erro
 	}
 
 	// Get job from queue
-	job, err := s.queue.GetJobByID(objectID)
+	job, err := s.queue.GetJobByID(ctx, objectID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get email job: %w", err)
 	}
@@ -197,29 +818,229 @@ func (s *EmailService) GetEmailStatus(emailID string) (*models.EmailStatus, erro
 	}
 
 	// Convert to status response
-	status := &models.EmailStatus{
-		ID:            job.ID.Hex(),
-		Status:        job.Status,
-		To:            job.To,
-		Subject:       job.Subject,
-		CreatedAt:     job.CreatedAt,
-		ProcessedAt:   job.ProcessedAt,
-		ErrorMessage:  job.ErrorMessage,
-		Provider:      job.Provider,
-		ProviderMsgID: job.ProviderMsgID,
+	return newEmailStatus(job), nil
+}
+
+// ErrEmailHistoryNotFound is returned for email IDs without any recorded event
+var ErrEmailHistoryNotFound = errors.New("email history not found")
+
+// GetEmailHistory returns the audit trail of an email, oldest event first. The
+// history is kept after the email itself has been cleaned up.
+func (s *EmailService) GetEmailHistory(ctx context.Context, emailID string) ([]audit.Event, error) {
+	// Ensure service is initialized
+	if err := s.ensureInitialized(); err != nil {
+		return nil, fmt.Errorf("service not ready: %w", err)
 	}
 
-	return status, nil
+	objectID, err := parseObjectID(emailID)
+	if err != nil {
+		return nil, router.NewValidationError("id", "Invalid email ID")
+	}
+
+	events, err := s.auditTrail.History(ctx, objectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get email history: %w", err)
+	}
+	if len(events) == 0 {
+		return nil, ErrEmailHistoryNotFound
+	}
+
+	return events, nil
+}
+
+// recordAudit appends an event to an email's audit trail. A failure to record
+// is logged but never fails the request.
+func (s *EmailService) recordAudit(ctx context.Context, jobID primitive.ObjectID, event, actor string, details map[string]string) {
+	err := s.auditTrail.Record(ctx, audit.Event{JobID: jobID, Type: event, Actor: actor, Details: details})
+	if err != nil {
+		logger.LogWarn(fmt.Sprintf("Failed to record %s audit event for email %s: %v", event, jobID.Hex(), err))
+	}
+}
+
+// queuedDetails describes a newly queued email for its audit trail
+func queuedDetails(ctx context.Context, job *models.EmailJob) map[string]string {
+	details := map[string]string{"to": job.To}
+	if requestID := logger.FromContext(ctx).Field("request_id"); requestID != "" {
+		details["request_id"] = requestID
+	}
+	if job.CampaignID != "" {
+		details["campaign_id"] = job.CampaignID
+	}
+	return details
+}
+
+// ListEmails returns a page of emails matching the filter and the total number of matches
+func (s *EmailService) ListEmails(ctx context.Context, filter queue.ListFilter) ([]*models.EmailStatus, int64, error) {
+	// Ensure service is initialized
+	if err := s.ensureInitialized(); err != nil {
+		return nil, 0, fmt.Errorf("service not ready: %w", err)
+	}
+
+	jobs, total, err := s.queue.ListJobs(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list emails: %w", err)
+	}
+
+	statuses := make([]*models.EmailStatus, len(jobs))
+	for i := range jobs {
+		statuses[i] = newEmailStatus(&jobs[i])
+	}
+
+	return statuses, total, nil
+}
+
+// purgeableStatuses are the statuses PurgeEmails accepts
+var purgeableStatuses = map[string]bool{
+	models.StatusFailed:     true,
+	models.StatusSent:       true,
+	models.StatusBounced:    true,
+	models.StatusComplained: true,
+}
+
+// PurgeEmails removes finished emails with the given status processed before
+// the cutoff, returning how many were removed. Failed emails with retries left
+// are never removed.
+func (s *EmailService) PurgeEmails(ctx context.Context, status string, before time.Time) (int64, error) {
+	if !purgeableStatuses[status] {
+		return 0, router.NewValidationError("status", "Status must be one of failed, sent, bounced or complained", status)
+	}
+
+	// Ensure service is initialized
+	if err := s.ensureInitialized(); err != nil {
+		return 0, fmt.Errorf("service not ready: %w", err)
+	}
+
+	removed, err := s.queue.PurgeJobs(ctx, queue.PurgeFilter{Status: status, Before: before})
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge emails: %w", err)
+	}
+
+	logger.LogInfo(fmt.Sprintf("Purged %d %s emails processed before %s", removed, status, before.Format(time.RFC3339)))
+	return removed, nil
+}
+
+// statusPollInterval is how often WatchEmailStatus checks an email for changes
+const statusPollInterval = time.Second
+
+// WatchEmailStatus sends the email's status every time it changes. The channel is
+// closed once the email reaches a final state or ctx is cancelled.
+func (s *EmailService) WatchEmailStatus(ctx context.Context, emailID string) (<-chan interface{}, error) {
+	// Ensure service is initialized
+	if err := s.ensureInitialized(); err != nil {
+		return nil, fmt.Errorf("service not ready: %w", err)
+	}
+
+	objectID, err := parseObjectID(emailID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid email ID: %w", err)
+	}
+
+	job, err := s.queue.GetJobByID(ctx, objectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get email job: %w", err)
+	}
+	if job == nil {
+		return nil, fmt.Errorf("email not found")
+	}
+
+	events := make(chan interface{})
+	go func() {
+		defer close(events)
+
+		ticker := time.NewTicker(statusPollInterval)
+		defer ticker.Stop()
+
+		lastStatus := ""
+		for {
+			if job.Status != lastStatus {
+				select {
+				case events <- newEmailStatus(job):
+				case <-ctx.Done():
+					return
+				}
+				lastStatus = job.Status
+			}
+
+			if isFinalStatus(job) {
+				return
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			// Keep the last known state on transient lookup errors
+			if next, err := s.queue.GetJobByID(ctx, objectID); err == nil && next != nil {
+				job = next
+			}
+		}
+	}()
+
+	return events, nil
+}
+
+// isFinalStatus reports whether the job will not change status again (short of delivery events)
+func isFinalStatus(job *models.EmailJob) bool {
+	switch job.Status {
+	case models.StatusSent, models.StatusBounced, models.StatusComplained:
+		return true
+	case models.StatusFailed:
+		return job.Attempts >= job.MaxAttempts
+	}
+	return false
+}
+
+// newEmailStatus converts a job into its status representation
+func newEmailStatus(job *models.EmailJob) *models.EmailStatus {
+	return &models.EmailStatus{
+		ID:             job.ID.Hex(),
+		Status:         job.Status,
+		To:             job.To,
+		Subject:        job.Subject,
+		CreatedAt:      job.CreatedAt,
+		ProcessedAt:    job.ProcessedAt,
+		ErrorMessage:   job.ErrorMessage,
+		Provider:       job.Provider,
+		ProviderMsgID:  job.ProviderMsgID,
+		DeliveryReason: job.DeliveryReason,
+		DryRun:         job.DryRun,
+		CampaignID:     job.CampaignID,
+		Delivered:      job.Delivered,
+	}
 }
 
 // GetStats returns email statistics
-func (s *EmailService) GetStats() (*models.EmailStats, error) {
+func (s *EmailService) GetStats(ctx context.Context) (*models.EmailStats, error) {
+	// Ensure service is initialized
+	if err := s.ensureInitialized(); err != nil {
+		return nil, fmt.Errorf("service not ready: %w", err)
+	}
+
+	return s.worker.GetStats(ctx)
+}
+
+// WorkerConfig returns the effective configuration of the email worker, to
+// confirm which environment overrides took effect
+func (s *EmailService) WorkerConfig() (*workers.WorkerConfig, error) {
+	// Ensure service is initialized
+	if err := s.ensureInitialized(); err != nil {
+		return nil, fmt.Errorf("service not ready: %w", err)
+	}
+
+	config := s.worker.Config()
+	return &config, nil
+}
+
+// RecentErrors returns the most recent queue and provider errors, newest first
+func (s *EmailService) RecentErrors() ([]models.ErrorRecord, error) {
 	// Ensure service is initialized
 	if err := s.ensureInitialized(); err != nil {
 		return nil, fmt.Errorf("service not ready: %w", err)
 	}
 
-	return s.worker.GetStats()
+	return s.worker.RecentErrors(), nil
 }
 
 // validateSendRequest validates the send email request
@@ -233,13 +1054,23 @@ func (s *EmailService) validateSendRequest(req *models.SendEmailRequest) error {
 	}
 
 	if req.HTML == "" {
-		return fmt.Errorf("HTML content is required")
+		return fmt.Errorf("HTML content or a template is required")
 	}
 
 	if req.From == "" {
 		return fmt.Errorf("sender email is required")
 	}
 
+	// Oversized emails would only fail later, at enqueue or at the provider
+	if size, limit := messageSize(req), maxMessageBytes(); size > limit {
+		return router.NewValidationError("html", fmt.Sprintf("Email is %d bytes, more than the %d bytes allowed", size, limit), strconv.Itoa(size))
+	}
+
+	// Only verified senders may be used
+	if !s.senders.Allows(req.From) {
+		return router.NewValidationError("from", "Sender is not in the list of allowed senders", req.From)
+	}
+
 	// Validate email formats
 	for _, provider := range s.providers {
 		if err := provider.ValidateEmail(req.To); err != nil {
@@ -250,11 +1081,176 @@ func (s *EmailService) validateSendRequest(req *models.SendEmailRequest) error {
 		}
 	}
 
+	// Copied recipients must be valid addresses too
+	if len(req.Cc)+len(req.Bcc) > maxCopyRecipients {
+		return router.NewValidationError("cc", fmt.Sprintf("At most %d cc and bcc recipients are allowed", maxCopyRecipients))
+	}
+	for _, copied := range []struct {
+		field     string
+		addresses []string
+	}{{"cc", req.Cc}, {"bcc", req.Bcc}} {
+		for _, address := range copied.addresses {
+			for _, provider := range s.providers {
+				if err := provider.ValidateEmail(address); err != nil {
+					return router.NewValidationError(copied.field, "Invalid email address: "+err.Error(), address)
+				}
+			}
+		}
+	}
+
 	// Validate priority
 	if req.Priority < 1 || req.Priority > 3 {
 		return fmt.Errorf("priority must be between 1 and 3")
 	}
 
+	// Zero uses the configured default
+	if req.MaxAttempts < 0 || req.MaxAttempts > maxAttemptsLimit {
+		return router.NewValidationError("max_attempts", fmt.Sprintf("Max attempts must be between 1 and %d", maxAttemptsLimit), strconv.Itoa(req.MaxAttempts))
+	}
+
+	// Display names end up in headers, so they must stay on one line
+	if strings.ContainsAny(req.FromName, "\r\n") {
+		return router.NewValidationError("from_name", "Sender name must not contain line breaks", req.FromName)
+	}
+	if strings.ContainsAny(req.ToName, "\r\n") {
+		return router.NewValidationError("to_name", "Recipient name must not contain line breaks", req.ToName)
+	}
+
+	if err := validateAttachments(req.Attachments); err != nil {
+		return err
+	}
+
+	// The unsubscribe link must be an absolute http(s) URL
+	if req.UnsubscribeURL != "" {
+		parsed, err := url.Parse(strings.ReplaceAll(req.UnsubscribeURL, unsubscribeTokenPlaceholder, "token"))
+		if err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" {
+			return router.NewValidationError("unsubscribe_url", "Unsubscribe URL must be an absolute http(s) URL", req.UnsubscribeURL)
+		}
+		if _, err := unsubscribeSecret(); err != nil && strings.Contains(req.UnsubscribeURL, unsubscribeTokenPlaceholder) {
+			return router.NewValidationError("unsubscribe_url", "Signed unsubscribe tokens are not configured", req.UnsubscribeURL)
+		}
+	}
+
+	// Reject recipients that hard-bounced or complained before
+	for _, recipient := range append(append([]string{req.To}, req.Cc...), req.Bcc...) {
+		entry, err := s.suppressions.Get(recipient)
+		if err != nil {
+			return err
+		}
+		if entry != nil {
+			return fmt.Errorf("recipient %s is suppressed (%s: %s)", recipient, entry.Source, entry.Reason)
+		}
+	}
+
+	return nil
+}
+
+// Unsubscribe verifies a signed unsubscribe token and suppresses the address it identifies
+func (s *EmailService) Unsubscribe(token string) (string, error) {
+	// Ensure service is initialized
+	if err := s.ensureInitialized(); err != nil {
+		return "", fmt.Errorf("service not ready: %w", err)
+	}
+
+	email, err := verifyUnsubscribeToken(token)
+	if err != nil {
+		return "", err
+	}
+
+	if err := s.suppressions.Add(email, "unsubscribe", "recipient unsubscribed"); err != nil {
+		return "", fmt.Errorf("failed to record unsubscribe: %w", err)
+	}
+
+	return email, nil
+}
+
+// HandleWebhook verifies and processes a provider delivery webhook and returns
+// the number of events applied
+func (s *EmailService) HandleWebhook(ctx context.Context, provider string, header http.Header, body []byte) (int, error) {
+	// Forged events must never reach the suppression list
+	if err := webhooks.Verify(provider, header, body, loadWebhookVerifyConfig()); err != nil {
+		return 0, err
+	}
+
+	// Ensure service is initialized
+	if err := s.ensureInitialized(); err != nil {
+		return 0, fmt.Errorf("service not ready: %w", err)
+	}
+
+	events, err := webhooks.Parse(provider, body)
+	if errors.Is(err, webhooks.ErrSubscriptionConfirmation) {
+		return 0, confirmSNSSubscription(body)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	applied := 0
+	for _, event := range events {
+		status := models.StatusBounced
+		if event.Type == webhooks.EventComplaint {
+			status = models.StatusComplained
+		}
+
+		if event.ProviderMsgID != "" {
+			jobID, err := s.queue.MarkDeliveryEvent(ctx, event.ProviderMsgID, status, event.Reason)
+			if err != nil {
+				return applied, err
+			}
+			if jobID.IsZero() {
+				logger.LogWarn(fmt.Sprintf("Webhook %s event for unknown message %s", provider, event.ProviderMsgID))
+			} else {
+				s.recordAudit(ctx, jobID, status, "webhook:"+provider, map[string]string{"reason": event.Reason})
+			}
+		}
+
+		// Hard bounces and complaints must never be mailed again
+		if event.Email != "" && (event.Permanent || event.Type == webhooks.EventComplaint) {
+			if err := s.suppressions.Add(event.Email, event.Type, event.Reason); err != nil {
+				return applied, err
+			}
+		}
+
+		applied++
+	}
+
+	return applied, nil
+}
+
+// loadWebhookVerifyConfig reads the webhook verification keys from the environment.
+// It runs per webhook since the service is created before .env is loaded.
+func loadWebhookVerifyConfig() webhooks.VerifyConfig {
+	return webhooks.VerifyConfig{
+		SendGridPublicKey: os.Getenv("SENDGRID_WEBHOOK_PUBLIC_KEY"),
+		MailgunSigningKey: os.Getenv("MAILGUN_WEBHOOK_SIGNING_KEY"),
+		AllowUnsigned:     os.Getenv("EMAIL_WEBHOOK_ALLOW_UNSIGNED") == "true",
+	}
+}
+
+// confirmSNSSubscription confirms an SNS topic subscription for the SES webhook
+func confirmSNSSubscription(body []byte) error {
+	message, err := webhooks.ParseSNSMessage(body)
+	if err != nil {
+		return err
+	}
+
+	subscribeURL, err := url.Parse(message.SubscribeURL)
+	if err != nil || subscribeURL.Scheme != "https" || !strings.HasSuffix(subscribeURL.Hostname(), ".amazonaws.com") {
+		return fmt.Errorf("refusing to confirm SNS subscription with URL %q", message.SubscribeURL)
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Get(subscribeURL.String())
+	if err != nil {
+		return fmt.Errorf("failed to confirm SNS subscription: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("SNS subscription confirmation returned status %d", resp.StatusCode)
+	}
+
+	logger.LogInfo("Confirmed SNS subscription for topic " + message.TopicArn)
 	return nil
 }
 
@@ -275,17 +1271,147 @@ func parseObjectID(id string) (primitive.ObjectID, error) {
 	return objectID, nil
 }
 
-// Stop stops the email service
-func (s *EmailService) Stop() {
+// HealthCheck reports whether the email worker is up and processing the queue
+func (s *EmailService) HealthCheck(ctx context.Context) error {
+	if err := s.ensureInitialized(); err != nil {
+		return fmt.Errorf("service not ready: %w", err)
+	}
+
+	if s.workerExpected() && !s.worker.IsRunning() {
+		return fmt.Errorf("email worker is not running")
+	}
+
+	return nil
+}
+
+// workerExpected reports whether this process is supposed to run the email worker
+func (s *EmailService) workerExpected() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.autoStart
+}
+
+// Health reports the state of the worker, MongoDB and the queue, and whether all of them are usable
+func (s *EmailService) Health(ctx context.Context) (map[string]interface{}, bool) {
+	healthy := true
+	health := map[string]interface{}{}
+
+	// Worker
+	workerStatus := map[string]interface{}{"running": false}
+	if err := s.ensureInitialized(); err != nil {
+		healthy = false
+		workerStatus["error"] = fmt.Sprintf("service not ready: %v", err)
+	} else if s.worker.IsRunning() {
+		workerStatus["running"] = true
+	} else if s.workerExpected() {
+		healthy = false
+		workerStatus["error"] = "email worker is not running"
+	} else {
+		workerStatus["enabled"] = false
+	}
+	health["worker"] = workerStatus
+
+	// MongoDB is only a hard dependency when it backs the queue
+	backend := os.Getenv("EMAIL_QUEUE_BACKEND")
+	required := backend == "" || backend == "mongo"
+	dbStatus := map[string]interface{}{"connected": true, "required": required}
+	if err := database.HealthCheck(ctx); err != nil {
+		dbStatus["connected"] = false
+		dbStatus["error"] = err.Error()
+		if required {
+			healthy = false
+		}
+	}
+	health["database"] = dbStatus
+
+	// Queue
 	if s.worker != nil {
-		s.worker.Stop()
+		queueStatus := map[string]interface{}{}
+		if count, err := s.worker.GetPendingCount(ctx); err != nil {
+			healthy = false
+			queueStatus["error"] = err.Error()
+		} else {
+			queueStatus["pending_count"] = count
+		}
+		health["queue"] = queueStatus
+	}
+
+	return health, healthy
+}
+
+// QueueDepth returns the number of pending jobs without forcing initialization
+func (s *EmailService) QueueDepth() (float64, error) {
+	s.mu.Lock()
+	worker := s.worker
+	s.mu.Unlock()
+
+	if worker == nil {
+		return 0, fmt.Errorf("service not initialized")
+	}
+
+	count, err := worker.GetPendingCount(context.Background())
+	if err != nil {
+		return 0, err
+	}
+	return float64(count), nil
+}
+
+// OldestPendingAge returns how long the oldest due pending job has waited, in seconds,
+// without forcing initialization
+func (s *EmailService) OldestPendingAge() (float64, error) {
+	stats, err := s.queueStats()
+	if err != nil {
+		return 0, err
+	}
+	return stats.OldestPendingAge.Seconds(), nil
+}
+
+// PendingByPriority returns the number of pending jobs per priority without forcing initialization
+func (s *EmailService) PendingByPriority() (map[string]float64, error) {
+	stats, err := s.queueStats()
+	if err != nil {
+		return nil, err
 	}
+
+	counts := make(map[string]float64, len(stats.PendingByPriority))
+	for priority, count := range stats.PendingByPriority {
+		counts[strconv.Itoa(priority)] = float64(count)
+	}
+	return counts, nil
+}
+
+// queueStats returns the queue statistics if the service has been initialized
+func (s *EmailService) queueStats() (*models.EmailStats, error) {
+	s.mu.Lock()
+	queue := s.queue
+	s.mu.Unlock()
+
+	if queue == nil {
+		return nil, fmt.Errorf("service not initialized")
+	}
+	return queue.GetQueueStats(context.Background())
+}
+
+// Stop stops the email service, draining in-flight sends until ctx is done
+func (s *EmailService) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	worker := s.worker
+	poolKey := s.workerPool
+	s.mu.Unlock()
+
+	if worker == nil {
+		return nil
+	}
+
+	err := worker.Stop(ctx)
+	releaseWorkerPool(poolKey)
+	return err
 }
 
 // DummyProvider is a dummy provider for testing when no real providers are configured
 type DummyProvider struct{}
 
-func (p *DummyProvider) Send(email *models.EmailJob) error {
+func (p *DummyProvider) Send(ctx context.Context, email *models.EmailJob) error {
 	// Simulate successful send
 	return nil
 }
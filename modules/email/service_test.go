@@ -0,0 +1,60 @@
+package email
+
+import (
+	"context"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/thenasky/go-framework/modules/email/models"
+	"github.com/thenasky/go-framework/modules/email/queue"
+)
+
+func TestWorkerPoolKeyResolvesDefaultCollection(t *testing.T) {
+	t.Setenv("EMAIL_QUEUE_BACKEND", "mongo")
+	t.Setenv("EMAIL_QUEUE_DB", "mail")
+
+	t.Setenv("EMAIL_QUEUE_COLLECTION", "")
+	unset := workerPoolKey()
+	t.Setenv("EMAIL_QUEUE_COLLECTION", queue.DefaultCollectionName)
+	explicit := workerPoolKey()
+	if unset != explicit {
+		t.Errorf("key with the default collection unset = %q, set explicitly = %q; both name the same queue", unset, explicit)
+	}
+
+	t.Setenv("EMAIL_QUEUE_COLLECTION", "other_queue")
+	if other := workerPoolKey(); other == explicit {
+		t.Errorf("key for another collection = %q, same as the default's", other)
+	}
+}
+
+func TestMaxRetriesIsDefaultAttempts(t *testing.T) {
+	t.Setenv("EMAIL_QUEUE_BACKEND", "memory")
+	t.Setenv("EMAIL_WORKER_ENABLED", "false")
+	t.Setenv("EMAIL_MAX_RETRIES", "5")
+	service := NewEmailService()
+	ctx := context.Background()
+
+	attempts := func(maxAttempts int) int {
+		t.Helper()
+		queued, err := service.SendEmail(ctx, &models.SendEmailRequest{
+			To: "user@example.com", From: "noreply@example.com", Subject: "Hi", HTML: "<p>Hi</p>", Priority: 2, MaxAttempts: maxAttempts,
+		})
+		if err != nil {
+			t.Fatalf("SendEmail: %v", err)
+		}
+		id, _ := primitive.ObjectIDFromHex(queued.ID)
+		job, err := service.queue.GetJobByID(ctx, id)
+		if err != nil {
+			t.Fatalf("GetJobByID: %v", err)
+		}
+		return job.MaxAttempts
+	}
+
+	if got := attempts(0); got != 5 {
+		t.Errorf("max attempts without max_attempts = %d, want EMAIL_MAX_RETRIES (5)", got)
+	}
+	if got := attempts(2); got != 2 {
+		t.Errorf("max attempts with max_attempts 2 = %d, want 2", got)
+	}
+}
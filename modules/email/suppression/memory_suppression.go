@@ -0,0 +1,47 @@
+package suppression
+
+import (
+	"sync"
+	"time"
+)
+
+// MemorySuppressionList keeps suppressed addresses in process memory
+type MemorySuppressionList struct {
+	mu      sync.RWMutex
+	entries map[string]Entry
+}
+
+// NewMemorySuppressionList creates a new in-memory suppression list
+func NewMemorySuppressionList() *MemorySuppressionList {
+	return &MemorySuppressionList{entries: make(map[string]Entry)}
+}
+
+// Add suppresses an address; adding an already suppressed address keeps the original entry
+func (l *MemorySuppressionList) Add(email, source, reason string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	key := normalize(email)
+	if _, exists := l.entries[key]; !exists {
+		l.entries[key] = Entry{
+			Email:     key,
+			Reason:    reason,
+			Source:    source,
+			CreatedAt: time.Now(),
+		}
+	}
+
+	return nil
+}
+
+// Get returns the suppression entry for an address, or nil if it isn't suppressed
+func (l *MemorySuppressionList) Get(email string) (*Entry, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	entry, ok := l.entries[normalize(email)]
+	if !ok {
+		return nil, nil
+	}
+	return &entry, nil
+}
@@ -0,0 +1,99 @@
+package suppression
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/thenasky/go-framework/internal/database"
+)
+
+// collectionName is the MongoDB collection holding suppressed addresses
+const collectionName = "email_suppressions"
+
+// Entry is a suppressed email address
+type Entry struct {
+	Email     string    `json:"email" bson:"email"`
+	Reason    string    `json:"reason" bson:"reason"`
+	Source    string    `json:"source" bson:"source"` // bounce, complaint, ...
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+}
+
+// indexes are the indexes of the suppression list collection
+var indexes = []mongo.IndexModel{
+	// One entry per address
+	{
+		Keys:    bson.D{{Key: "email", Value: 1}},
+		Options: options.Index().SetName("email_unique").SetUnique(true),
+	},
+}
+
+// MongoSuppressionList stores addresses that must not be mailed again
+type MongoSuppressionList struct {
+	collection *mongo.Collection
+	ctx        context.Context
+}
+
+// NewMongoSuppressionList creates a new MongoDB-based suppression list
+func NewMongoSuppressionList() (*MongoSuppressionList, error) {
+	db := database.MongoDB()
+	if db == nil {
+		return nil, fmt.Errorf("MongoDB not connected")
+	}
+
+	collection := db.Collection(collectionName)
+	if err := database.EnsureCollectionIndexes(collection, indexes); err != nil {
+		return nil, fmt.Errorf("failed to create suppression list indexes: %w", err)
+	}
+
+	return &MongoSuppressionList{
+		collection: collection,
+		ctx:        context.Background(),
+	}, nil
+}
+
+// Add suppresses an address; adding an already suppressed address keeps the original entry
+func (l *MongoSuppressionList) Add(email, source, reason string) error {
+	entry := Entry{
+		Email:     normalize(email),
+		Reason:    reason,
+		Source:    source,
+		CreatedAt: time.Now(),
+	}
+
+	ctx, cancel := database.OpContext(l.ctx)
+	defer cancel()
+
+	_, err := l.collection.UpdateOne(
+		ctx,
+		bson.M{"email": entry.Email},
+		bson.M{"$setOnInsert": entry},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to add suppression: %w", err)
+	}
+
+	return nil
+}
+
+// Get returns the suppression entry for an address, or nil if it isn't suppressed
+func (l *MongoSuppressionList) Get(email string) (*Entry, error) {
+	ctx, cancel := database.OpContext(l.ctx)
+	defer cancel()
+
+	var entry Entry
+	err := l.collection.FindOne(ctx, bson.M{"email": normalize(email)}).Decode(&entry)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to check suppression: %w", err)
+	}
+
+	return &entry, nil
+}
@@ -0,0 +1,30 @@
+package suppression
+
+import (
+	"net/mail"
+	"strings"
+)
+
+// List stores addresses that must not be mailed again
+type List interface {
+	// Add suppresses an address; adding an already suppressed address keeps the original entry
+	Add(email, source, reason string) error
+	// Get returns the suppression entry for an address, or nil if it isn't suppressed
+	Get(email string) (*Entry, error)
+}
+
+// normalize returns the key under which an address is stored, so that
+// " Foo@Example.com", "foo@example.com" and "Foo <foo@example.com>" match
+func normalize(email string) string {
+	email = strings.TrimSpace(email)
+	if parsed, err := mail.ParseAddress(email); err == nil {
+		email = parsed.Address
+	}
+	return strings.ToLower(email)
+}
+
+// Ensure both backends implement List
+var (
+	_ List = (*MongoSuppressionList)(nil)
+	_ List = (*MemorySuppressionList)(nil)
+)
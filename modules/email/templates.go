@@ -0,0 +1,60 @@
+package email
+
+import (
+	"bytes"
+	"html"
+	"html/template"
+	"regexp"
+	"strings"
+
+	"github.com/thenasky/go-framework/internal/router"
+	"github.com/thenasky/go-framework/modules/email/models"
+)
+
+var (
+	// hiddenContentPattern matches elements whose content is never shown to the reader
+	hiddenContentPattern = regexp.MustCompile(`(?is)<(script|style|head)[^>]*>.*?</(script|style|head)>`)
+	// lineBreakPattern matches tags that end a line of text
+	lineBreakPattern = regexp.MustCompile(`(?i)<br\s*/?>|</(p|div|h[1-6]|li|tr|table|blockquote)>`)
+	// tagPattern matches any remaining HTML tag
+	tagPattern = regexp.MustCompile(`<[^>]*>`)
+	// blankLinesPattern matches runs of more than one empty line
+	blankLinesPattern = regexp.MustCompile(`\n{3,}`)
+)
+
+// renderTemplate renders req.Template with req.TemplateData into req.HTML.
+// Requests without a template are left untouched.
+func renderTemplate(req *models.SendEmailRequest) error {
+	if req.Template == "" {
+		return nil
+	}
+
+	tmpl, err := template.New("email").Option("missingkey=error").Parse(req.Template)
+	if err != nil {
+		return router.NewValidationError("template", "Template could not be parsed: "+err.Error())
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, req.TemplateData); err != nil {
+		return router.NewValidationError("template_data", "Template could not be rendered: "+err.Error())
+	}
+
+	req.HTML = buf.String()
+	return nil
+}
+
+// htmlToText derives a plain-text version of an HTML body
+func htmlToText(body string) string {
+	text := hiddenContentPattern.ReplaceAllString(body, "")
+	text = lineBreakPattern.ReplaceAllString(text, "\n")
+	text = tagPattern.ReplaceAllString(text, "")
+	text = html.UnescapeString(text)
+
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.Join(strings.Fields(line), " ")
+	}
+	text = strings.Join(lines, "\n")
+
+	return strings.TrimSpace(blankLinesPattern.ReplaceAllString(text, "\n\n"))
+}
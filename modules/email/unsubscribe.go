@@ -0,0 +1,79 @@
+package email
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// unsubscribeTokenPlaceholder is replaced with the recipient's signed token in an unsubscribe URL
+const unsubscribeTokenPlaceholder = "{token}"
+
+// Unsubscribe token errors
+var (
+	ErrUnsubscribeDisabled = errors.New("unsubscribe tokens require EMAIL_UNSUBSCRIBE_SECRET")
+	ErrInvalidToken        = errors.New("invalid unsubscribe token")
+)
+
+// unsubscribeSecret returns the key used to sign unsubscribe tokens
+func unsubscribeSecret() ([]byte, error) {
+	secret := os.Getenv("EMAIL_UNSUBSCRIBE_SECRET")
+	if secret == "" {
+		return nil, ErrUnsubscribeDisabled
+	}
+	return []byte(secret), nil
+}
+
+// signUnsubscribeToken returns a token identifying email that cannot be forged without the secret
+func signUnsubscribeToken(email string) (string, error) {
+	secret, err := unsubscribeSecret()
+	if err != nil {
+		return "", err
+	}
+
+	payload := base64.RawURLEncoding.EncodeToString([]byte(email))
+	return payload + "." + unsubscribeSignature(secret, payload), nil
+}
+
+// verifyUnsubscribeToken checks the token signature and returns the address it identifies
+func verifyUnsubscribeToken(token string) (string, error) {
+	secret, err := unsubscribeSecret()
+	if err != nil {
+		return "", err
+	}
+
+	payload, signature, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(unsubscribeSignature(secret, payload))) {
+		return "", ErrInvalidToken
+	}
+
+	email, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil || len(email) == 0 {
+		return "", ErrInvalidToken
+	}
+	return string(email), nil
+}
+
+// unsubscribeSignature returns the HMAC-SHA256 of payload
+func unsubscribeSignature(secret []byte, payload string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// buildUnsubscribeURL fills in the recipient's token when the URL contains the placeholder
+func buildUnsubscribeURL(rawURL, recipient string) (string, error) {
+	if !strings.Contains(rawURL, unsubscribeTokenPlaceholder) {
+		return rawURL, nil
+	}
+
+	token, err := signUnsubscribeToken(recipient)
+	if err != nil {
+		return "", err
+	}
+	return strings.ReplaceAll(rawURL, unsubscribeTokenPlaceholder, url.QueryEscape(token)), nil
+}
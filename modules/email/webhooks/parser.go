@@ -0,0 +1,247 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Delivery event types
+const (
+	EventBounce    = "bounce"
+	EventComplaint = "complaint"
+)
+
+// Event is a provider-neutral delivery event
+type Event struct {
+	Type          string // bounce or complaint
+	Email         string // Affected recipient
+	ProviderMsgID string // Message ID assigned by the provider when sending
+	Reason        string
+	Permanent     bool // Hard bounce - the address should not be mailed again
+}
+
+// ErrUnsupportedProvider is returned for providers without a webhook parser
+var ErrUnsupportedProvider = fmt.Errorf("unsupported webhook provider")
+
+// Parse converts a provider webhook payload into delivery events.
+// Events other than bounces and complaints are ignored.
+func Parse(provider string, body []byte) ([]Event, error) {
+	switch strings.ToLower(provider) {
+	case "sendgrid":
+		return parseSendGrid(body)
+	case "ses":
+		return parseSES(body)
+	case "mailgun":
+		return parseMailgun(body)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedProvider, provider)
+	}
+}
+
+// ===== SendGrid =====
+
+// sendGridEvent is a single entry of a SendGrid event webhook batch
+type sendGridEvent struct {
+	Email       string `json:"email"`
+	Event       string `json:"event"`
+	Type        string `json:"type"`
+	Reason      string `json:"reason"`
+	SGMessageID string `json:"sg_message_id"`
+}
+
+func parseSendGrid(body []byte) ([]Event, error) {
+	var payload []sendGridEvent
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("invalid SendGrid payload: %w", err)
+	}
+
+	var events []Event
+	for _, e := range payload {
+		// sg_message_id is "<X-Message-Id>.filter..."; only the first part is returned on send
+		msgID := e.SGMessageID
+		if i := strings.Index(msgID, "."); i != -1 {
+			msgID = msgID[:i]
+		}
+
+		switch e.Event {
+		case "bounce", "dropped":
+			events = append(events, Event{
+				Type:          EventBounce,
+				Email:         e.Email,
+				ProviderMsgID: msgID,
+				Reason:        e.Reason,
+				Permanent:     e.Type != "blocked", // "blocked" is a soft bounce
+			})
+		case "spamreport":
+			events = append(events, Event{
+				Type:          EventComplaint,
+				Email:         e.Email,
+				ProviderMsgID: msgID,
+				Reason:        "spam report",
+			})
+		}
+	}
+
+	return events, nil
+}
+
+// ===== Amazon SES (via SNS) =====
+
+// SNSMessage is the envelope SNS uses to deliver notifications over HTTP
+type SNSMessage struct {
+	Type             string `json:"Type"`
+	MessageID        string `json:"MessageId"`
+	TopicArn         string `json:"TopicArn"`
+	Subject          string `json:"Subject,omitempty"`
+	Message          string `json:"Message"`
+	Timestamp        string `json:"Timestamp"`
+	Token            string `json:"Token,omitempty"`
+	SubscribeURL     string `json:"SubscribeURL,omitempty"`
+	SignatureVersion string `json:"SignatureVersion"`
+	Signature        string `json:"Signature"`
+	SigningCertURL   string `json:"SigningCertURL"`
+}
+
+// sesNotification is the SES event carried inside an SNS message
+type sesNotification struct {
+	NotificationType string `json:"notificationType"`
+	Mail             struct {
+		MessageID string `json:"messageId"`
+	} `json:"mail"`
+	Bounce struct {
+		BounceType        string `json:"bounceType"`
+		BouncedRecipients []struct {
+			EmailAddress   string `json:"emailAddress"`
+			DiagnosticCode string `json:"diagnosticCode"`
+		} `json:"bouncedRecipients"`
+	} `json:"bounce"`
+	Complaint struct {
+		ComplaintFeedbackType string `json:"complaintFeedbackType"`
+		ComplainedRecipients  []struct {
+			EmailAddress string `json:"emailAddress"`
+		} `json:"complainedRecipients"`
+	} `json:"complaint"`
+}
+
+// ErrSubscriptionConfirmation is returned when an SNS subscription must be confirmed
+var ErrSubscriptionConfirmation = fmt.Errorf("SNS subscription confirmation")
+
+// ParseSNSMessage decodes an SNS HTTP delivery envelope
+func ParseSNSMessage(body []byte) (*SNSMessage, error) {
+	var envelope SNSMessage
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return nil, fmt.Errorf("invalid SNS payload: %w", err)
+	}
+	return &envelope, nil
+}
+
+func parseSES(body []byte) ([]Event, error) {
+	envelope, err := ParseSNSMessage(body)
+	if err != nil {
+		return nil, err
+	}
+
+	if envelope.Type == "SubscriptionConfirmation" {
+		return nil, ErrSubscriptionConfirmation
+	}
+	if envelope.Type != "Notification" {
+		return nil, nil
+	}
+
+	var notification sesNotification
+	if err := json.Unmarshal([]byte(envelope.Message), &notification); err != nil {
+		return nil, fmt.Errorf("invalid SES notification: %w", err)
+	}
+
+	var events []Event
+	switch notification.NotificationType {
+	case "Bounce":
+		for _, recipient := range notification.Bounce.BouncedRecipients {
+			reason := recipient.DiagnosticCode
+			if reason == "" {
+				reason = notification.Bounce.BounceType + " bounce"
+			}
+			events = append(events, Event{
+				Type:          EventBounce,
+				Email:         recipient.EmailAddress,
+				ProviderMsgID: notification.Mail.MessageID,
+				Reason:        reason,
+				Permanent:     notification.Bounce.BounceType == "Permanent",
+			})
+		}
+	case "Complaint":
+		reason := notification.Complaint.ComplaintFeedbackType
+		if reason == "" {
+			reason = "complaint"
+		}
+		for _, recipient := range notification.Complaint.ComplainedRecipients {
+			events = append(events, Event{
+				Type:          EventComplaint,
+				Email:         recipient.EmailAddress,
+				ProviderMsgID: notification.Mail.MessageID,
+				Reason:        reason,
+			})
+		}
+	}
+
+	return events, nil
+}
+
+// ===== Mailgun =====
+
+// mailgunWebhook is the body of a Mailgun webhook
+type mailgunWebhook struct {
+	EventData struct {
+		Event     string `json:"event"`
+		Severity  string `json:"severity"`
+		Reason    string `json:"reason"`
+		Recipient string `json:"recipient"`
+		Message   struct {
+			Headers struct {
+				MessageID string `json:"message-id"`
+			} `json:"headers"`
+		} `json:"message"`
+		DeliveryStatus struct {
+			Description string `json:"description"`
+			Message     string `json:"message"`
+		} `json:"delivery-status"`
+	} `json:"event-data"`
+}
+
+func parseMailgun(body []byte) ([]Event, error) {
+	var payload mailgunWebhook
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("invalid Mailgun payload: %w", err)
+	}
+
+	data := payload.EventData
+	msgID := strings.Trim(data.Message.Headers.MessageID, "<>")
+
+	switch data.Event {
+	case "failed":
+		reason := data.DeliveryStatus.Description
+		if reason == "" {
+			reason = data.DeliveryStatus.Message
+		}
+		if reason == "" {
+			reason = data.Reason
+		}
+		return []Event{{
+			Type:          EventBounce,
+			Email:         data.Recipient,
+			ProviderMsgID: msgID,
+			Reason:        reason,
+			Permanent:     data.Severity == "permanent",
+		}}, nil
+	case "complained":
+		return []Event{{
+			Type:          EventComplaint,
+			Email:         data.Recipient,
+			ProviderMsgID: msgID,
+			Reason:        "complaint",
+		}}, nil
+	}
+
+	return nil, nil
+}
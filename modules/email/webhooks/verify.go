@@ -0,0 +1,267 @@
+package webhooks
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rsa"
+	_ "crypto/sha1" // SNS signature version 1
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrInvalidSignature is returned for webhooks whose signature is missing or doesn't verify
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+// VerifyConfig holds the keys used to verify provider webhook signatures
+type VerifyConfig struct {
+	SendGridPublicKey string // Verification key from SendGrid's Signed Event Webhook settings
+	MailgunSigningKey string // HTTP webhook signing key from the Mailgun dashboard
+	AllowUnsigned     bool   // Accept webhooks of providers without a configured key, for local testing
+}
+
+// Verify checks the signature of a provider webhook before its events are trusted.
+// SES notifications are verified against the SNS signing certificate; SendGrid
+// and Mailgun need their key in config.
+func Verify(provider string, header http.Header, body []byte, config VerifyConfig) error {
+	switch strings.ToLower(provider) {
+	case "sendgrid":
+		if config.SendGridPublicKey == "" {
+			return unconfigured(provider, config)
+		}
+		return verifySendGrid(header, body, config.SendGridPublicKey)
+	case "mailgun":
+		if config.MailgunSigningKey == "" {
+			return unconfigured(provider, config)
+		}
+		return verifyMailgun(body, config.MailgunSigningKey)
+	case "ses":
+		return verifySNS(body)
+	default:
+		return fmt.Errorf("%w: %s", ErrUnsupportedProvider, provider)
+	}
+}
+
+// unconfigured rejects a webhook whose provider has no verification key unless unsigned webhooks are allowed
+func unconfigured(provider string, config VerifyConfig) error {
+	if config.AllowUnsigned {
+		return nil
+	}
+	return fmt.Errorf("%w: no %s verification key configured", ErrInvalidSignature, provider)
+}
+
+// ===== SendGrid =====
+
+// verifySendGrid checks the ECDSA signature SendGrid computes over the timestamp and body
+func verifySendGrid(header http.Header, body []byte, publicKey string) error {
+	signature := header.Get("X-Twilio-Email-Event-Webhook-Signature")
+	timestamp := header.Get("X-Twilio-Email-Event-Webhook-Timestamp")
+	if signature == "" || timestamp == "" {
+		return fmt.Errorf("%w: missing SendGrid signature headers", ErrInvalidSignature)
+	}
+
+	key, err := parseECDSAPublicKey(publicKey)
+	if err != nil {
+		return fmt.Errorf("invalid SendGrid verification key: %w", err)
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return fmt.Errorf("%w: malformed SendGrid signature", ErrInvalidSignature)
+	}
+
+	digest := sha256.Sum256(append([]byte(timestamp), body...))
+	if !ecdsa.VerifyASN1(key, digest[:], decoded) {
+		return fmt.Errorf("%w: SendGrid signature mismatch", ErrInvalidSignature)
+	}
+	return nil
+}
+
+// parseECDSAPublicKey parses a base64 DER or PEM encoded ECDSA public key
+func parseECDSAPublicKey(encoded string) (*ecdsa.PublicKey, error) {
+	var der []byte
+	if block, _ := pem.Decode([]byte(encoded)); block != nil {
+		der = block.Bytes
+	} else {
+		decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+		if err != nil {
+			return nil, err
+		}
+		der = decoded
+	}
+
+	parsed, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, err
+	}
+	key, ok := parsed.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, errors.New("not an ECDSA public key")
+	}
+	return key, nil
+}
+
+// ===== Mailgun =====
+
+// mailgunSignature is the signature block Mailgun includes in every webhook body
+type mailgunSignature struct {
+	Signature struct {
+		Timestamp string `json:"timestamp"`
+		Token     string `json:"token"`
+		Signature string `json:"signature"`
+	} `json:"signature"`
+}
+
+// verifyMailgun checks the HMAC-SHA256 Mailgun computes over the timestamp and token
+func verifyMailgun(body []byte, signingKey string) error {
+	var payload mailgunSignature
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return fmt.Errorf("invalid Mailgun payload: %w", err)
+	}
+
+	signature := payload.Signature
+	if signature.Timestamp == "" || signature.Token == "" || signature.Signature == "" {
+		return fmt.Errorf("%w: missing Mailgun signature", ErrInvalidSignature)
+	}
+
+	mac := hmac.New(sha256.New, []byte(signingKey))
+	mac.Write([]byte(signature.Timestamp + signature.Token))
+	expected := hex.EncodeToString(mac.Sum(nil))
+
+	if !hmac.Equal([]byte(expected), []byte(strings.ToLower(signature.Signature))) {
+		return fmt.Errorf("%w: Mailgun signature mismatch", ErrInvalidSignature)
+	}
+	return nil
+}
+
+// ===== Amazon SNS =====
+
+// snsCertHostPattern matches the hosts SNS serves its signing certificates from
+var snsCertHostPattern = regexp.MustCompile(`^sns\.[a-z0-9-]+\.amazonaws\.com(\.cn)?$`)
+
+var (
+	snsCerts   = make(map[string]*x509.Certificate)
+	snsCertsMu sync.Mutex
+)
+
+// verifySNS checks an SNS message against the certificate it names, which must
+// be served by SNS itself over HTTPS
+func verifySNS(body []byte) error {
+	message, err := ParseSNSMessage(body)
+	if err != nil {
+		return err
+	}
+	if message.Signature == "" || message.SigningCertURL == "" {
+		return fmt.Errorf("%w: missing SNS signature", ErrInvalidSignature)
+	}
+
+	var hash crypto.Hash
+	switch message.SignatureVersion {
+	case "1":
+		hash = crypto.SHA1
+	case "2":
+		hash = crypto.SHA256
+	default:
+		return fmt.Errorf("%w: unsupported SNS signature version %q", ErrInvalidSignature, message.SignatureVersion)
+	}
+
+	cert, err := snsCertificate(message.SigningCertURL)
+	if err != nil {
+		return err
+	}
+	key, ok := cert.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return fmt.Errorf("%w: SNS certificate is not an RSA key", ErrInvalidSignature)
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(message.Signature)
+	if err != nil {
+		return fmt.Errorf("%w: malformed SNS signature", ErrInvalidSignature)
+	}
+
+	digest := hash.New()
+	digest.Write([]byte(message.stringToSign()))
+	if err := rsa.VerifyPKCS1v15(key, hash, digest.Sum(nil), signature); err != nil {
+		return fmt.Errorf("%w: SNS signature mismatch", ErrInvalidSignature)
+	}
+	return nil
+}
+
+// stringToSign builds the canonical string SNS signs for the message's type
+func (m *SNSMessage) stringToSign() string {
+	fields := [][2]string{{"Message", m.Message}, {"MessageId", m.MessageID}}
+	if m.Type == "Notification" {
+		if m.Subject != "" {
+			fields = append(fields, [2]string{"Subject", m.Subject})
+		}
+	} else {
+		fields = append(fields, [2]string{"SubscribeURL", m.SubscribeURL})
+	}
+	fields = append(fields, [2]string{"Timestamp", m.Timestamp})
+	if m.Type != "Notification" {
+		fields = append(fields, [2]string{"Token", m.Token})
+	}
+	fields = append(fields, [2]string{"TopicArn", m.TopicArn}, [2]string{"Type", m.Type})
+
+	var b strings.Builder
+	for _, field := range fields {
+		b.WriteString(field[0] + "\n" + field[1] + "\n")
+	}
+	return b.String()
+}
+
+// snsCertificate downloads and caches an SNS signing certificate
+func snsCertificate(certURL string) (*x509.Certificate, error) {
+	parsed, err := url.Parse(certURL)
+	if err != nil || parsed.Scheme != "https" || !snsCertHostPattern.MatchString(parsed.Hostname()) {
+		return nil, fmt.Errorf("%w: untrusted SNS certificate URL %q", ErrInvalidSignature, certURL)
+	}
+
+	snsCertsMu.Lock()
+	defer snsCertsMu.Unlock()
+
+	if cert, ok := snsCerts[certURL]; ok {
+		return cert, nil
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Get(certURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download SNS certificate: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("SNS certificate download returned status %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err != nil {
+		return nil, fmt.Errorf("failed to download SNS certificate: %w", err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("SNS certificate is not PEM encoded")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SNS certificate: %w", err)
+	}
+
+	snsCerts[certURL] = cert
+	return cert, nil
+}
@@ -0,0 +1,77 @@
+package workers
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// domainWindow is the length of the window domain limits are counted in
+const domainWindow = time.Minute
+
+// domainLimiter caps how many emails are sent to each recipient domain per minute
+type domainLimiter struct {
+	mu           sync.Mutex
+	limits       map[string]int // Sends per minute by domain
+	defaultLimit int            // Applies to domains without their own limit; 0 means unlimited
+	windows      map[string]*sendWindow
+}
+
+// sendWindow counts the sends to a domain in the current window
+type sendWindow struct {
+	start time.Time
+	count int
+}
+
+// newDomainLimiter creates a limiter from per-domain limits and a default limit
+func newDomainLimiter(limits map[string]int, defaultLimit int) *domainLimiter {
+	normalized := make(map[string]int, len(limits))
+	for domain, limit := range limits {
+		normalized[strings.ToLower(domain)] = limit
+	}
+
+	return &domainLimiter{
+		limits:       normalized,
+		defaultLimit: defaultLimit,
+		windows:      make(map[string]*sendWindow),
+	}
+}
+
+// allow records a send to the recipient's domain if it is within the limit.
+// Otherwise it returns false and the time the domain accepts sends again.
+func (l *domainLimiter) allow(recipient string, now time.Time) (bool, time.Time) {
+	domain := recipientDomain(recipient)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	limit, ok := l.limits[domain]
+	if !ok {
+		limit = l.defaultLimit
+	}
+	if limit <= 0 || domain == "" {
+		return true, time.Time{}
+	}
+
+	window, ok := l.windows[domain]
+	if !ok || now.Sub(window.start) >= domainWindow {
+		window = &sendWindow{start: now}
+		l.windows[domain] = window
+	}
+
+	if window.count >= limit {
+		return false, window.start.Add(domainWindow)
+	}
+	window.count++
+	return true, time.Time{}
+}
+
+// recipientDomain returns the lowercased domain of an address
+func recipientDomain(address string) string {
+	address = strings.TrimSpace(strings.TrimSuffix(address, ">"))
+	at := strings.LastIndex(address, "@")
+	if at < 0 {
+		return ""
+	}
+	return strings.ToLower(address[at+1:])
+}
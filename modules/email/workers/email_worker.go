@@ -2,12 +2,18 @@ package workers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
-	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/thenasky/go-framework/internal/jobs"
+	"github.com/thenasky/go-framework/internal/metrics"
+	"github.com/thenasky/go-framework/modules/email/audit"
 	"github.com/thenasky/go-framework/modules/email/models"
 	"github.com/thenasky/go-framework/modules/email/providers"
 	"github.com/thenasky/go-framework/modules/email/queue"
@@ -15,22 +21,55 @@ import (
 
 // EmailWorker processes email jobs from the queue
 type EmailWorker struct {
-	queue           *queue.MongoQueue
+	queue           queue.Queue
 	providers       []providers.EmailProvider
-	workerCount     int
-	stopChan        chan struct{}
-	wg              sync.WaitGroup
-	ctx             context.Context
-	cancel          context.CancelFunc
+	auditTrail      audit.Trail
+	config          WorkerConfig // Effective configuration, reported by Config
+	pool            *jobs.Pool
+	mu              sync.Mutex
+	inFlight        map[primitive.ObjectID]context.CancelFunc // Jobs claimed by a worker goroutine and not yet finished, with the func aborting their send
 	processingDelay time.Duration
+	retryDelay      time.Duration
+	retention       time.Duration
+	priorityAging   time.Duration
+	domainLimiter   *domainLimiter
+	dryRun          bool
+	watching        atomic.Bool
+	errors          errorLog
 }
 
+// Prometheus counters for send outcomes
+var (
+	emailsSentTotal = metrics.NewCounter(
+		"emails_sent_total",
+		"Total number of emails sent, by provider.",
+		"provider",
+	)
+	emailsFailedTotal = metrics.NewCounter(
+		"emails_failed_total",
+		"Total number of email send attempts that failed.",
+	)
+)
+
+// DryRunProvider is the provider name recorded on jobs completed in dry-run mode
+const DryRunProvider = "dry-run"
+
+// watchPollInterval is the idle poll interval used while a change stream is active
+const watchPollInterval = 5 * time.Second
+
 // WorkerConfig holds configuration for the email worker
 type WorkerConfig struct {
-	WorkerCount     int           `json:"worker_count"`     // Number of worker goroutines
-	ProcessingDelay time.Duration `json:"processing_delay"` // Delay between job checks
-	MaxRetries      int           `json:"max_retries"`      // Maximum retry attempts
-	RetryDelay      time.Duration `json:"retry_delay"`      // Delay between retries
+	WorkerCount     int           `json:"worker_count"`      // Number of worker goroutines
+	ProcessingDelay time.Duration `json:"processing_delay"`  // Delay between job checks
+	MaxRetries      int           `json:"max_retries"`       // Default attempts per email, used when a request doesn't set max_attempts
+	RetryDelay      time.Duration `json:"retry_delay"`       // Delay between retries
+	Retention       time.Duration `json:"retention"`         // How long sent and dead jobs are kept
+	UseChangeStream bool          `json:"use_change_stream"` // Wake workers from a MongoDB change stream
+	DryRun          bool          `json:"dry_run"`           // Log emails and mark them sent instead of calling providers
+	PriorityAging   time.Duration `json:"priority_aging"`    // Wait after which due jobs are promoted to high priority (0 = never)
+
+	DomainRateLimits       map[string]int `json:"domain_rate_limits,omitempty"` // Sends per minute by recipient domain
+	DefaultDomainRateLimit int            `json:"default_domain_rate_limit"`    // Sends per minute to other domains (0 = unlimited)
 }
 
 // DefaultWorkerConfig returns sensible default configuration
@@ -38,150 +77,282 @@ func DefaultWorkerConfig() *WorkerConfig {
 	return &WorkerConfig{
 		WorkerCount:     2,                      // 2 workers by default
 		ProcessingDelay: 100 * time.Millisecond, // Check every 100ms
-		MaxRetries:      3,                      // Max 3 retries
+		MaxRetries:      3,                      // 3 attempts per email
 		RetryDelay:      5 * time.Minute,        // Wait 5 minutes between retries
+		Retention:       24 * time.Hour,         // Keep finished jobs for a day
+		PriorityAging:   10 * time.Minute,       // Promote jobs waiting over 10 minutes
 	}
 }
 
-// NewEmailWorker creates a new email worker
-func NewEmailWorker(queue *queue.MongoQueue, providers []providers.EmailProvider, config *WorkerConfig) *EmailWorker {
+// NewEmailWorker creates a new email worker that records the lifecycle of the
+// jobs it processes in auditTrail
+func NewEmailWorker(queue queue.Queue, providers []providers.EmailProvider, auditTrail audit.Trail, config *WorkerConfig) *EmailWorker {
 	if config == nil {
 		config = DefaultWorkerConfig()
 	}
 
-	ctx, cancel := context.WithCancel(context.Background())
+	// Keep a copy so later changes to the caller's config can't diverge from what runs
+	effective := *config
+	effective.DomainRateLimits = cloneRateLimits(config.DomainRateLimits)
+	if effective.WorkerCount < 1 {
+		effective.WorkerCount = 1 // The pool always runs at least one goroutine
+	}
 
-	return &EmailWorker{
+	w := &EmailWorker{
 		queue:           queue,
 		providers:       providers,
-		workerCount:     config.WorkerCount,
-		stopChan:        make(chan struct{}),
-		ctx:             ctx,
-		cancel:          cancel,
+		auditTrail:      auditTrail,
+		config:          effective,
 		processingDelay: config.ProcessingDelay,
+		retryDelay:      config.RetryDelay,
+		retention:       config.Retention,
+		priorityAging:   config.PriorityAging,
+		domainLimiter:   newDomainLimiter(config.DomainRateLimits, config.DefaultDomainRateLimit),
+		dryRun:          config.DryRun,
+		inFlight:        make(map[primitive.ObjectID]context.CancelFunc),
 	}
-}
 
-// Start starts the email worker
-func (w *EmailWorker) Start() {
-	log.Printf("Starting email worker with %d workers", w.workerCount)
+	w.pool = jobs.NewPool(jobs.PoolConfig{
+		Name:           "email worker",
+		Workers:        config.WorkerCount,
+		Process:        w.processNextJob,
+		PollInterval:   w.pollInterval,
+		Wake:           queue.NewJobs,
+		OnDrainTimeout: w.requeueInFlight,
+	})
+
+	// Wake up on inserts from other nodes when change streams are available
+	if config.UseChangeStream {
+		w.pool.Go(w.watchRoutine)
+	}
 
-	// Start worker goroutines
-	for i := 0; i < w.workerCount; i++ {
-		w.wg.Add(1)
-		go w.workerRoutine(i)
+	w.pool.Every(1*time.Hour, w.cleanupOldJobs)
+
+	// Keep low-priority jobs from starving behind a steady stream of high-priority ones
+	if config.PriorityAging > 0 {
+		// Check twice per threshold so a job never waits much longer than it
+		interval := config.PriorityAging / 2
+		if interval < time.Second {
+			interval = time.Second
+		}
+		w.pool.Every(interval, w.promoteAgedJobs)
 	}
 
-	// Start cleanup routine
-	w.wg.Add(1)
-	go w.cleanupRoutine()
+	return w
+}
 
-	log.Println("Email worker started successfully")
+// Config returns the configuration the worker runs with, after defaults and
+// environment overrides were applied
+func (w *EmailWorker) Config() WorkerConfig {
+	config := w.config
+	config.DomainRateLimits = cloneRateLimits(w.config.DomainRateLimits)
+	return config
 }
 
-// Stop stops the email worker gracefully
-func (w *EmailWorker) Stop() {
-	log.Println("Stopping email worker...")
+// cloneRateLimits copies a domain rate limit map
+func cloneRateLimits(limits map[string]int) map[string]int {
+	if limits == nil {
+		return nil
+	}
+	clone := make(map[string]int, len(limits))
+	for domain, limit := range limits {
+		clone[domain] = limit
+	}
+	return clone
+}
 
-	// Signal all workers to stop
-	close(w.stopChan)
+// Start starts the email worker. Calling it on a worker that is already running is a no-op.
+func (w *EmailWorker) Start() {
+	w.pool.Start()
+}
 
-	// Cancel context
-	w.cancel()
+// Stop drains the email worker: no new jobs are dequeued, and jobs already being
+// sent are given until ctx is done to finish. Jobs still in flight at the deadline
+// are put back in the queue and an error is returned. Stopping a worker that is
+// not running is a no-op.
+func (w *EmailWorker) Stop(ctx context.Context) error {
+	return w.pool.Stop(ctx)
+}
 
-	// Wait for all workers to finish
-	w.wg.Wait()
+// trackJob records that a job is being processed by a worker goroutine, and
+// how to abort its send; a nil cancel records that it is finished
+func (w *EmailWorker) trackJob(jobID primitive.ObjectID, cancel context.CancelFunc) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
 
-	log.Println("Email worker stopped successfully")
+	if cancel != nil {
+		w.inFlight[jobID] = cancel
+	} else {
+		delete(w.inFlight, jobID)
+	}
 }
 
-// workerRoutine is the main worker loop
-func (w *EmailWorker) workerRoutine(workerID int) {
-	defer w.wg.Done()
-
-	log.Printf("Worker %d started", workerID)
-
-	for {
-		select {
-		case <-w.stopChan:
-			log.Printf("Worker %d stopping", workerID)
-			return
-		case <-w.ctx.Done():
-			log.Printf("Worker %d context cancelled", workerID)
-			return
-		default:
-			// Process next job
-			if err := w.processNextJob(workerID); err != nil {
-				log.Printf("Worker %d error: %v", workerID, err)
-				// Small delay on error to prevent tight loop
-				time.Sleep(1 * time.Second)
-			}
+// CancelSend aborts the provider call of a job being processed on this node and
+// reports whether there was one. The job is then retried like any transient failure.
+func (w *EmailWorker) CancelSend(jobID primitive.ObjectID) bool {
+	w.mu.Lock()
+	cancel, ok := w.inFlight[jobID]
+	w.mu.Unlock()
 
-			// Wait before checking for next job
-			time.Sleep(w.processingDelay)
+	if ok {
+		cancel()
+	}
+	return ok
+}
 
-			// Add additional delay between workers to prevent rate limiting
-			if workerID == 0 {
-				time.Sleep(2 * time.Second)
-			} else {
-				time.Sleep(3 * time.Second)
-			}
+// requeueInFlight puts every job still being processed back in the queue so another
+// worker picks it up right away. The interrupted attempt isn't counted, since the
+// shutdown rather than the send cut it short. A send that completes afterwards
+// still marks its job complete.
+func (w *EmailWorker) requeueInFlight() {
+	w.mu.Lock()
+	jobIDs := make([]primitive.ObjectID, 0, len(w.inFlight))
+	for jobID := range w.inFlight {
+		jobIDs = append(jobIDs, jobID)
+	}
+	w.mu.Unlock()
+
+	requeued := 0
+	for _, jobID := range jobIDs {
+		if err := w.queue.Reschedule(context.Background(), jobID, time.Now()); err != nil {
+			log.Printf("Failed to requeue in-flight job %s: %v", jobID.Hex(), err)
+			w.RecordError("requeue", jobID.Hex(), err)
+			continue
 		}
+		requeued++
 	}
+	log.Printf("Email worker stop deadline reached, requeued %d in-flight jobs", requeued)
 }
 
-// processNextJob processes the next available job
-func (w *EmailWorker) processNextJob(workerID int) error {
+// pollInterval is how long an idle worker goroutine waits before polling the queue again
+func (w *EmailWorker) pollInterval() time.Duration {
+	// With a change stream every insert is signalled, so polling is only needed
+	// for scheduled jobs and retries becoming due
+	if w.watching.Load() && w.processingDelay < watchPollInterval {
+		return watchPollInterval
+	}
+	return w.processingDelay
+}
+
+// watchRoutine follows the queue's change stream, falling back to polling when unavailable
+func (w *EmailWorker) watchRoutine(ctx context.Context) {
+	w.watching.Store(true)
+	err := w.queue.Watch(ctx)
+	w.watching.Store(false)
+
+	if err != nil {
+		log.Printf("Change stream unavailable, falling back to polling: %v", err)
+	}
+}
+
+// processNextJob processes the next available job and reports whether one was found
+func (w *EmailWorker) processNextJob(ctx context.Context, workerID int) (bool, error) {
 	// Get next job from queue
-	job, err := w.queue.Dequeue()
+	job, err := w.queue.Dequeue(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to dequeue job: %w", err)
+		w.RecordError("dequeue", "", err)
+		return false, fmt.Errorf("failed to dequeue job: %w", err)
 	}
 
 	// No jobs available
 	if job == nil {
-		return nil
+		return false, nil
+	}
+
+	// Once claimed, the job is finished and recorded even when the worker starts stopping
+	jobCtx := context.WithoutCancel(ctx)
+
+	// Let Stop requeue the job if it is still being processed at the drain
+	// deadline, and CancelSend abort its provider call
+	sendCtx, cancelSend := context.WithCancel(jobCtx)
+	defer cancelSend()
+	w.trackJob(job.ID, cancelSend)
+	defer w.trackJob(job.ID, nil)
+
+	actor := fmt.Sprintf("worker-%d", workerID)
+	w.recordAudit(jobCtx, job.ID, audit.EventDequeued, actor, map[string]string{
+		"attempt": fmt.Sprintf("%d of %d", job.Attempts, job.MaxAttempts),
+	})
+
+	// Hold the job back while its recipient domain is over its rate limit
+	if ok, retryAt := w.domainLimiter.allow(job.To, time.Now()); !ok {
+		log.Printf("Worker %d deferring job %s until %s: recipient domain rate limit reached", workerID, job.ID.Hex(), retryAt.Format(time.RFC3339))
+		if err := w.queue.Reschedule(jobCtx, job.ID, retryAt); err != nil {
+			w.RecordError("reschedule", job.ID.Hex(), err)
+			return true, fmt.Errorf("failed to reschedule job: %w", err)
+		}
+		w.recordAudit(jobCtx, job.ID, audit.EventDeferred, actor, map[string]string{
+			"until":  retryAt.Format(time.RFC3339),
+			"reason": "recipient domain rate limit",
+		})
+		return true, nil
 	}
 
 	log.Printf("Worker %d processing job %s (to: %s)", workerID, job.ID.Hex(), job.To)
 
 	// Process the job
-	if err := w.processJob(job); err != nil {
+	if err := w.processJob(sendCtx, job); err != nil {
 		log.Printf("Worker %d failed to process job %s: %v", workerID, job.ID.Hex(), err)
+		emailsFailedTotal.Inc()
+		w.RecordError("send", job.ID.Hex(), err)
+
+		// Permanent errors (e.g. bad recipient) can't be fixed by retrying
+		if providers.IsPermanent(err) {
+			if markErr := w.queue.MarkDead(jobCtx, job.ID, job.Provider, err.Error()); markErr != nil {
+				log.Printf("Worker %d failed to mark job %s as dead: %v", workerID, job.ID.Hex(), markErr)
+				w.RecordError("mark_dead", job.ID.Hex(), markErr)
+				return true, fmt.Errorf("failed to mark job dead: %w", markErr)
+			}
+			w.recordAudit(jobCtx, job.ID, audit.EventFailed, actor, map[string]string{"error": err.Error()})
+			return true, nil
+		}
 
-		// Check if this is a rate limiting error
-		if strings.Contains(err.Error(), "Too many login attempts") ||
-			strings.Contains(err.Error(), "rate limit") ||
-			strings.Contains(err.Error(), "429") ||
-			strings.Contains(err.Error(), "454") {
-
-			// For rate limiting, add exponential backoff delay
+		// Mark job as failed so it is retried on the normal schedule, or after a
+		// shorter backoff for transient provider errors (rate limits, temporary
+		// outages). The delay is stored on the job so the worker moves on meanwhile.
+		retryAt := time.Now().Add(w.retryDelay)
+		if providers.IsRetryable(err) {
 			backoffDelay := time.Duration(job.Attempts) * 30 * time.Second
 			if backoffDelay > 5*time.Minute {
 				backoffDelay = 5 * time.Minute
 			}
-
-			log.Printf("Rate limiting detected, backing off for %v before retry", backoffDelay)
-			time.Sleep(backoffDelay)
-
-			// Don't mark as failed immediately, let it retry later
-			return err
+			retryAt = time.Now().Add(backoffDelay)
 		}
-
-		// Mark job as failed for non-rate-limiting errors
-		if markErr := w.queue.MarkFailed(job.ID, err.Error()); markErr != nil {
+		if markErr := w.queue.MarkFailed(jobCtx, job.ID, job.Provider, err.Error(), retryAt); markErr != nil {
 			log.Printf("Worker %d failed to mark job %s as failed: %v", workerID, job.ID.Hex(), markErr)
+			w.RecordError("mark_failed", job.ID.Hex(), markErr)
+			return true, fmt.Errorf("failed to mark job failed: %w", markErr)
+		}
+		if job.Attempts >= job.MaxAttempts {
+			w.recordAudit(jobCtx, job.ID, audit.EventFailed, actor, map[string]string{"error": err.Error()})
+		} else {
+			w.recordAudit(jobCtx, job.ID, audit.EventRetrying, actor, map[string]string{
+				"error":    err.Error(),
+				"retry_at": retryAt.Format(time.RFC3339),
+			})
 		}
 
-		return err
+		// The failure is recorded on the job; only queue errors should slow the pool down
+		return true, nil
 	}
 
+	w.recordAudit(jobCtx, job.ID, audit.EventSent, actor, map[string]string{
+		"provider":        job.Provider,
+		"provider_msg_id": job.ProviderMsgID,
+	})
+
 	log.Printf("Worker %d successfully processed job %s", workerID, job.ID.Hex())
-	return nil
+	return true, nil
 }
 
-// processJob sends an email using available providers
-func (w *EmailWorker) processJob(job *models.EmailJob) error {
+// processJob sends an email using available providers. Cancelling ctx aborts
+// the provider call in progress; the outcome is still recorded.
+func (w *EmailWorker) processJob(ctx context.Context, job *models.EmailJob) error {
+	recordCtx := context.WithoutCancel(ctx)
+	if w.dryRun || job.DryRun {
+		return w.completeDryRun(recordCtx, job)
+	}
+
 	var lastError error
 
 	// Try each provider until one succeeds
@@ -192,20 +363,38 @@ func (w *EmailWorker) processJob(job *models.EmailJob) error {
 			continue
 		}
 
-		// Try to send email
-		if err := provider.Send(job); err != nil {
+		// Try to send email; a failure is recorded against the last provider tried
+		job.Provider = provider.GetName()
+		job.ProviderMsgID = ""
+		if err := provider.Send(ctx, job); err != nil {
 			lastError = fmt.Errorf("provider %s failed: %w", provider.GetName(), err)
+
+			// Some recipients already have the email: remember them so retries skip
+			// them, and don't fail over since another provider would resend to them
+			var partial *providers.PartialDeliveryError
+			if errors.As(err, &partial) {
+				if recordErr := w.queue.RecordDelivered(recordCtx, job.ID, partial.Accepted); recordErr != nil {
+					w.RecordError("record_delivered", job.ID.Hex(), recordErr)
+				}
+				break
+			}
+
+			// Another provider would reject the email the same way, and an aborted
+			// send shouldn't carry on with the next one
+			if providers.IsPermanent(err) || ctx.Err() != nil {
+				break
+			}
 			continue
 		}
 
 		// Success! Mark job as complete
 		providerName := provider.GetName()
-		providerMsgID := fmt.Sprintf("msg_%d", time.Now().UnixNano()) // Generate unique ID
-
-		if err := w.queue.MarkComplete(job.ID, providerName, providerMsgID); err != nil {
+		if err := w.queue.MarkComplete(recordCtx, job.ID, providerName, job.ProviderMsgID); err != nil {
+			w.RecordError("mark_complete", job.ID.Hex(), err)
 			return fmt.Errorf("failed to mark job complete: %w", err)
 		}
 
+		emailsSentTotal.Inc(providerName)
 		log.Printf("Email sent successfully via %s (job: %s)", providerName, job.ID.Hex())
 		return nil
 	}
@@ -214,45 +403,86 @@ func (w *EmailWorker) processJob(job *models.EmailJob) error {
 	return fmt.Errorf("all providers failed to send email: %w", lastError)
 }
 
-// cleanupRoutine periodically cleans up old completed jobs
-func (w *EmailWorker) cleanupRoutine() {
-	defer w.wg.Done()
-
-	ticker := time.NewTicker(1 * time.Hour) // Cleanup every hour
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-w.stopChan:
-			return
-		case <-w.ctx.Done():
-			return
-		case <-ticker.C:
-			if err := w.queue.CleanupOldJobs(24 * time.Hour); err != nil {
-				log.Printf("Cleanup routine error: %v", err)
-			} else {
-				log.Println("Cleanup routine completed successfully")
-			}
-		}
+// completeDryRun marks a job sent without calling a provider
+func (w *EmailWorker) completeDryRun(ctx context.Context, job *models.EmailJob) error {
+	log.Printf("Dry run: would send email to %s from %s (subject: %q, job: %s)", job.To, job.From, job.Subject, job.ID.Hex())
+
+	job.Provider = DryRunProvider
+	job.ProviderMsgID = fmt.Sprintf("dry-run-%d", time.Now().UnixNano())
+	if err := w.queue.MarkComplete(ctx, job.ID, job.Provider, job.ProviderMsgID); err != nil {
+		w.RecordError("mark_complete", job.ID.Hex(), err)
+		return fmt.Errorf("failed to mark job complete: %w", err)
+	}
+
+	emailsSentTotal.Inc(DryRunProvider)
+	return nil
+}
+
+// recordAudit appends an event to a job's audit trail. A failure to record is
+// logged but never fails the job.
+func (w *EmailWorker) recordAudit(ctx context.Context, jobID primitive.ObjectID, event, actor string, details map[string]string) {
+	if w.auditTrail == nil {
+		return
+	}
+
+	err := w.auditTrail.Record(ctx, audit.Event{JobID: jobID, Type: event, Actor: actor, Details: details})
+	if err != nil {
+		log.Printf("Failed to record %s audit event for job %s: %v", event, jobID.Hex(), err)
+		w.RecordError("audit", jobID.Hex(), err)
+	}
+}
+
+// cleanupOldJobs removes completed jobs past the retention period
+func (w *EmailWorker) cleanupOldJobs() {
+	if err := w.queue.CleanupOldJobs(context.Background(), w.retention); err != nil {
+		log.Printf("Cleanup routine error: %v", err)
+		w.RecordError("cleanup", "", err)
+	} else {
+		log.Println("Cleanup routine completed successfully")
+	}
+}
+
+// promoteAgedJobs promotes jobs that have waited longer than the aging threshold
+func (w *EmailWorker) promoteAgedJobs() {
+	promoted, err := w.queue.PromoteAgedJobs(context.Background(), w.priorityAging)
+	if err != nil {
+		log.Printf("Priority aging error: %v", err)
+		w.RecordError("aging", "", err)
+	} else if promoted > 0 {
+		log.Printf("Promoted %d jobs waiting over %s to high priority", promoted, w.priorityAging)
 	}
 }
 
 // GetStats returns current worker statistics
-func (w *EmailWorker) GetStats() (*models.EmailStats, error) {
-	return w.queue.GetQueueStats()
+func (w *EmailWorker) GetStats(ctx context.Context) (*models.EmailStats, error) {
+	stats, err := w.queue.GetQueueStats(ctx)
+	if err != nil {
+		w.RecordError("stats", "", err)
+		return nil, err
+	}
+
+	stats.DryRun = w.dryRun
+
+	if last, ok := w.errors.last(); ok {
+		stats.LastError = last.Message
+		stats.LastErrorAt = &last.OccurredAt
+	}
+
+	for _, provider := range w.providers {
+		if reporter, ok := provider.(providers.StatusReporter); ok {
+			stats.Providers = append(stats.Providers, reporter.Status())
+		}
+	}
+
+	return stats, nil
 }
 
 // GetPendingCount returns the number of pending jobs
-func (w *EmailWorker) GetPendingCount() (int64, error) {
-	return w.queue.GetPendingJobsCount()
+func (w *EmailWorker) GetPendingCount(ctx context.Context) (int64, error) {
+	return w.queue.GetPendingJobsCount(ctx)
 }
 
-// IsRunning returns true if the worker is currently running
+// IsRunning returns true if the worker has been started and is not stopping
 func (w *EmailWorker) IsRunning() bool {
-	select {
-	case <-w.stopChan:
-		return false
-	default:
-		return true
-	}
+	return w.pool.IsRunning()
 }
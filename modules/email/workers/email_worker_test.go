@@ -0,0 +1,330 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/thenasky/go-framework/modules/email/models"
+	"github.com/thenasky/go-framework/modules/email/providers"
+	"github.com/thenasky/go-framework/modules/email/queue"
+)
+
+// fakeProvider returns err from every send and counts the sends that reached it
+type fakeProvider struct {
+	name  string
+	err   error
+	sends atomic.Int32
+}
+
+func (p *fakeProvider) Send(ctx context.Context, email *models.EmailJob) error {
+	p.sends.Add(1)
+	if p.err == nil {
+		email.ProviderMsgID = p.name + "-" + email.ID.Hex()
+	}
+	return p.err
+}
+
+func (p *fakeProvider) GetName() string { return p.name }
+func (p *fakeProvider) GetQuota() (*providers.QuotaInfo, error) {
+	return &providers.QuotaInfo{Provider: p.name}, nil
+}
+func (p *fakeProvider) ValidateEmail(email string) error { return nil }
+
+// blockingProvider blocks every send until its context ends, like a provider
+// that stopped answering
+type blockingProvider struct {
+	started  chan struct{} // Closed when the first send starts
+	returned chan struct{} // Closed when the first send returns
+}
+
+func newBlockingProvider() *blockingProvider {
+	return &blockingProvider{started: make(chan struct{}), returned: make(chan struct{})}
+}
+
+func (p *blockingProvider) Send(ctx context.Context, email *models.EmailJob) error {
+	close(p.started)
+	defer close(p.returned)
+
+	<-ctx.Done()
+	return &providers.ProviderError{Provider: p.GetName(), Code: "canceled", Retryable: true, Err: ctx.Err()}
+}
+
+func (p *blockingProvider) GetName() string { return "blocking" }
+func (p *blockingProvider) GetQuota() (*providers.QuotaInfo, error) {
+	return &providers.QuotaInfo{}, nil
+}
+func (p *blockingProvider) ValidateEmail(email string) error { return nil }
+
+// testWorkerConfig returns a single-goroutine config that polls often
+func testWorkerConfig() *WorkerConfig {
+	config := DefaultWorkerConfig()
+	config.WorkerCount = 1
+	config.ProcessingDelay = 10 * time.Millisecond
+	config.PriorityAging = 0
+	return config
+}
+
+// enqueueTestJob queues an email to user@example.com
+func enqueueTestJob(t *testing.T, q queue.Queue) *models.EmailJob {
+	t.Helper()
+	job := &models.EmailJob{To: "user@example.com", From: "noreply@example.com", Subject: "Hello", HTML: "<p>Hello</p>"}
+	if err := q.Enqueue(context.Background(), job); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	return job
+}
+
+// waitFor fails the test unless ch is closed within timeout
+func waitFor(t *testing.T, ch <-chan struct{}, timeout time.Duration, what string) {
+	t.Helper()
+	select {
+	case <-ch:
+	case <-time.After(timeout):
+		t.Fatalf("timed out waiting for %s", what)
+	}
+}
+
+// stopWorker stops w, failing the test if it doesn't drain in time
+func stopWorker(t *testing.T, w *EmailWorker) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := w.Stop(ctx); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+}
+
+func TestCancelSendAbortsProviderCall(t *testing.T) {
+	q := queue.NewMemoryQueue()
+	blocking := newBlockingProvider()
+	fallback := &fakeProvider{name: "fallback"}
+	worker := NewEmailWorker(q, []providers.EmailProvider{blocking, fallback}, nil, testWorkerConfig())
+
+	job := enqueueTestJob(t, q)
+	if worker.CancelSend(job.ID) {
+		t.Fatal("CancelSend found a job that isn't being sent")
+	}
+
+	worker.Start()
+	waitFor(t, blocking.started, 5*time.Second, "the send to start")
+
+	if !worker.CancelSend(job.ID) {
+		t.Fatal("CancelSend didn't find the job being sent")
+	}
+	waitFor(t, blocking.returned, time.Second, "the cancelled send to return")
+
+	// The cancelled send is retried after a backoff, like any transient failure
+	stored := waitForJob(t, q, job.ID, processed)
+	stopWorker(t, worker)
+
+	if sends := fallback.sends.Load(); sends != 0 {
+		t.Errorf("cancelled send failed over to the next provider %d times", sends)
+	}
+	if stored.Status != models.StatusFailed || stored.Attempts >= stored.MaxAttempts || !stored.ScheduledAt.After(time.Now()) {
+		t.Errorf("job after cancel = %s after %d of %d attempts, due %v, want a retry later", stored.Status, stored.Attempts, stored.MaxAttempts, stored.ScheduledAt)
+	}
+	if record, ok := worker.errors.last(); !ok || record.Operation != "send" || !strings.Contains(record.Message, context.Canceled.Error()) {
+		t.Errorf("last recorded error = %+v, want the cancelled send", record)
+	}
+}
+
+func TestStopRequeuesInFlightJobs(t *testing.T) {
+	q := queue.NewMemoryQueue()
+	blocking := newBlockingProvider()
+	worker := NewEmailWorker(q, []providers.EmailProvider{blocking}, nil, testWorkerConfig())
+
+	job := enqueueTestJob(t, q)
+	worker.Start()
+	waitFor(t, blocking.started, 5*time.Second, "the send to start")
+
+	// The send never finishes, so the drain deadline passes with the job claimed
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := worker.Stop(ctx); err == nil {
+		t.Fatal("Stop returned no error with a send still in flight")
+	}
+
+	stored, err := q.GetJobByID(context.Background(), job.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Status != models.StatusPending || stored.Attempts != 0 || stored.ScheduledAt.After(time.Now()) {
+		t.Errorf("job after drain = %s after %d attempts, due %v; want pending and due without a used attempt",
+			stored.Status, stored.Attempts, stored.ScheduledAt)
+	}
+
+	// Another worker can claim it straight away
+	claimed, err := q.Dequeue(context.Background())
+	if err != nil || claimed == nil || claimed.ID != job.ID {
+		t.Errorf("Dequeue after drain = %v, %v; want the requeued job", claimed, err)
+	}
+
+	// Release the stuck send so the worker goroutine exits
+	worker.CancelSend(job.ID)
+	waitFor(t, blocking.returned, time.Second, "the cancelled send to return")
+	stopWorker(t, worker)
+}
+
+// waitForJob polls q until the job satisfies done, failing the test after a few seconds
+func waitForJob(t *testing.T, q queue.Queue, jobID primitive.ObjectID, done func(*models.EmailJob) bool) *models.EmailJob {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		job, err := q.GetJobByID(context.Background(), jobID)
+		if err != nil {
+			t.Fatalf("GetJobByID: %v", err)
+		}
+		if done(job) {
+			return job
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job still %s after 5s", job.Status)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// processed reports whether a job left the pending and processing states
+func processed(job *models.EmailJob) bool {
+	return job.Status != models.StatusPending && job.Status != models.StatusProcessing
+}
+
+func TestWorkerSendsQueuedJobs(t *testing.T) {
+	q := queue.NewMemoryQueue()
+	provider := &fakeProvider{name: "fake"}
+	worker := NewEmailWorker(q, []providers.EmailProvider{provider}, nil, testWorkerConfig())
+	worker.Start()
+	defer stopWorker(t, worker)
+
+	job := waitForJob(t, q, enqueueTestJob(t, q).ID, processed)
+	if job.Status != models.StatusSent || job.Provider != "fake" || job.ProviderMsgID != "fake-"+job.ID.Hex() {
+		t.Errorf("job = %s via %s (%s), want sent via fake", job.Status, job.Provider, job.ProviderMsgID)
+	}
+}
+
+func TestWorkerFailsOverToNextProvider(t *testing.T) {
+	q := queue.NewMemoryQueue()
+	down := &fakeProvider{name: "down", err: errors.New("connection refused")}
+	up := &fakeProvider{name: "up"}
+	worker := NewEmailWorker(q, []providers.EmailProvider{down, up}, nil, testWorkerConfig())
+	worker.Start()
+	defer stopWorker(t, worker)
+
+	job := waitForJob(t, q, enqueueTestJob(t, q).ID, processed)
+	if job.Status != models.StatusSent || job.Provider != "up" {
+		t.Errorf("job = %s via %s, want sent via up", job.Status, job.Provider)
+	}
+	if down.sends.Load() != 1 {
+		t.Errorf("failing provider tried %d times, want 1", down.sends.Load())
+	}
+}
+
+func TestWorkerMarksPermanentFailuresDead(t *testing.T) {
+	q := queue.NewMemoryQueue()
+	rejecting := &fakeProvider{name: "rejecting", err: &providers.ProviderError{
+		Provider: "rejecting", Code: "550", Permanent: true, Err: errors.New("mailbox unavailable"),
+	}}
+	fallback := &fakeProvider{name: "fallback"}
+	worker := NewEmailWorker(q, []providers.EmailProvider{rejecting, fallback}, nil, testWorkerConfig())
+	worker.Start()
+	defer stopWorker(t, worker)
+
+	job := waitForJob(t, q, enqueueTestJob(t, q).ID, processed)
+	if job.Status != models.StatusFailed || job.Attempts != job.MaxAttempts {
+		t.Errorf("job = %s after %d of %d attempts, want failed without attempts left", job.Status, job.Attempts, job.MaxAttempts)
+	}
+	if fallback.sends.Load() != 0 {
+		t.Error("permanently rejected email sent through the next provider")
+	}
+}
+
+func TestWorkerDrainsFailingJobsWithoutBackoff(t *testing.T) {
+	q := queue.NewMemoryQueue()
+	rejecting := &fakeProvider{name: "rejecting", err: &providers.ProviderError{
+		Provider: "rejecting", Code: "550", Permanent: true, Err: errors.New("mailbox unavailable"),
+	}}
+	worker := NewEmailWorker(q, []providers.EmailProvider{rejecting}, nil, testWorkerConfig())
+
+	var queued []*models.EmailJob
+	for i := 0; i < 5; i++ {
+		queued = append(queued, enqueueTestJob(t, q))
+	}
+
+	start := time.Now()
+	worker.Start()
+	defer stopWorker(t, worker)
+
+	for _, job := range queued {
+		waitForJob(t, q, job.ID, processed)
+	}
+	// A recorded send failure isn't a queue error, so the pool must not back off
+	// (at least a second) between jobs
+	if elapsed := time.Since(start); elapsed > 900*time.Millisecond {
+		t.Errorf("draining %d failing jobs took %v, want no backoff between them", len(queued), elapsed)
+	}
+}
+
+func TestWorkerRetriesFailedSendsLater(t *testing.T) {
+	q := queue.NewMemoryQueue()
+	config := testWorkerConfig()
+	config.RetryDelay = time.Hour
+	worker := NewEmailWorker(q, []providers.EmailProvider{&fakeProvider{name: "down", err: errors.New("connection refused")}}, nil, config)
+	worker.Start()
+	defer stopWorker(t, worker)
+
+	job := waitForJob(t, q, enqueueTestJob(t, q).ID, processed)
+	if job.Status != models.StatusFailed || job.Attempts != 1 {
+		t.Fatalf("job = %s after %d attempts, want failed after 1", job.Status, job.Attempts)
+	}
+	if wait := time.Until(job.ScheduledAt); wait < 59*time.Minute {
+		t.Errorf("retry scheduled in %v, want the retry delay", wait)
+	}
+}
+
+func TestWorkerBacksOffTransientFailuresWithoutBlocking(t *testing.T) {
+	q := queue.NewMemoryQueue()
+	limited := &fakeProvider{name: "limited", err: &providers.ProviderError{
+		Provider: "limited", Code: "429", Retryable: true, Err: errors.New("rate limited"),
+	}}
+	config := testWorkerConfig()
+	config.RetryDelay = time.Hour
+	worker := NewEmailWorker(q, []providers.EmailProvider{limited}, nil, config)
+
+	first, second := enqueueTestJob(t, q), enqueueTestJob(t, q)
+	worker.Start()
+	defer stopWorker(t, worker)
+
+	// The backoff is stored on the job, so the worker goes on with the next one
+	waitForJob(t, q, second.ID, processed)
+	job := waitForJob(t, q, first.ID, processed)
+	if job.Status != models.StatusFailed || job.Attempts != 1 {
+		t.Fatalf("job = %s after %d attempts, want failed after 1", job.Status, job.Attempts)
+	}
+	if wait := time.Until(job.ScheduledAt); wait <= 0 || wait > 30*time.Second {
+		t.Errorf("retry scheduled in %v, want the transient error backoff", wait)
+	}
+}
+
+func TestWorkerDryRunSkipsProviders(t *testing.T) {
+	q := queue.NewMemoryQueue()
+	provider := &fakeProvider{name: "fake"}
+	config := testWorkerConfig()
+	config.DryRun = true
+	worker := NewEmailWorker(q, []providers.EmailProvider{provider}, nil, config)
+	worker.Start()
+	defer stopWorker(t, worker)
+
+	job := waitForJob(t, q, enqueueTestJob(t, q).ID, processed)
+	if job.Status != models.StatusSent || job.Provider != DryRunProvider {
+		t.Errorf("job = %s via %s, want sent via %s", job.Status, job.Provider, DryRunProvider)
+	}
+	if provider.sends.Load() != 0 {
+		t.Error("dry run called the provider")
+	}
+}
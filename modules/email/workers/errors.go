@@ -0,0 +1,69 @@
+package workers
+
+import (
+	"sync"
+	"time"
+
+	"github.com/thenasky/go-framework/modules/email/models"
+)
+
+// errorLogSize is the number of recent errors kept for diagnosis
+const errorLogSize = 50
+
+// errorLog is a fixed-size ring buffer of the most recent errors
+type errorLog struct {
+	mu      sync.Mutex
+	records [errorLogSize]models.ErrorRecord
+	next    int
+	count   int
+}
+
+// add records an error, overwriting the oldest one when full
+func (l *errorLog) add(record models.ErrorRecord) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.records[l.next] = record
+	l.next = (l.next + 1) % errorLogSize
+	if l.count < errorLogSize {
+		l.count++
+	}
+}
+
+// recent returns the recorded errors, newest first
+func (l *errorLog) recent() []models.ErrorRecord {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	records := make([]models.ErrorRecord, 0, l.count)
+	for i := 1; i <= l.count; i++ {
+		records = append(records, l.records[(l.next-i+errorLogSize)%errorLogSize])
+	}
+	return records
+}
+
+// last returns the most recent error, if any
+func (l *errorLog) last() (models.ErrorRecord, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.count == 0 {
+		return models.ErrorRecord{}, false
+	}
+	return l.records[(l.next-1+errorLogSize)%errorLogSize], true
+}
+
+// RecordError records a failed queue or provider operation for GetStats and RecentErrors
+func (w *EmailWorker) RecordError(operation, jobID string, err error) {
+	w.errors.add(models.ErrorRecord{
+		Operation:  operation,
+		JobID:      jobID,
+		Message:    err.Error(),
+		OccurredAt: time.Now(),
+	})
+}
+
+// RecentErrors returns the most recent queue and provider errors, newest first
+func (w *EmailWorker) RecentErrors() []models.ErrorRecord {
+	return w.errors.recent()
+}